@@ -0,0 +1,236 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/lxn/walk"
+	. "github.com/lxn/walk/declarative"
+
+	"erp-connector/internal/config"
+	"erp-connector/internal/logger"
+)
+
+// mappingEdits holds the widgets of one documentType's mapping.
+type mappingEdits struct {
+	documentID, foreignID, currencyIDs *walk.LineEdit
+	warehouse, copies, unit, agent     *walk.LineEdit
+	remarks                            *walk.LineEdit
+}
+
+// showDocumentMappingDialog edits hasavshevet.localCurrency and
+// hasavshevet.sendOrder. Empty fields keep the defaults, shown as cue
+// banners. Changes are validated and saved to config on Save.
+func showDocumentMappingDialog(owner walk.Form, cfg *config.Config, logSvc logger.LoggerService) {
+	var dlg *walk.Dialog
+	var statusLabel *walk.Label
+	var localCurrencyEdit *walk.LineEdit
+
+	setStatus := func(text string) {
+		if statusLabel != nil {
+			statusLabel.SetText(text)
+		}
+	}
+
+	defaults := config.DefaultHasavshevetDocumentMappings()
+	edits := make(map[string]*mappingEdits)
+	children := []Widget{
+		Label{Text: "Local currency code"},
+		LineEdit{AssignTo: &localCurrencyEdit, CueBanner: config.DefaultHasavshevetLocalCurrency},
+	}
+	for _, docType := range config.HasavshevetDocumentTypes() {
+		d := defaults[docType]
+		e := &mappingEdits{}
+		edits[docType] = e
+		children = append(children,
+			HSeparator{},
+			Label{Text: docType, Font: Font{Bold: true}},
+			Label{Text: "Document ID (local currency)"},
+			LineEdit{AssignTo: &e.documentID, CueBanner: strconv.Itoa(d.DocumentID)},
+			Label{Text: "Document ID (foreign currency, empty = same)"},
+			LineEdit{AssignTo: &e.foreignID, CueBanner: formatOptionalInt(d.ForeignCurrencyDocumentID)},
+			Label{Text: "Document ID per currency, e.g. $=32, EUR=33"},
+			LineEdit{AssignTo: &e.currencyIDs},
+			Label{Text: "Warehouse / Copies / Unit"},
+			Composite{
+				Layout: HBox{MarginsZero: true},
+				Children: []Widget{
+					LineEdit{AssignTo: &e.warehouse, CueBanner: strconv.Itoa(d.Warehouse)},
+					LineEdit{AssignTo: &e.copies, CueBanner: strconv.Itoa(d.Copies)},
+					LineEdit{AssignTo: &e.unit, CueBanner: d.Unit},
+				},
+			},
+			Label{Text: "Agent override (empty = customer's agent)"},
+			LineEdit{AssignTo: &e.agent},
+			Label{Text: "Remarks template: " + strings.Join(config.HasavshevetRemarksPlaceholders(), " ")},
+			LineEdit{AssignTo: &e.remarks, CueBanner: d.RemarksTemplate},
+		)
+	}
+	children = append(children, HSeparator{}, Label{AssignTo: &statusLabel})
+
+	err := (Dialog{
+		AssignTo: &dlg,
+		Title:    "Hasavshevet Document Mapping",
+		MinSize:  Size{Width: 460, Height: 500},
+		Size:     Size{Width: 480, Height: 680},
+		Layout:   VBox{},
+		Children: []Widget{
+			ScrollView{Layout: VBox{}, Children: children},
+			Composite{
+				Layout: HBox{},
+				Children: []Widget{
+					HSpacer{},
+					PushButton{Text: "Save", OnClicked: func() {
+						next := *cfg
+						next.Hasavshevet.LocalCurrency = strings.TrimSpace(localCurrencyEdit.Text())
+						next.Hasavshevet.SendOrder = nil
+						for _, docType := range config.HasavshevetDocumentTypes() {
+							m, err := edits[docType].read()
+							if err != nil {
+								setStatus(docType + ": " + err.Error())
+								return
+							}
+							if m.DocumentID != 0 || m.ForeignCurrencyDocumentID != 0 || len(m.CurrencyDocumentIDs) > 0 ||
+								m.Warehouse != 0 || m.Copies != 0 || m.Unit != "" || m.Agent != "" || m.RemarksTemplate != "" {
+								if next.Hasavshevet.SendOrder == nil {
+									next.Hasavshevet.SendOrder = make(map[string]config.HasavshevetDocumentMapping)
+								}
+								next.Hasavshevet.SendOrder[docType] = m
+							}
+						}
+						if err := next.ValidateCompanies(); err != nil {
+							setStatus(err.Error())
+							return
+						}
+
+						setStatus("Saving...")
+						go func() {
+							if err := config.Save(next); err != nil {
+								if logSvc != nil {
+									logSvc.Error("document mapping: failed to save config", err)
+								}
+								dlg.Synchronize(func() { setStatus("Failed to save config: " + err.Error()) })
+								return
+							}
+							if logSvc != nil {
+								logSvc.Info(fmt.Sprintf("document mapping saved: localCurrency=%q types=%v — RESTART erp-connectord daemon for changes to take effect",
+									next.Hasavshevet.LocalCurrency, slices.Sorted(maps.Keys(next.Hasavshevet.SendOrder))))
+							}
+							dlg.Synchronize(func() {
+								*cfg = next
+								setStatus("נשמר בהצלחה. הפעל מחדש את erp-connectord (restart the daemon) כדי שהשינויים ייכנסו לתוקף.")
+							})
+						}()
+					}},
+					PushButton{Text: "Close", OnClicked: func() {
+						dlg.Accept()
+					}},
+				},
+			},
+		},
+	}).Create(owner)
+
+	if err != nil {
+		walk.MsgBox(owner, "Error", "Failed to create document mapping window: "+err.Error(), walk.MsgBoxIconError)
+		return
+	}
+
+	// Populate fields from current config
+	localCurrencyEdit.SetText(cfg.Hasavshevet.LocalCurrency)
+	for docType, e := range edits {
+		e.write(cfg.Hasavshevet.SendOrder[docType])
+	}
+
+	dlg.Run()
+}
+
+func (e *mappingEdits) write(m config.HasavshevetDocumentMapping) {
+	e.documentID.SetText(formatOptionalInt(m.DocumentID))
+	e.foreignID.SetText(formatOptionalInt(m.ForeignCurrencyDocumentID))
+	e.currencyIDs.SetText(formatCurrencyIDs(m.CurrencyDocumentIDs))
+	e.warehouse.SetText(formatOptionalInt(m.Warehouse))
+	e.copies.SetText(formatOptionalInt(m.Copies))
+	e.unit.SetText(m.Unit)
+	e.agent.SetText(m.Agent)
+	e.remarks.SetText(m.RemarksTemplate)
+}
+
+func (e *mappingEdits) read() (config.HasavshevetDocumentMapping, error) {
+	var m config.HasavshevetDocumentMapping
+	var err error
+	if m.DocumentID, err = parseOptionalInt("document ID", e.documentID.Text()); err != nil {
+		return m, err
+	}
+	if m.ForeignCurrencyDocumentID, err = parseOptionalInt("foreign currency document ID", e.foreignID.Text()); err != nil {
+		return m, err
+	}
+	if m.CurrencyDocumentIDs, err = parseCurrencyIDs(e.currencyIDs.Text()); err != nil {
+		return m, err
+	}
+	if m.Warehouse, err = parseOptionalInt("warehouse", e.warehouse.Text()); err != nil {
+		return m, err
+	}
+	if m.Copies, err = parseOptionalInt("copies", e.copies.Text()); err != nil {
+		return m, err
+	}
+	m.Unit = strings.TrimSpace(e.unit.Text())
+	m.Agent = strings.TrimSpace(e.agent.Text())
+	m.RemarksTemplate = strings.TrimSpace(e.remarks.Text())
+	return m, nil
+}
+
+func formatOptionalInt(v int) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.Itoa(v)
+}
+
+func parseOptionalInt(name, text string) (int, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return 0, nil
+	}
+	v, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, fmt.Errorf("%s %q is not a number", name, text)
+	}
+	return v, nil
+}
+
+// parseCurrencyIDs parses "$=32, EUR=33" into a currency → DocumentID map.
+func parseCurrencyIDs(text string) (map[string]int, error) {
+	var out map[string]int
+	for _, part := range strings.Split(text, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		currency, id, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q must be currency=documentId", part)
+		}
+		v, err := parseOptionalInt("document ID", id)
+		if err != nil {
+			return nil, err
+		}
+		if out == nil {
+			out = make(map[string]int)
+		}
+		out[strings.TrimSpace(currency)] = v
+	}
+	return out, nil
+}
+
+func formatCurrencyIDs(ids map[string]int) string {
+	parts := make([]string, 0, len(ids))
+	for _, currency := range slices.Sorted(maps.Keys(ids)) {
+		parts = append(parts, fmt.Sprintf("%s=%d", currency, ids[currency]))
+	}
+	return strings.Join(parts, ", ")
+}
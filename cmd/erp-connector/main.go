@@ -21,7 +21,9 @@ import (
 
 	"erp-connector/internal/config"
 	"erp-connector/internal/db"
+	"erp-connector/internal/db/migrate"
 	"erp-connector/internal/erp/hasavshevet"
+	"erp-connector/internal/erp/sap"
 	"erp-connector/internal/logger"
 	"erp-connector/internal/platform/autostart"
 	"erp-connector/internal/secrets"
@@ -52,6 +54,11 @@ type mainForm struct {
 	passEdit    *walk.LineEdit
 	erpUserEdit *walk.LineEdit
 
+	instanceEdit     *walk.LineEdit
+	encryptCombo     *walk.ComboBox
+	trustCertCheck   *walk.CheckBox
+	windowsAuthCheck *walk.CheckBox
+
 	foldersComposite *walk.Composite
 	folderEdits      []*walk.LineEdit
 
@@ -59,6 +66,13 @@ type mainForm struct {
 	sendOrderEdit    *walk.LineEdit
 	hasBatEdit       *walk.LineEdit
 
+	sapSection      *walk.Composite
+	slURLEdit       *walk.LineEdit
+	slUserEdit      *walk.LineEdit
+	slPassEdit      *walk.LineEdit
+	slWarehouseEdit *walk.LineEdit
+	slDraftsCheck   *walk.CheckBox
+
 	statusLabel *walk.Label
 }
 
@@ -121,6 +135,15 @@ func newMainForm(cfg config.Config, logSvc logger.LoggerService) (*mainForm, err
 						PasswordMode: true,
 						CueBanner:    "Leave blank to keep existing",
 					},
+					Label{Text: "Named instance (optional)"},
+					LineEdit{AssignTo: &f.instanceEdit, CueBanner: "e.g. SQLEXPRESS — port is then resolved by SQL Browser"},
+					Label{Text: "Encrypt"},
+					ComboBox{
+						AssignTo: &f.encryptCombo,
+						Model:    config.DBEncryptValues(),
+					},
+					CheckBox{AssignTo: &f.trustCertCheck, Text: "Trust server certificate"},
+					CheckBox{AssignTo: &f.windowsAuthCheck, Text: "Windows authentication (ignore User/Password)"},
 					Label{Text: "ERP User"},
 					Composite{
 						Layout: HBox{MarginsZero: true},
@@ -167,6 +190,24 @@ func newMainForm(cfg config.Config, logSvc logger.LoggerService) (*mainForm, err
 									PushButton{Text: "Browse...", OnClicked: f.onBrowseHasBat},
 								},
 							},
+							PushButton{Text: "Document mapping...", OnClicked: f.onOpenDocumentMapping},
+						},
+					},
+
+					// ── SAP-only section ──────────────────────────────────
+					Composite{
+						AssignTo: &f.sapSection,
+						Layout:   VBox{MarginsZero: true},
+						Children: []Widget{
+							Label{Text: "SAP Service Layer URL (orders)"},
+							LineEdit{AssignTo: &f.slURLEdit, CueBanner: "e.g. https://sap-host:50000/b1s/v1 — empty disables sendOrder"},
+							Label{Text: "Service Layer user"},
+							LineEdit{AssignTo: &f.slUserEdit},
+							Label{Text: "Service Layer password"},
+							LineEdit{AssignTo: &f.slPassEdit, PasswordMode: true, CueBanner: "Leave blank to keep existing"},
+							Label{Text: "Default warehouse (optional)"},
+							LineEdit{AssignTo: &f.slWarehouseEdit},
+							CheckBox{AssignTo: &f.slDraftsCheck, Text: "Create documents as drafts"},
 						},
 					},
 
@@ -201,9 +242,17 @@ func newMainForm(cfg config.Config, logSvc logger.LoggerService) (*mainForm, err
 	f.portEdit.SetText(strconv.Itoa(cfg.DB.Port))
 	f.userEdit.SetText(cfg.DB.User)
 	f.dbNameEdit.SetText(cfg.DB.Database)
+	f.instanceEdit.SetText(cfg.DB.Instance)
+	f.setComboByValue(f.encryptCombo, config.DBEncryptValues(), cfg.DB.Encrypt)
+	f.trustCertCheck.SetChecked(cfg.DB.TrustServerCertificate)
+	f.windowsAuthCheck.SetChecked(cfg.DB.WindowsAuth)
 	f.erpUserEdit.SetText(cfg.ERPUser)
 	f.sendOrderEdit.SetText(cfg.SendOrderDir)
 	f.hasBatEdit.SetText(cfg.HasBatFile)
+	f.slURLEdit.SetText(cfg.SAP.ServiceLayerURL)
+	f.slUserEdit.SetText(cfg.SAP.User)
+	f.slWarehouseEdit.SetText(cfg.SAP.DefaultWarehouse)
+	f.slDraftsCheck.SetChecked(cfg.SAP.Drafts)
 
 	// Populate dynamic folder list.
 	if len(cfg.ImageFolders) == 0 {
@@ -275,6 +324,7 @@ func (f *mainForm) addFolderRow(path string) {
 
 func (f *mainForm) updateSendOrderVisibility(erp config.ERPType) {
 	f.sendOrderSection.SetVisible(erp == config.ERPHasavshevet)
+	f.sapSection.SetVisible(erp == config.ERPSAP)
 }
 
 func (f *mainForm) setStatus(text string) {
@@ -326,6 +376,10 @@ func (f *mainForm) onOpenPDFSettings() {
 	showPDFSettingsDialog(f.MainWindow, &f.cfg, f.logSvc)
 }
 
+func (f *mainForm) onOpenDocumentMapping() {
+	showDocumentMappingDialog(f.MainWindow, &f.cfg, f.logSvc)
+}
+
 func (f *mainForm) onDocumentation() {
 	if err := exec.Command("cmd", "/c", "start", documentationURL).Start(); err != nil {
 		f.setStatus("Failed to open documentation: " + err.Error())
@@ -349,6 +403,7 @@ func (f *mainForm) onTestUser() {
 	tmp.DB.Port = p
 	tmp.DB.User = f.userEdit.Text()
 	tmp.DB.Database = f.dbNameEdit.Text()
+	f.readDBOptions(&tmp.DB)
 	pass := f.passEdit.Text()
 
 	f.setStatus("Testing user...")
@@ -387,6 +442,7 @@ func (f *mainForm) onTestConnection() {
 	tmp.DB.Port = p
 	tmp.DB.User = f.userEdit.Text()
 	tmp.DB.Database = f.dbNameEdit.Text()
+	f.readDBOptions(&tmp.DB)
 	pass := f.passEdit.Text()
 
 	f.setStatus("Testing connection...")
@@ -543,12 +599,19 @@ func (f *mainForm) onRestartServer() {
 
 // ── Config helpers ───────────────────────────────────────────────────────────
 
+// formSecrets are the passwords typed into the form; empty keeps the stored
+// value.
+type formSecrets struct {
+	db           string
+	serviceLayer string
+}
+
 // readFormConfig reads all widget values on the UI thread and returns a Config
-// and the password string. Must be called from the UI goroutine.
-func (f *mainForm) readFormConfig() (config.Config, string, error) {
+// and the passwords. Must be called from the UI goroutine.
+func (f *mainForm) readFormConfig() (config.Config, formSecrets, error) {
 	p, ok := f.parsePort()
 	if !ok {
-		return config.Config{}, "", fmt.Errorf("invalid DB Port")
+		return config.Config{}, formSecrets{}, fmt.Errorf("invalid DB Port")
 	}
 
 	cfg := f.cfg
@@ -561,10 +624,11 @@ func (f *mainForm) readFormConfig() (config.Config, string, error) {
 	cfg.DB.Port = p
 	cfg.DB.User = f.userEdit.Text()
 	cfg.DB.Database = f.dbNameEdit.Text()
+	f.readDBOptions(&cfg.DB)
 	cfg.ERPUser = strings.TrimSpace(f.erpUserEdit.Text())
 
 	if cfg.ERP == config.ERPHasavshevet && strings.TrimSpace(cfg.DB.Database) == "" {
-		return config.Config{}, "", fmt.Errorf("DB database is required for Hasavshevet")
+		return config.Config{}, formSecrets{}, fmt.Errorf("DB database is required for Hasavshevet")
 	}
 
 	folders := make([]string, 0, len(f.folderEdits))
@@ -583,7 +647,25 @@ func (f *mainForm) readFormConfig() (config.Config, string, error) {
 		cfg.HasBatFile = ""
 	}
 
-	return cfg, f.passEdit.Text(), nil
+	pass := formSecrets{db: f.passEdit.Text()}
+	if cfg.ERP == config.ERPSAP {
+		cfg.SAP.ServiceLayerURL = strings.TrimSpace(f.slURLEdit.Text())
+		cfg.SAP.User = strings.TrimSpace(f.slUserEdit.Text())
+		cfg.SAP.DefaultWarehouse = strings.TrimSpace(f.slWarehouseEdit.Text())
+		cfg.SAP.Drafts = f.slDraftsCheck.Checked()
+		pass.serviceLayer = f.slPassEdit.Text()
+	}
+
+	return cfg, pass, nil
+}
+
+// readDBOptions copies the optional connection fields (instance, encryption,
+// auth mode) into dbCfg. UI thread only.
+func (f *mainForm) readDBOptions(dbCfg *config.DBConfig) {
+	dbCfg.Instance = strings.TrimSpace(f.instanceEdit.Text())
+	dbCfg.Encrypt = comboValue(f.encryptCombo, config.DBEncryptValues())
+	dbCfg.TrustServerCertificate = f.trustCertCheck.Checked()
+	dbCfg.WindowsAuth = f.windowsAuthCheck.Checked()
 }
 
 // parsePort parses and validates the port field. UI thread only.
@@ -597,8 +679,9 @@ func (f *mainForm) parsePort() (int, bool) {
 
 // persistConfig performs all I/O: DB procedure setup, password save, config save.
 // Safe to call from a background goroutine.
-func persistConfig(cfg config.Config, password string, logSvc logger.LoggerService) error {
-	pw, err := resolveDBPassword(cfg.ERP, password, cfg.ERP == config.ERPHasavshevet)
+func persistConfig(cfg config.Config, pass formSecrets, logSvc logger.LoggerService) error {
+	password := pass.db
+	pw, err := resolveDBPassword(cfg.ERP, password, cfg.ERP == config.ERPHasavshevet && !cfg.DB.WindowsAuth)
 	if err != nil {
 		return err
 	}
@@ -613,26 +696,20 @@ func persistConfig(cfg config.Config, password string, logSvc logger.LoggerServi
 		}
 		defer dbConn.Close()
 
-		created, err := hasavshevet.EnsureGPriceBulkProcedure(ctx, dbConn)
-		if err != nil {
-			logSvc.Error("failed to initialize GPRICE_Bulk", err)
-			return fmt.Errorf("failed to initialize GPRICE_Bulk: %w", err)
-		}
-		if created {
-			logSvc.Success("GPRICE_Bulk created")
-		} else {
-			logSvc.Info("GPRICE_Bulk already exists")
-		}
-
-		created, err = hasavshevet.EnsureOnHandStockForSkusProcedure(ctx, dbConn)
+		res, err := migrate.Apply(ctx, dbConn, hasavshevet.DBObjects(), false)
 		if err != nil {
-			logSvc.Error("failed to initialize GetOnHandStockForSkus", err)
-			return fmt.Errorf("failed to initialize GetOnHandStockForSkus: %w", err)
+			logSvc.Error("failed to install Hasavshevet procedures", err)
+			return fmt.Errorf("failed to install Hasavshevet procedures: %w", err)
 		}
-		if created {
-			logSvc.Success("GetOnHandStockForSkus created")
-		} else {
-			logSvc.Info("GetOnHandStockForSkus already exists")
+		for _, st := range res.Before {
+			switch {
+			case st.NeedsInstall():
+				logSvc.Success(fmt.Sprintf("%s installed (version %d, was %s)", st.Name, st.Version, st.State))
+			case st.State == migrate.StateNewer:
+				logSvc.Warn(fmt.Sprintf("%s is at version %d, newer than this connector's %d; left unchanged", st.Name, st.InstalledVersion, st.Version))
+			default:
+				logSvc.Info(fmt.Sprintf("%s is up to date", st.Name))
+			}
 		}
 	}
 
@@ -641,6 +718,11 @@ func persistConfig(cfg config.Config, password string, logSvc logger.LoggerServi
 			return fmt.Errorf("failed to save password: %w", err)
 		}
 	}
+	if pass.serviceLayer != "" {
+		if err := secrets.Set(sap.ServiceLayerPasswordKey, []byte(pass.serviceLayer)); err != nil {
+			return fmt.Errorf("failed to save Service Layer password: %w", err)
+		}
+	}
 
 	if err := config.Save(cfg); err != nil {
 		return fmt.Errorf("error saving config: %w", err)
@@ -2,21 +2,23 @@ package main
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"os/user"
-	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"erp-connector/internal/api"
 	"erp-connector/internal/config"
 	"erp-connector/internal/db"
 	"erp-connector/internal/email"
+	"erp-connector/internal/erp"
 	"erp-connector/internal/erp/hasavshevet"
+	_ "erp-connector/internal/erp/priority"
+	_ "erp-connector/internal/erp/sap"
 	"erp-connector/internal/logger"
 	"erp-connector/internal/pdf"
 	"erp-connector/internal/platform/autostart"
@@ -27,14 +29,33 @@ import (
 const windowsServiceName = "erp-connectord"
 
 type serverApp struct {
-	cfg          config.Config
-	logSvc       logger.LoggerService
-	dbConn       *sql.DB
-	srv          *http.Server
-	errCh        chan error
-	dbPassStr    string
-	orderQueue   *hasavshevet.OrderQueue
-	queueCancel  context.CancelFunc
+	cfg       config.Config
+	logSvc    logger.LoggerService
+	companies []*companyRuntime
+	srv       *http.Server
+	errCh     chan error
+}
+
+// companyRuntime is the database handle and ERP adapter of one company
+// profile. Each company gets its own pool and single-writer queue so a slow
+// or unreachable company database never blocks another company.
+type companyRuntime struct {
+	name        string
+	cfg         config.Config
+	dbHandle    *db.Handle
+	dbCancel    context.CancelFunc
+	adapter     erp.Adapter
+	queueCancel context.CancelFunc
+}
+
+// deps returns the API dependencies of the company.
+func (rt *companyRuntime) deps() api.CompanyDeps {
+	return api.CompanyDeps{
+		Name:    rt.name,
+		Config:  rt.cfg,
+		DB:      rt.dbHandle,
+		Adapter: rt.adapter,
+	}
 }
 
 func (a *serverApp) Start() error {
@@ -54,7 +75,7 @@ func (a *serverApp) Start() error {
 		return err
 	}
 	a.cfg = cfg
-	bootstrapLog.Info(fmt.Sprintf("config loaded: erp=%s apiListen=%s sendOrderDir=%q", cfg.ERP, cfg.APIListen, cfg.SendOrderDir))
+	bootstrapLog.Info(fmt.Sprintf("config loaded: erp=%s apiListen=%s sendOrderDir=%q companies=%v", cfg.ERP, cfg.APIListen, cfg.SendOrderDir, cfg.CompanyNames()))
 
 	bootstrapLog.Info("calling logger.New(cfg)")
 	logSvc, err := logger.New(cfg)
@@ -65,35 +86,143 @@ func (a *serverApp) Start() error {
 	a.logSvc = logSvc
 	bootstrapLog.Info("logger.New(cfg) returned")
 
-	logSvc.Info(fmt.Sprintf("calling secrets.Get for db password (key=%s)", dbPasswordKey(cfg.ERP)))
-	dbPassword, dbPassErr := secrets.Get(dbPasswordKey(cfg.ERP))
+	if err := cfg.ValidateCompanies(); err != nil {
+		logSvc.Error("invalid company configuration", err)
+		return err
+	}
+
+	names := cfg.CompanyNames()
+	if len(names) == 0 {
+		names = []string{""}
+	}
+	var companyDeps []api.CompanyDeps
+	for _, name := range names {
+		companyCfg, err := cfg.ForCompany(name)
+		if err != nil {
+			logSvc.Error("company configuration error", err)
+			a.Stop(context.Background())
+			return err
+		}
+		rt, err := a.startCompany(name, companyCfg)
+		if err != nil {
+			logSvc.Error("company startup error", err)
+			a.Stop(context.Background())
+			return err
+		}
+		a.companies = append(a.companies, rt)
+		companyDeps = append(companyDeps, rt.deps())
+	}
+
+	srv, err := api.NewServer(cfg, api.ServerDeps{
+		Logger:    logSvc,
+		Companies: companyDeps,
+	})
+	if err != nil {
+		logSvc.Error("config validation error", err)
+		a.Stop(context.Background())
+		return err
+	}
+	a.srv = srv
+
+	a.errCh = make(chan error, 1)
+	go func() {
+		a.errCh <- srv.ListenAndServe()
+	}()
+	logSvc.Info(fmt.Sprintf("HTTP server goroutine launched, will listen on %s", srv.Addr))
+
+	logSvc.Info(fmt.Sprintf("erp-connectord listening on %s", srv.Addr))
+	return nil
+}
+
+// startCompany opens the company's database handle (for ERPs read over SQL)
+// and builds its ERP adapter with the post-order hooks. name is empty in
+// single-company mode.
+func (a *serverApp) startCompany(name string, cfg config.Config) (*companyRuntime, error) {
+	rt := &companyRuntime{name: name, cfg: cfg}
+	logSvc := a.logSvc
+	if name != "" {
+		logSvc = companyLogger{prefix: "[" + name + "] ", LoggerService: a.logSvc}
+	}
+
+	reg, ok := erp.Lookup(cfg.ERP)
+	if !ok {
+		return nil, fmt.Errorf("erp %q: %w", cfg.ERP, erp.ErrNotSupported)
+	}
+
+	passKey := companyDBPasswordKey(cfg.ERP, name)
+	logSvc.Info(fmt.Sprintf("calling secrets.Get for db password (key=%s)", passKey))
+	dbPassStr, dbPassErr := loadDBPassword(cfg.ERP, name)
 	if dbPassErr != nil {
 		logSvc.Error("failed to load db password", dbPassErr)
+	} else {
+		logSvc.Info(fmt.Sprintf("db password loaded (length=%d)", len(dbPassStr)))
 	}
-	if dbPassErr == nil {
-		a.dbPassStr = string(dbPassword)
-		logSvc.Info(fmt.Sprintf("db password loaded (length=%d)", len(a.dbPassStr)))
+
+	if reg.UsesSQL {
+		a.startDB(rt, dbPassStr, logSvc)
+	} else {
+		logSvc.Info(fmt.Sprintf("%s: serviceURL=%s user=%s", cfg.ERP, cfg.Priority.ServiceURL, cfg.Priority.User))
+	}
+
+	adapter, err := reg.New(erp.Deps{
+		Config:   cfg,
+		DB:       rt.dbHandle,
+		Password: dbPassStr,
+		Secret:   func(key string) (string, error) { return companySecret(key, name) },
+		Logger:   logSvc,
+		Hooks:    a.postOrderHooks(cfg, logSvc),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s adapter: %w", cfg.ERP, err)
+	}
+	rt.adapter = adapter
+
+	queueCtx, queueCancel := context.WithCancel(context.Background())
+	rt.queueCancel = queueCancel
+	adapter.Start(queueCtx)
+	logSvc.Info(fmt.Sprintf("%s adapter capabilities: %v", cfg.ERP, adapter.Capabilities()))
+
+	if !reg.UsesSQL {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := adapter.Health(ctx); err != nil {
+			logSvc.Warn(fmt.Sprintf("%s service not reachable at startup: %v", cfg.ERP, err))
+		} else {
+			logSvc.Success(fmt.Sprintf("%s service reachable", cfg.ERP))
+		}
 	}
+	return rt, nil
+}
 
+// startDB opens the company's SQL Server handle and keeps reconnecting in the
+// background.
+func (a *serverApp) startDB(rt *companyRuntime, password string, logSvc logger.LoggerService) {
+	cfg := rt.cfg
+	dbOpts := db.OptionsFromConfig(cfg.DB)
 	logSvc.Info(fmt.Sprintf(
-		"calling db.Open: driver=%s host=%s port=%d database=%s user=%s",
+		"calling db.Open: driver=%s host=%s port=%d database=%s user=%s maxOpen=%d maxIdle=%d idleTime=%s lifetime=%s",
 		cfg.DB.Driver, cfg.DB.Host, cfg.DB.Port, cfg.DB.Database, cfg.DB.User,
+		dbOpts.MaxOpenConns, dbOpts.MaxIdleConns, dbOpts.ConnMaxIdleTime, dbOpts.ConnMaxLifetime,
 	))
-	dbConn, err := db.Open(cfg, a.dbPassStr, db.DefaultOptions())
-	if err != nil {
-		logSvc.Error("db connection failed", err)
-		a.Stop(context.Background())
-		return err
+	// The HTTP server starts even when SQL Server is not reachable yet (e.g.
+	// it boots slower than this service). DB-backed routes answer
+	// DB_UNAVAILABLE until the handle connects; the order queue starts on the
+	// first successful connection.
+	dbHandle := db.NewHandle(cfg, password, dbOpts, logSvc)
+	rt.dbHandle = dbHandle
+	if err := dbHandle.Connect(context.Background()); err != nil {
+		logSvc.Warn(fmt.Sprintf("initial db connection failed; starting in degraded mode and retrying in background: %v", err))
+	} else {
+		logSvc.Info("initial db connection succeeded")
 	}
-	a.dbConn = dbConn
-	logSvc.Info("db.Open returned successfully")
-
-	// Build the send-order queue for Hasavshevet.
-	// Order number file lives next to IMOVEIN files for self-contained directory.
-	numStorePath := filepath.Join(cfg.SendOrderDir, "lastOrderNumber.json")
-	numStore := hasavshevet.NewOrderNumberStore(numStorePath)
-	sender := hasavshevet.NewSender(dbConn, cfg, numStore, logSvc)
+	dbCtx, dbCancel := context.WithCancel(context.Background())
+	rt.dbCancel = dbCancel
+	go dbHandle.Run(dbCtx)
+}
 
+// postOrderHooks builds the post-order hooks (PDF generation, printing,
+// email) configured for the company.
+func (a *serverApp) postOrderHooks(cfg config.Config, logSvc logger.LoggerService) []erp.PostOrderHook {
 	// Set up post-order hooks (PDF generation, printing, email).
 	logSvc.Info(fmt.Sprintf(
 		"PDF config snapshot at startup: PrintAfterOrder=%v EmailAfterOrder=%v UseRemoteTemplate=%v RemoteTemplateBaseURL=%q tokenCount=%d ChromePath=%q SumatraPDFPath=%q PrinterName=%q",
@@ -105,7 +234,7 @@ func (a *serverApp) Start() error {
 	if cfg.PDF.PrintAfterOrder {
 		logVisiblePrintersAndValidate(logSvc, cfg.PDF.PrinterName)
 	}
-	var postHooks []hasavshevet.PostOrderHook
+	var postHooks []erp.PostOrderHook
 	if cfg.PDF.PrintAfterOrder || cfg.PDF.EmailAfterOrder {
 		chromePath := cfg.PDF.ChromePath
 		if chromePath == "" {
@@ -134,47 +263,26 @@ func (a *serverApp) Start() error {
 		logSvc.Warn("no PDF post-order hook registered: both PrintAfterOrder and EmailAfterOrder are false in config — toggle them in the GUI Settings → PDF & Email Settings, click Save, then RESTART erp-connectord for changes to take effect")
 	}
 
-	queue := hasavshevet.NewOrderQueue(sender, logSvc, postHooks...)
-	queueCtx, queueCancel := context.WithCancel(context.Background())
-	queue.Start(queueCtx)
-	a.orderQueue = queue
-	a.queueCancel = queueCancel
-
-	srv, err := api.NewServer(cfg, api.ServerDeps{
-		DBPassword:     a.dbPassStr,
-		DB:             dbConn,
-		Logger:         logSvc,
-		SendOrderQueue: queue,
-	})
-	if err != nil {
-		logSvc.Error("config validation error", err)
-		a.Stop(context.Background())
-		return err
-	}
-	a.srv = srv
-
-	a.errCh = make(chan error, 1)
-	go func() {
-		a.errCh <- srv.ListenAndServe()
-	}()
-	logSvc.Info(fmt.Sprintf("HTTP server goroutine launched, will listen on %s", srv.Addr))
-
-	logSvc.Info(fmt.Sprintf("erp-connectord listening on %s", srv.Addr))
-	return nil
+	return postHooks
 }
 
 func (a *serverApp) Stop(ctx context.Context) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	if a.queueCancel != nil {
-		a.queueCancel()
+	for _, rt := range a.companies {
+		if rt.queueCancel != nil {
+			rt.queueCancel()
+		}
 	}
 	if a.srv != nil {
 		_ = a.srv.Shutdown(ctx)
 	}
-	if a.dbConn != nil {
-		_ = a.dbConn.Close()
+	for _, rt := range a.companies {
+		if rt.dbCancel != nil {
+			rt.dbCancel()
+		}
+		_ = rt.dbHandle.Close()
 	}
 	if a.logSvc != nil {
 		_ = a.logSvc.Close()
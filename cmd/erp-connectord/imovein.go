@@ -0,0 +1,153 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"erp-connector/internal/config"
+	"erp-connector/internal/erp/hasavshevet"
+)
+
+// runIMOVEIN implements "erp-connectord imovein [-company NAME] [-all] show
+// ORDER|FILE" and "... diff A B": it decodes IMOVEIN.doc files with their
+// PRM, prints or compares them field by field and flags overflowed, short
+// and probably truncated fields. ORDER is an order number, read from
+// sendOrderDir/history/<ORDER>/; FILE is a path to a .doc.
+func runIMOVEIN(args []string, out io.Writer) int {
+	fs := flag.NewFlagSet("imovein", flag.ContinueOnError)
+	fs.SetOutput(out)
+	company := fs.String("company", "", "company whose sendOrderDir holds the history")
+	all := fs.Bool("all", false, "show blank fields too")
+	fs.Usage = func() {
+		fmt.Fprintln(out, "usage: erp-connectord imovein [-company NAME] [-all] show ORDER|FILE")
+		fmt.Fprintln(out, "       erp-connectord imovein [-company NAME] diff ORDER|FILE ORDER|FILE")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	rest := fs.Args()
+	var paths []string
+	switch {
+	case len(rest) == 2 && rest[0] == "show":
+		paths = rest[1:]
+	case len(rest) == 3 && rest[0] == "diff":
+		paths = rest[1:]
+	default:
+		fs.Usage()
+		return 2
+	}
+
+	files := make([]hasavshevet.IMOVEINFile, 0, len(paths))
+	for _, arg := range paths {
+		path, err := resolveIMOVEINPath(arg, *company)
+		if err != nil {
+			fmt.Fprintln(out, err)
+			return 1
+		}
+		f, err := hasavshevet.ReadIMOVEINFile(path)
+		if err != nil {
+			fmt.Fprintf(out, "read %s: %v\n", path, err)
+			return 1
+		}
+		files = append(files, f)
+	}
+
+	warned := false
+	if rest[0] == "show" {
+		warned = printIMOVEIN(out, files[0], *all)
+	} else {
+		for _, f := range files {
+			warned = printIMOVEINWarnings(out, f) || warned
+		}
+		printIMOVEINDiff(out, files[0], files[1])
+	}
+	if warned {
+		return 1
+	}
+	return 0
+}
+
+// resolveIMOVEINPath maps an order number to its history DOC; anything else
+// is taken as a path.
+func resolveIMOVEINPath(arg, company string) (string, error) {
+	orderNum, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return arg, nil
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return "", fmt.Errorf("load config: %w", err)
+	}
+	companyCfg, err := cfg.ForCompany(company)
+	if err != nil {
+		return "", err
+	}
+	if companyCfg.SendOrderDir == "" {
+		return "", fmt.Errorf("sendOrderDir is not configured; pass the .doc path instead")
+	}
+	return hasavshevet.HistoryDOCPath(companyCfg.SendOrderDir, orderNum), nil
+}
+
+func printIMOVEIN(out io.Writer, f hasavshevet.IMOVEINFile, all bool) bool {
+	warned := printIMOVEINWarnings(out, f)
+	for _, r := range f.Records {
+		fmt.Fprintf(out, "\nrecord %d (%d bytes)\n", r.Number, r.Length)
+		for _, v := range r.Values {
+			if v.Value == "" && !all && !v.Missing {
+				continue
+			}
+			mark := ""
+			switch {
+			case v.Missing:
+				mark = "  ! missing"
+			case v.Full:
+				mark = "  ! full, may be truncated"
+			}
+			fmt.Fprintf(out, "  %-7s %5d %4d  %-28s %s%s\n", v.Key, v.Start, v.Width, v.Title, v.Value, mark)
+		}
+	}
+	return warned
+}
+
+// printIMOVEINWarnings prints the file's header and its layout and record
+// warnings, and reports whether there were any.
+func printIMOVEINWarnings(out io.Writer, f hasavshevet.IMOVEINFile) bool {
+	layout := "default layout (no .prm)"
+	if f.PRMPath != "" {
+		layout = f.PRMPath
+	}
+	fmt.Fprintf(out, "%s: %d records, %s, %d-byte records\n", f.Path, len(f.Records), layout, f.Layout.RecordLength)
+
+	var warnings []string
+	for _, note := range f.LayoutNotes {
+		warnings = append(warnings, "prm: "+note)
+	}
+	for _, r := range f.Records {
+		warnings = append(warnings, r.Warnings(f.Layout)...)
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(out, "  ! %s\n", w)
+	}
+	return len(warnings) > 0
+}
+
+func printIMOVEINDiff(out io.Writer, a, b hasavshevet.IMOVEINFile) {
+	diffs := hasavshevet.DiffDOC(a.Records, b.Records)
+	if len(diffs) == 0 {
+		fmt.Fprintln(out, "\nno field differences")
+		return
+	}
+	fmt.Fprintln(out)
+	for _, d := range diffs {
+		field := "record"
+		if d.Key != "" {
+			field = fmt.Sprintf("%s %s", d.Key, strings.TrimSpace(d.Title))
+		}
+		fmt.Fprintf(out, "record %d  %-36s %q -> %q\n", d.Record, field, d.A, d.B)
+	}
+}
@@ -11,13 +11,73 @@ import (
 	"time"
 
 	"erp-connector/internal/config"
+	"erp-connector/internal/logger"
+	"erp-connector/internal/secrets"
 )
 
 func dbPasswordKey(erp config.ERPType) string {
 	return "db_password_" + string(erp)
 }
 
+// companyDBPasswordKey is the secret holding a company's own DB password.
+// The unnamed company uses the ERP-wide key written by the GUI.
+func companyDBPasswordKey(erp config.ERPType, company string) string {
+	if company == "" {
+		return dbPasswordKey(erp)
+	}
+	return dbPasswordKey(erp) + "_" + company
+}
+
+// loadDBPassword reads the company's DB password. Companies on the same SQL
+// Server usually share the login saved by the GUI, so a missing
+// company-specific secret falls back to the ERP-wide one.
+func loadDBPassword(erp config.ERPType, company string) (string, error) {
+	b, err := secrets.Get(companyDBPasswordKey(erp, company))
+	if err != nil && company != "" {
+		b, err = secrets.Get(dbPasswordKey(erp))
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// companySecret reads key_<company> and falls back to the connector-wide key.
+func companySecret(key, company string) (string, error) {
+	if company != "" {
+		if b, err := secrets.Get(key + "_" + company); err == nil {
+			return string(b), nil
+		}
+	}
+	b, err := secrets.Get(key)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// companyLogger prefixes every message with the company name so interleaved
+// queue and reconnect logs of several companies stay readable.
+type companyLogger struct {
+	prefix string
+	logger.LoggerService
+}
+
+func (l companyLogger) Info(msg string)             { l.LoggerService.Info(l.prefix + msg) }
+func (l companyLogger) Error(msg string, err error) { l.LoggerService.Error(l.prefix+msg, err) }
+func (l companyLogger) Warn(msg string)             { l.LoggerService.Warn(l.prefix + msg) }
+func (l companyLogger) Success(msg string)          { l.LoggerService.Success(l.prefix + msg) }
+
+// Close is a no-op: the shared logger is closed once by serverApp.Stop.
+func (l companyLogger) Close() error { return nil }
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:], os.Stdout))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "imovein" {
+		os.Exit(runIMOVEIN(os.Args[2:], os.Stdout))
+	}
 	if runAsService() {
 		return
 	}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"erp-connector/internal/config"
+	"erp-connector/internal/db"
+	"erp-connector/internal/db/migrate"
+	"erp-connector/internal/erp"
+)
+
+const migrateTimeout = 2 * time.Minute
+
+// runMigrate implements "erp-connectord migrate [-company NAME] [-dry-run]":
+// it installs or upgrades the connector's stored procedures in every
+// company database (or only NAME) and prints each object's state. With
+// -dry-run it prints the DDL instead of running it.
+func runMigrate(args []string, out io.Writer) int {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.SetOutput(out)
+	company := fs.String("company", "", "only migrate this company")
+	dryRun := fs.Bool("dry-run", false, "print the DDL without running it")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(out, "load config: %v\n", err)
+		return 1
+	}
+	if err := cfg.ValidateCompanies(); err != nil {
+		fmt.Fprintf(out, "invalid company configuration: %v\n", err)
+		return 1
+	}
+
+	names := cfg.CompanyNames()
+	if *company != "" {
+		names = []string{*company}
+	} else if len(names) == 0 {
+		names = []string{""}
+	}
+
+	failed := false
+	for _, name := range names {
+		label := name
+		if label == "" {
+			label = "default"
+		}
+		if err := migrateCompany(cfg, name, *dryRun, out); err != nil {
+			fmt.Fprintf(out, "[%s] %v\n", label, err)
+			failed = true
+		}
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+func migrateCompany(cfg config.Config, name string, dryRun bool, out io.Writer) error {
+	companyCfg, err := cfg.ForCompany(name)
+	if err != nil {
+		return err
+	}
+	prefix := ""
+	if name != "" {
+		prefix = "[" + name + "] "
+	}
+	objects := erp.DBObjects(companyCfg.ERP)
+	if len(objects) == 0 {
+		fmt.Fprintf(out, "%s%s: no connector SQL objects to install\n", prefix, companyCfg.ERP)
+		return nil
+	}
+
+	password, err := loadDBPassword(companyCfg.ERP, name)
+	if err != nil && !companyCfg.DB.WindowsAuth {
+		return fmt.Errorf("load db password: %w", err)
+	}
+	dbConn, err := db.Open(companyCfg, password, db.DefaultOptions())
+	if err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+	defer dbConn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), migrateTimeout)
+	defer cancel()
+	res, err := migrate.Apply(ctx, dbConn, objects, dryRun)
+	if err != nil {
+		return err
+	}
+
+	for _, st := range res.Before {
+		action := "unchanged"
+		if st.NeedsInstall() {
+			action = "installed"
+			if dryRun {
+				action = "would install"
+			}
+		}
+		fmt.Fprintf(out, "%s%-32s %-10s installed=%d connector=%d  %s\n",
+			prefix, st.Name, st.State, st.InstalledVersion, st.Version, action)
+	}
+	if dryRun {
+		fmt.Fprintln(out, res.DDL)
+	}
+	return nil
+}
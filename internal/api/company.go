@@ -0,0 +1,127 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"erp-connector/internal/api/dto"
+	"erp-connector/internal/api/middleware"
+	"erp-connector/internal/api/utils"
+)
+
+// CompanyHeader selects the company for a request. The path prefix
+// /api/companies/{name}/... does the same and takes precedence.
+const CompanyHeader = "X-Company"
+
+const companiesPath = "/api/companies"
+
+type routedCompany struct {
+	deps CompanyDeps
+	mux  *http.ServeMux
+}
+
+// companyRouter resolves the target company of an authenticated request,
+// checks the token may access it and hands the request to that company's mux.
+type companyRouter struct {
+	companies   []routedCompany
+	defaultName string
+}
+
+func (cr *companyRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	grant, _ := middleware.GrantFromContext(r.Context())
+
+	if r.URL.Path == companiesPath || r.URL.Path == companiesPath+"/" {
+		if r.Method != http.MethodGet {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed", "METHOD_NOT_ALLOWED", nil)
+			return
+		}
+		cr.listCompanies(w, grant)
+		return
+	}
+
+	name, rest, prefixed := splitCompanyPath(r.URL.Path)
+	if !prefixed {
+		name = strings.TrimSpace(r.Header.Get(CompanyHeader))
+	}
+
+	target, ok := cr.resolve(name)
+	if !ok {
+		if name == "" {
+			utils.WriteError(w, http.StatusBadRequest, "Company is required", "COMPANY_REQUIRED", map[string]any{
+				"header": CompanyHeader,
+			})
+			return
+		}
+		utils.WriteError(w, http.StatusNotFound, "Unknown company", "COMPANY_NOT_FOUND", map[string]any{
+			"company": name,
+		})
+		return
+	}
+	if target.deps.Name != "" && !grant.Allows(target.deps.Name) {
+		utils.WriteError(w, http.StatusForbidden, "Token is not allowed to access this company", "COMPANY_FORBIDDEN", map[string]any{
+			"company": target.deps.Name,
+		})
+		return
+	}
+
+	if prefixed {
+		r2 := new(http.Request)
+		*r2 = *r
+		u := *r.URL
+		u.Path = rest
+		u.RawPath = ""
+		r2.URL = &u
+		r = r2
+	}
+	target.mux.ServeHTTP(w, r)
+}
+
+// resolve returns the company for name, falling back to the default company
+// when name is empty. A single unnamed company serves every unnamed request.
+func (cr *companyRouter) resolve(name string) (routedCompany, bool) {
+	if name == "" {
+		if len(cr.companies) == 1 && cr.companies[0].deps.Name == "" {
+			return cr.companies[0], true
+		}
+		name = cr.defaultName
+		if name == "" {
+			return routedCompany{}, false
+		}
+	}
+	for _, c := range cr.companies {
+		if c.deps.Name != "" && strings.EqualFold(c.deps.Name, name) {
+			return c, true
+		}
+	}
+	return routedCompany{}, false
+}
+
+func (cr *companyRouter) listCompanies(w http.ResponseWriter, grant middleware.TokenGrant) {
+	out := dto.CompaniesResponse{Companies: []dto.CompanyInfo{}}
+	for _, c := range cr.companies {
+		if c.deps.Name != "" && !grant.Allows(c.deps.Name) {
+			continue
+		}
+		out.Companies = append(out.Companies, dto.CompanyInfo{
+			Name:        c.deps.Name,
+			ERP:         string(c.deps.Config.ERP),
+			Default:     len(cr.companies) == 1 || strings.EqualFold(c.deps.Name, cr.defaultName),
+			DBAvailable: c.deps.DB.Available(),
+		})
+	}
+	utils.WriteJSON(w, http.StatusOK, out)
+}
+
+// splitCompanyPath turns /api/companies/{name}/rest into (name, /api/rest).
+func splitCompanyPath(path string) (name, rest string, ok bool) {
+	const prefix = companiesPath + "/"
+	if !strings.HasPrefix(path, prefix) {
+		return "", "", false
+	}
+	tail := strings.TrimPrefix(path, prefix)
+	name, sub, _ := strings.Cut(tail, "/")
+	if name == "" {
+		return "", "", false
+	}
+	return name, "/api/" + sub, true
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"erp-connector/internal/api/dto"
+	"erp-connector/internal/api/middleware"
+	"erp-connector/internal/config"
+)
+
+// echoMux answers every /api/ route with the company name and the path the
+// company mux saw.
+func echoMux(name string) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Served-By", name)
+		w.Header().Set("X-Path", r.URL.Path)
+	})
+	return mux
+}
+
+func newTestRouter(defaultName string, names ...string) http.Handler {
+	cr := &companyRouter{defaultName: defaultName}
+	for _, n := range names {
+		cr.companies = append(cr.companies, routedCompany{
+			deps: CompanyDeps{Name: n, Config: config.Config{ERP: config.ERPHasavshevet}},
+			mux:  echoMux(n),
+		})
+	}
+	return middleware.AuthTokens([]middleware.TokenGrant{
+		{Token: "master"},
+		{Token: "acme-only", Companies: []string{"acme"}},
+	}, cr)
+}
+
+func doRequest(h http.Handler, token, path, company string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	if company != "" {
+		req.Header.Set(CompanyHeader, company)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestCompanyRouter_Selection(t *testing.T) {
+	h := newTestRouter("", "acme", "globex")
+
+	tests := []struct {
+		name, token, path, header string
+		wantCode                  int
+		wantCompany, wantPath     string
+	}{
+		{"header", "master", "/api/health", "globex", 200, "globex", "/api/health"},
+		{"header case-insensitive", "master", "/api/health", "ACME", 200, "acme", "/api/health"},
+		{"path prefix", "master", "/api/companies/globex/sendOrder", "", 200, "globex", "/api/sendOrder"},
+		{"prefix beats header", "master", "/api/companies/acme/health", "globex", 200, "acme", "/api/health"},
+		{"no company, no default", "master", "/api/health", "", 400, "", ""},
+		{"unknown company", "master", "/api/health", "initech", 404, "", ""},
+		{"restricted token allowed", "acme-only", "/api/health", "acme", 200, "acme", "/api/health"},
+		{"restricted token forbidden", "acme-only", "/api/companies/globex/health", "", 403, "", ""},
+		{"bad token", "nope", "/api/health", "acme", 401, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(h, tt.token, tt.path, tt.header)
+			if w.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d; body: %s", w.Code, tt.wantCode, w.Body.String())
+			}
+			if got := w.Header().Get("X-Served-By"); got != tt.wantCompany {
+				t.Errorf("served by %q, want %q", got, tt.wantCompany)
+			}
+			if got := w.Header().Get("X-Path"); got != tt.wantPath {
+				t.Errorf("path %q, want %q", got, tt.wantPath)
+			}
+		})
+	}
+}
+
+// TestCompanyRouter_Default verifies requests without a company go to the
+// configured default, and that single-company mode needs no selection.
+func TestCompanyRouter_Default(t *testing.T) {
+	h := newTestRouter("acme", "acme", "globex")
+	if w := doRequest(h, "master", "/api/health", ""); w.Header().Get("X-Served-By") != "acme" {
+		t.Errorf("default company not used: code %d", w.Code)
+	}
+
+	single := newTestRouter("", "")
+	w := doRequest(single, "master", "/api/health", "")
+	if w.Code != http.StatusOK || w.Header().Get("X-Path") != "/api/health" {
+		t.Errorf("single-company request: code %d path %q", w.Code, w.Header().Get("X-Path"))
+	}
+}
+
+// TestCompanyRouter_List verifies GET /api/companies only shows companies the
+// token may access.
+func TestCompanyRouter_List(t *testing.T) {
+	h := newTestRouter("acme", "acme", "globex")
+
+	for token, want := range map[string]int{"master": 2, "acme-only": 1} {
+		w := doRequest(h, token, "/api/companies", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: code %d", token, w.Code)
+		}
+		var resp dto.CompaniesResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		if len(resp.Companies) != want {
+			t.Errorf("%s: %d companies, want %d", token, len(resp.Companies), want)
+		}
+		if !resp.Companies[0].Default || resp.Companies[0].Name != "acme" {
+			t.Errorf("%s: first company = %+v, want default acme", token, resp.Companies[0])
+		}
+	}
+}
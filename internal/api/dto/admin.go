@@ -0,0 +1,49 @@
+package dto
+
+// DBPoolSettings echoes the effective pool configuration next to the live
+// statistics so both can be compared when tuning a site.
+type DBPoolSettings struct {
+	MaxOpenConns           int   `json:"maxOpenConns"`
+	MaxIdleConns           int   `json:"maxIdleConns"`
+	ConnMaxIdleTimeSeconds int64 `json:"connMaxIdleTimeSeconds"`
+	ConnMaxLifetimeSeconds int64 `json:"connMaxLifetimeSeconds"`
+}
+
+// DBStatsResponse is returned by GET /api/admin/dbStats and mirrors sql.DBStats.
+type DBStatsResponse struct {
+	Available          bool           `json:"available"`
+	MaxOpenConnections int            `json:"maxOpenConnections"`
+	OpenConnections    int            `json:"openConnections"`
+	InUse              int            `json:"inUse"`
+	Idle               int            `json:"idle"`
+	WaitCount          int64          `json:"waitCount"`
+	WaitDurationMs     int64          `json:"waitDurationMs"`
+	MaxIdleClosed      int64          `json:"maxIdleClosed"`
+	MaxIdleTimeClosed  int64          `json:"maxIdleTimeClosed"`
+	MaxLifetimeClosed  int64          `json:"maxLifetimeClosed"`
+	Pool               DBPoolSettings `json:"pool"`
+}
+
+// DBObject is the state of one connector stored procedure in the ERP
+// database.
+type DBObject struct {
+	Name             string `json:"name"`
+	State            string `json:"state"`
+	Version          int    `json:"version"`
+	InstalledVersion int    `json:"installedVersion"`
+}
+
+// MigrationsResponse is returned by GET and POST /api/admin/migrations.
+type MigrationsResponse struct {
+	Objects []DBObject `json:"objects"`
+	DryRun  bool       `json:"dryRun,omitempty"`
+	Applied []string   `json:"applied,omitempty"`
+	DDL     string     `json:"ddl,omitempty"`
+}
+
+// HealthResponse is returned by GET /api/health. Drift lists connector
+// stored procedures that are missing, outdated or changed by hand.
+type HealthResponse struct {
+	Status string     `json:"status"`
+	Drift  []DBObject `json:"drift,omitempty"`
+}
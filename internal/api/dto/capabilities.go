@@ -0,0 +1,8 @@
+package dto
+
+// CapabilitiesResponse is returned by GET /api/capabilities. Every known
+// capability is listed so clients can tell "unsupported" from "unknown".
+type CapabilitiesResponse struct {
+	ERP          string          `json:"erp"`
+	Capabilities map[string]bool `json:"capabilities"`
+}
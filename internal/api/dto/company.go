@@ -0,0 +1,14 @@
+package dto
+
+// CompanyInfo describes one company profile visible to the caller's token.
+type CompanyInfo struct {
+	Name        string `json:"name"`
+	ERP         string `json:"erp"`
+	Default     bool   `json:"default"`
+	DBAvailable bool   `json:"dbAvailable"`
+}
+
+// CompaniesResponse is returned by GET /api/companies.
+type CompaniesResponse struct {
+	Companies []CompanyInfo `json:"companies"`
+}
@@ -0,0 +1,28 @@
+package dto
+
+// CustomerCredit is returned by GET /api/customers/{extId}/credit. Amounts
+// are in the company's local currency.
+type CustomerCredit struct {
+	ExtID          string   `json:"extId"`
+	CreditLimit    *float64 `json:"creditLimit"`
+	Balance        *float64 `json:"balance"`
+	OpenDeliveries float64  `json:"openDeliveries"`
+	OpenOrders     float64  `json:"openOrders"`
+	PendingOrders  float64  `json:"pendingOrders"`
+	Exposure       float64  `json:"exposure"`
+	Available      *float64 `json:"available"`
+	Blocked        bool     `json:"blocked"`
+}
+
+// SendOrderCredit reports the credit check run for an accepted order.
+type SendOrderCredit struct {
+	// Result is ok, warning, flagged or unchecked (customer credit could not
+	// be read).
+	Result      string   `json:"result"`
+	CreditLimit *float64 `json:"creditLimit"`
+	// Exposure includes this order.
+	Exposure   float64 `json:"exposure"`
+	OrderTotal float64 `json:"orderTotal"`
+	Exceeded   bool    `json:"exceeded"`
+	Blocked    bool    `json:"blocked"`
+}
@@ -0,0 +1,33 @@
+package dto
+
+type Customer struct {
+	ExtID        string            `json:"extId"`
+	Name         string            `json:"name"`
+	Addresses    []CustomerAddress `json:"addresses"`
+	Phones       []string          `json:"phones"`
+	Email        string            `json:"email,omitempty"`
+	TaxID        string            `json:"taxId,omitempty"`
+	Agent        string            `json:"agent,omitempty"`
+	PriceList    string            `json:"priceList,omitempty"`
+	PaymentTerms string            `json:"paymentTerms,omitempty"`
+	Currency     string            `json:"currency,omitempty"`
+	CreditLimit  *float64          `json:"creditLimit"`
+	Balance      *float64          `json:"balance,omitempty"`
+	Blocked      bool              `json:"blocked"`
+	Details      map[string]any    `json:"details,omitempty"`
+}
+
+type CustomerAddress struct {
+	Type    string `json:"type"`
+	Street  string `json:"street,omitempty"`
+	City    string `json:"city,omitempty"`
+	ZipCode string `json:"zipCode,omitempty"`
+	Country string `json:"country,omitempty"`
+}
+
+type CustomerSearchResponse struct {
+	Customers []Customer `json:"customers"`
+	Page      int        `json:"page"`
+	PageSize  int        `json:"pageSize"`
+	Total     int        `json:"total"`
+}
@@ -0,0 +1,38 @@
+package dto
+
+import "time"
+
+type Document struct {
+	Kind        string         `json:"kind"`
+	Number      string         `json:"number"`
+	Date        *time.Time     `json:"date,omitempty"`
+	DueDate     *time.Time     `json:"dueDate,omitempty"`
+	Status      string         `json:"status"`
+	Currency    string         `json:"currency,omitempty"`
+	NetTotal    float64        `json:"netTotal"`
+	Total       *float64       `json:"total,omitempty"`
+	VAT         *float64       `json:"vat,omitempty"`
+	Reference   string         `json:"reference,omitempty"`
+	OrderNumber string         `json:"orderNumber,omitempty"`
+	Lines       []DocumentLine `json:"lines"`
+	Details     map[string]any `json:"details,omitempty"`
+}
+
+type DocumentLine struct {
+	LineNum      int     `json:"lineNum"`
+	SKU          string  `json:"sku"`
+	Name         string  `json:"name,omitempty"`
+	Quantity     float64 `json:"quantity"`
+	OpenQuantity float64 `json:"openQuantity"`
+	Price        float64 `json:"price"`
+	DiscountPct  float64 `json:"discountPct"`
+	Total        float64 `json:"total"`
+	Status       string  `json:"status"`
+}
+
+type DocumentsResponse struct {
+	Documents []Document `json:"documents"`
+	Page      int        `json:"page"`
+	PageSize  int        `json:"pageSize"`
+	Total     int        `json:"total"`
+}
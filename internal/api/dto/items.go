@@ -0,0 +1,29 @@
+package dto
+
+import "time"
+
+type Item struct {
+	SKU          string         `json:"sku"`
+	Name         string         `json:"name"`
+	Barcodes     []string       `json:"barcodes"`
+	Group        string         `json:"group,omitempty"`
+	Manufacturer string         `json:"manufacturer,omitempty"`
+	Units        ItemUnits      `json:"units"`
+	PackSize     float64        `json:"packSize"`
+	VATClass     string         `json:"vatClass,omitempty"`
+	Active       bool           `json:"active"`
+	ModifiedAt   *time.Time     `json:"modifiedAt,omitempty"`
+	Details      map[string]any `json:"details,omitempty"`
+}
+
+type ItemUnits struct {
+	Sales     string `json:"sales,omitempty"`
+	Inventory string `json:"inventory,omitempty"`
+}
+
+type ItemSearchResponse struct {
+	Items    []Item `json:"items"`
+	Page     int    `json:"page"`
+	PageSize int    `json:"pageSize"`
+	Total    int    `json:"total"`
+}
@@ -0,0 +1,29 @@
+package dto
+
+// SendOrderJob is returned by GET, PUT and DELETE /api/sendOrder/{jobId}.
+type SendOrderJob struct {
+	JobID          string `json:"jobId"`
+	Status         string `json:"status"`
+	OrderNumber    int64  `json:"orderNumber,omitempty"`
+	DocumentNumber string `json:"documentNumber,omitempty"`
+	Entity         string `json:"entity,omitempty"`
+	// VATRate is the VAT percent the document was created with, when the
+	// connector sets it (Hasavshevet); VATExempt marks exempt customers.
+	VATRate   *float64        `json:"vatRate,omitempty"`
+	VATExempt bool            `json:"vatExempt,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	Errors    []OrderJobError `json:"errors,omitempty"`
+}
+
+// OrderJobError is one problem the ERP reported for the order. Line is the
+// 1-based order line, omitted for header errors; Field is the ERP field key
+// (IMOVEIN "line22" for Hasavshevet) and FieldTitle its description.
+type OrderJobError struct {
+	Line       int    `json:"line,omitempty"`
+	SKU        string `json:"sku,omitempty"`
+	Field      string `json:"field,omitempty"`
+	FieldTitle string `json:"fieldTitle,omitempty"`
+	Message    string `json:"message"`
+	// Text is the error as one line, e.g. "line 3 (SKU-001): unknown SKU".
+	Text string `json:"text"`
+}
@@ -0,0 +1,42 @@
+package dto
+
+// SendOrderPreview is returned by POST /api/sendOrder?dryRun=true: the
+// document the ERP would receive, built without sending it.
+type SendOrderPreview struct {
+	Status       string                `json:"status"` // always "preview"
+	OrderNumber  int64                 `json:"orderNumber,omitempty"`
+	Account      SendOrderAccount      `json:"account"`
+	CurrencyRate float64               `json:"currencyRate"`
+	VATRate      *float64              `json:"vatRate,omitempty"`
+	VATExempt    bool                  `json:"vatExempt,omitempty"`
+	Records      []SendOrderPreviewRow `json:"records"`
+	// Warnings lists truncated fields, then credit check warnings.
+	Warnings []string         `json:"warnings,omitempty"`
+	Credit   *SendOrderCredit `json:"credit,omitempty"`
+	Meta     SendOrderMeta    `json:"meta"`
+}
+
+// SendOrderAccount is the customer account an order was resolved to.
+type SendOrderAccount struct {
+	AccountKey string `json:"accountKey"`
+	FullName   string `json:"fullName,omitempty"`
+	Address    string `json:"address,omitempty"`
+	City       string `json:"city,omitempty"`
+	Phone      string `json:"phone,omitempty"`
+}
+
+// SendOrderPreviewRow is one document record (order line) of a preview.
+type SendOrderPreviewRow struct {
+	Line   int                     `json:"line"`
+	Fields []SendOrderPreviewField `json:"fields"`
+}
+
+// SendOrderPreviewField is one non-blank field of a preview record.
+type SendOrderPreviewField struct {
+	Field     string `json:"field"`
+	Title     string `json:"title"`
+	Start     int    `json:"start"`
+	Width     int    `json:"width"`
+	Value     string `json:"value"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
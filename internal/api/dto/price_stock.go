@@ -6,13 +6,25 @@ type PriceStockRequest struct {
 	Warehouses []string `json:"warehouses"`
 	UserExtID  string   `json:"userExtId"`
 	Date       string   `json:"date,omitempty"`
+	// Quantities maps SKU → quantity for quantity-break pricing (default 1).
+	Quantities map[string]float64 `json:"quantities,omitempty"`
+	// DocumentType is the sendOrder documentType the prices are for.
+	DocumentType string `json:"documentType,omitempty"`
 }
 
 type PriceStockItem struct {
-	SKU              string             `json:"sku"`
-	Prices           map[string]float64 `json:"prices,omitempty"`
-	StockByWarehouse map[string]float64 `json:"stockByWarehouse,omitempty"`
-	Details          map[string]any     `json:"details,omitempty"`
+	SKU              string                    `json:"sku"`
+	Prices           map[string]float64        `json:"prices,omitempty"`
+	StockByWarehouse map[string]float64        `json:"stockByWarehouse,omitempty"`
+	WarehouseStock   map[string]WarehouseStock `json:"warehouseStock,omitempty"`
+	Details          map[string]any            `json:"details,omitempty"`
+}
+
+type WarehouseStock struct {
+	OnHand    float64 `json:"onHand"`
+	Committed float64 `json:"committed"`
+	OnOrder   float64 `json:"onOrder"`
+	Available float64 `json:"available"`
 }
 
 type PriceStockMeta struct {
@@ -15,6 +15,7 @@ type SendOrderRequest struct {
 	Total        *float64            `json:"total"`
 	Currency      string              `json:"currency"`
 	CustomerEmail string              `json:"customerEmail"` // optional; for PDF email delivery
+	Priority      string              `json:"priority,omitempty"` // optional; urgent, normal (default) or bulk
 	Details       []SendOrderLineItem `json:"details"`
 }
 
@@ -29,6 +30,28 @@ type SendOrderLineItem struct {
 	SinglePrice   *float64 `json:"singlePrice"`
 	TotalPrice    *float64 `json:"totalPrice"`
 	Discount      *float64 `json:"discount"`
+	// Optional line attributes. Omitted fields keep the ERP's defaults: the
+	// document's warehouse and unit, and the order's dueDate.
+	Warehouse int                 `json:"warehouse,omitempty"`
+	Remarks   string              `json:"remarks,omitempty"`
+	Unit      string              `json:"unit,omitempty"`
+	DueDate   string              `json:"dueDate,omitempty"`
+	Batch     string              `json:"batch,omitempty"`
+	Serial    string              `json:"serial,omitempty"`
+	Extra     *SendOrderLineExtra `json:"extra,omitempty"`
+}
+
+// SendOrderLineExtra holds an order line's free-text, amount, date and
+// number extra fields.
+type SendOrderLineExtra struct {
+	Text1 string   `json:"text1,omitempty"`
+	Text2 string   `json:"text2,omitempty"`
+	Sum1  *float64 `json:"sum1,omitempty"`
+	Sum2  *float64 `json:"sum2,omitempty"`
+	Date1 string   `json:"date1,omitempty"`
+	Date2 string   `json:"date2,omitempty"`
+	Num1  *float64 `json:"num1,omitempty"`
+	Num2  *float64 `json:"num2,omitempty"`
 }
 
 type SendOrderMeta struct {
@@ -37,7 +60,16 @@ type SendOrderMeta struct {
 
 // SendOrderAccepted is returned immediately with 202 when the order is enqueued.
 type SendOrderAccepted struct {
-	Status string        `json:"status"`
-	JobID  string        `json:"jobId"`
-	Meta   SendOrderMeta `json:"meta"`
+	Status string `json:"status"`
+	JobID  string `json:"jobId"`
+	// Credit is set when the credit check ran; Warnings explains a warning
+	// or flagged result.
+	Credit   *SendOrderCredit `json:"credit,omitempty"`
+	Warnings []string         `json:"warnings,omitempty"`
+	// QueuePosition is the job's place among the waiting jobs (1 runs next)
+	// and EstimatedWaitSeconds a rough wait, once the queue has timed an
+	// order. Both are omitted by ERPs whose queue does not report them.
+	QueuePosition        int           `json:"queuePosition,omitempty"`
+	EstimatedWaitSeconds *int64        `json:"estimatedWaitSeconds,omitempty"`
+	Meta                 SendOrderMeta `json:"meta"`
 }
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"erp-connector/internal/api/dto"
+	"erp-connector/internal/api/utils"
+	"erp-connector/internal/db"
+)
+
+// NewDBStatsHandler reports sql.DB.Stats() for the daemon's connection pool
+// together with the configured pool settings. Statistics stay readable while
+// the database is down so a degraded site can still be inspected.
+func NewDBStatsHandler(dbHandle *db.Handle, opt db.Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dbConn := dbHandle.Pool()
+		if dbConn == nil {
+			utils.WriteError(w, http.StatusServiceUnavailable, "Database connection unavailable", "DB_UNAVAILABLE", nil)
+			return
+		}
+
+		st := dbConn.Stats()
+		utils.WriteJSON(w, http.StatusOK, dto.DBStatsResponse{
+			Available:          dbHandle.Available(),
+			MaxOpenConnections: st.MaxOpenConnections,
+			OpenConnections:    st.OpenConnections,
+			InUse:              st.InUse,
+			Idle:               st.Idle,
+			WaitCount:          st.WaitCount,
+			WaitDurationMs:     st.WaitDuration.Milliseconds(),
+			MaxIdleClosed:      st.MaxIdleClosed,
+			MaxIdleTimeClosed:  st.MaxIdleTimeClosed,
+			MaxLifetimeClosed:  st.MaxLifetimeClosed,
+			Pool: dto.DBPoolSettings{
+				MaxOpenConns:           opt.MaxOpenConns,
+				MaxIdleConns:           opt.MaxIdleConns,
+				ConnMaxIdleTimeSeconds: int64(opt.ConnMaxIdleTime.Seconds()),
+				ConnMaxLifetimeSeconds: int64(opt.ConnMaxLifetime.Seconds()),
+			},
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"erp-connector/internal/api/dto"
+	"erp-connector/internal/api/utils"
+	"erp-connector/internal/erp"
+)
+
+// NewCapabilitiesHandler reports which features the company's ERP adapter
+// supports with the current configuration.
+func NewCapabilitiesHandler(adapter erp.Adapter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		caps := make(map[string]bool, len(erp.AllCapabilities()))
+		for _, c := range erp.AllCapabilities() {
+			caps[string(c)] = erp.Supports(adapter, c)
+		}
+		utils.WriteJSON(w, http.StatusOK, dto.CapabilitiesResponse{
+			ERP:          string(adapter.ERP()),
+			Capabilities: caps,
+		})
+	}
+}
+
+// NewNotSupportedHandler answers routes whose capability the ERP lacks.
+func NewNotSupportedHandler(adapter erp.Adapter, c erp.Capability) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		utils.WriteError(w, http.StatusBadRequest, "Not supported for this ERP type", "ERP_NOT_SUPPORTED", map[string]any{
+			"erp":        string(adapter.ERP()),
+			"capability": string(c),
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"erp-connector/internal/api/dto"
+	"erp-connector/internal/config"
+	"erp-connector/internal/erp"
+	"erp-connector/internal/erp/sap"
+)
+
+func TestCapabilitiesHandler_ListsAllCapabilities(t *testing.T) {
+	a := sap.NewAdapter(nil, config.Config{ERP: config.ERPSAP})
+	w := httptest.NewRecorder()
+	NewCapabilitiesHandler(a).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/capabilities", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	var resp dto.CapabilitiesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.ERP != string(config.ERPSAP) {
+		t.Errorf("erp = %q", resp.ERP)
+	}
+	if len(resp.Capabilities) != len(erp.AllCapabilities()) {
+		t.Errorf("capabilities = %v, want every known capability listed", resp.Capabilities)
+	}
+	if !resp.Capabilities[string(erp.CapPriceStock)] || resp.Capabilities[string(erp.CapSendOrder)] {
+		t.Errorf("capabilities = %v", resp.Capabilities)
+	}
+}
+
+func TestNotSupportedHandler(t *testing.T) {
+	a := sap.NewAdapter(nil, config.Config{ERP: config.ERPSAP})
+	w := httptest.NewRecorder()
+	NewNotSupportedHandler(a, erp.CapSendOrder).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/sendOrder", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d", w.Code)
+	}
+	var resp map[string]any
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp["code"] != "ERP_NOT_SUPPORTED" {
+		t.Errorf("body = %s", w.Body.String())
+	}
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"erp-connector/internal/api/dto"
+	"erp-connector/internal/api/utils"
+	"erp-connector/internal/db"
+	"erp-connector/internal/erp"
+)
+
+// Credit check modes, matching config.CreditCheckConfig.Mode.
+const (
+	CreditReject = "reject"
+	CreditWarn   = "warn"
+	CreditFlag   = "flag"
+)
+
+// CreditPolicy is the sendOrder credit check. Provider reads the customer's
+// exposure; Mode decides what happens to an order over the limit or for a
+// blocked customer; FlagText is prepended to the comment of flagged orders.
+type CreditPolicy struct {
+	Provider erp.CreditProvider
+	Mode     string
+	FlagText string
+}
+
+// NewCustomerCreditHandler serves GET /api/customers/{extId}/credit.
+func NewCustomerCreditHandler(credit erp.CreditProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		extID := strings.TrimSpace(r.PathValue("extId"))
+		if extID == "" {
+			utils.WriteError(w, http.StatusBadRequest, "extId is required", "VALIDATION_ERROR", nil)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), customerLookupTimeout)
+		defer cancel()
+
+		s, err := credit.CustomerCredit(ctx, extID)
+		if err != nil {
+			writeCustomerError(w, err, extID)
+			return
+		}
+		utils.WriteJSON(w, http.StatusOK, dto.CustomerCredit{
+			ExtID:          s.ExtID,
+			CreditLimit:    s.CreditLimit,
+			Balance:        s.Balance,
+			OpenDeliveries: s.OpenDeliveries,
+			OpenOrders:     s.OpenOrders,
+			PendingOrders:  s.PendingOrders,
+			Exposure:       s.Exposure(),
+			Available:      s.Available(),
+			Blocked:        s.Blocked,
+		})
+	}
+}
+
+// check runs the credit check for req. replacing is the queued job req
+// replaces (PUT), whose old total leaves the exposure, or "" for a new order.
+// In flag mode a failing order gets FlagText prepended to its comment. It
+// returns false after writing the error response when the order must not be
+// queued.
+func (p *CreditPolicy) check(ctx context.Context, w http.ResponseWriter, req *erp.OrderRequest, replacing string) (*dto.SendOrderCredit, []string, bool) {
+	ctx, cancel := context.WithTimeout(ctx, customerLookupTimeout)
+	defer cancel()
+
+	s, err := p.Provider.CustomerCredit(ctx, req.UserExtID)
+	if err != nil {
+		if p.Mode == CreditReject {
+			writeCreditLookupError(w, err, req.UserExtID)
+			return nil, nil, false
+		}
+		reason := "credit check failed: " + err.Error()
+		if errors.Is(err, erp.ErrNotFound) {
+			reason = fmt.Sprintf("credit not checked: customer %s not found", req.UserExtID)
+		}
+		return &dto.SendOrderCredit{Result: "unchecked", OrderTotal: req.Total}, []string{reason}, true
+	}
+
+	if pending, ok := p.Provider.(erp.PendingOrderTotals); ok && replacing != "" {
+		if total, ok := pending.PendingJobTotal(replacing, req.UserExtID); ok {
+			s.PendingOrders -= total
+		}
+	}
+
+	c := erp.CheckCredit(s, req.Total)
+	out := &dto.SendOrderCredit{
+		Result:      "ok",
+		CreditLimit: s.CreditLimit,
+		Exposure:    c.Exposure,
+		OrderTotal:  c.OrderTotal,
+		Exceeded:    c.Exceeded,
+		Blocked:     s.Blocked,
+	}
+	if c.OK() {
+		return out, nil, true
+	}
+
+	var reasons []string
+	if s.Blocked {
+		reasons = append(reasons, fmt.Sprintf("customer %s is blocked", req.UserExtID))
+	}
+	if c.Exceeded {
+		reasons = append(reasons, fmt.Sprintf("credit limit %.2f exceeded: exposure %.2f including this order", *s.CreditLimit, c.Exposure))
+	}
+
+	switch p.Mode {
+	case CreditReject:
+		status, msg, code := http.StatusUnprocessableEntity, "Credit limit exceeded", "CREDIT_LIMIT_EXCEEDED"
+		if s.Blocked {
+			msg, code = "Customer is blocked", "CUSTOMER_BLOCKED"
+		}
+		utils.WriteError(w, status, msg, code, map[string]any{
+			"extId":       req.UserExtID,
+			"creditLimit": s.CreditLimit,
+			"exposure":    c.Exposure,
+			"orderTotal":  c.OrderTotal,
+			"blocked":     s.Blocked,
+		})
+		return nil, nil, false
+	case CreditFlag:
+		out.Result = "flagged"
+		req.Comment = strings.TrimSpace(p.FlagText + " " + req.Comment)
+	default:
+		out.Result = "warning"
+	}
+	return out, reasons, true
+}
+
+func writeCreditLookupError(w http.ResponseWriter, err error, extID string) {
+	switch {
+	case errors.Is(err, erp.ErrNotFound):
+		utils.WriteError(w, http.StatusUnprocessableEntity, "Customer not found; credit cannot be checked", "CUSTOMER_NOT_FOUND", map[string]any{"extId": extID})
+	case errors.Is(err, db.ErrUnavailable):
+		utils.WriteError(w, http.StatusServiceUnavailable, "Database connection unavailable", "DB_UNAVAILABLE", nil)
+	default:
+		utils.WriteError(w, http.StatusServiceUnavailable, "Credit check failed", "CREDIT_CHECK_FAILED", nil)
+	}
+}
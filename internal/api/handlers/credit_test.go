@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"erp-connector/internal/api/dto"
+	"erp-connector/internal/config"
+	"erp-connector/internal/erp"
+	"erp-connector/internal/erp/hasavshevet"
+)
+
+type fakeCredit struct {
+	statuses map[string]erp.CreditStatus
+}
+
+func (f *fakeCredit) CustomerCredit(_ context.Context, extID string) (erp.CreditStatus, error) {
+	s, ok := f.statuses[extID]
+	if !ok {
+		return erp.CreditStatus{}, erp.ErrNotFound
+	}
+	return s, nil
+}
+
+// pendingCredit adds the orders waiting in q to fakeCredit's exposure, the way
+// the adapters do.
+type pendingCredit struct {
+	*fakeCredit
+	q *hasavshevet.OrderQueue
+}
+
+func (c pendingCredit) CustomerCredit(ctx context.Context, extID string) (erp.CreditStatus, error) {
+	s, err := c.fakeCredit.CustomerCredit(ctx, extID)
+	s.PendingOrders = c.q.PendingTotal(extID)
+	return s, err
+}
+
+func (c pendingCredit) PendingJobTotal(jobID, extID string) (float64, bool) {
+	return c.q.PendingJobTotal(jobID, extID)
+}
+
+// recordingOrders accepts every order and keeps the last one.
+type recordingOrders struct {
+	last *erp.OrderRequest
+}
+
+func (o *recordingOrders) SubmitOrder(req erp.OrderRequest) (string, error) {
+	o.last = &req
+	return "42", nil
+}
+
+// creditFixture has CUST001 with a 1000 limit and 900 exposure, so the 150
+// test order goes over it.
+func creditFixture() *fakeCredit {
+	limit, balance := 1000.0, 600.0
+	return &fakeCredit{statuses: map[string]erp.CreditStatus{
+		"CUST001": {ExtID: "CUST001", CreditLimit: &limit, Balance: &balance, OpenDeliveries: 100, OpenOrders: 200},
+	}}
+}
+
+func TestCustomerCreditHandler(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /api/customers/{extId}/credit", NewCustomerCreditHandler(creditFixture()))
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/customers/CUST001/credit", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d body=%s", w.Code, w.Body.String())
+	}
+	var resp dto.CustomerCredit
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Exposure != 900 || resp.Available == nil || *resp.Available != 100 {
+		t.Errorf("credit = %+v, want exposure 900 and 100 available", resp)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/customers/NOPE/credit", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown customer: status = %d, want 404", w.Code)
+	}
+}
+
+func TestSendOrderHandler_CreditReject(t *testing.T) {
+	orders := &recordingOrders{}
+	h := NewSendOrderHandler(orders, &CreditPolicy{Provider: creditFixture(), Mode: CreditReject})
+	w := sendOrderRequest(t, h, validOrderBody())
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422; body=%s", w.Code, w.Body.String())
+	}
+	var resp map[string]any
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp["code"] != "CREDIT_LIMIT_EXCEEDED" {
+		t.Errorf("body = %s", w.Body.String())
+	}
+	if orders.last != nil {
+		t.Error("rejected order must not be queued")
+	}
+}
+
+func TestSendOrderHandler_CreditWithinLimit(t *testing.T) {
+	orders := &recordingOrders{}
+	h := NewSendOrderHandler(orders, &CreditPolicy{Provider: creditFixture(), Mode: CreditReject})
+	body := validOrderBody()
+	body["total"] = 50.0
+	w := sendOrderRequest(t, h, body)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202; body=%s", w.Code, w.Body.String())
+	}
+	var resp dto.SendOrderAccepted
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Credit == nil || resp.Credit.Result != "ok" || len(resp.Warnings) != 0 {
+		t.Errorf("response = %s", w.Body.String())
+	}
+}
+
+func TestSendOrderHandler_CreditWarnAndFlag(t *testing.T) {
+	tests := []struct {
+		mode, result, comment string
+	}{
+		{CreditWarn, "warning", "test order"},
+		{CreditFlag, "flagged", "CREDIT CHECK test order"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			orders := &recordingOrders{}
+			h := NewSendOrderHandler(orders, &CreditPolicy{Provider: creditFixture(), Mode: tt.mode, FlagText: "CREDIT CHECK"})
+			w := sendOrderRequest(t, h, validOrderBody())
+			if w.Code != http.StatusAccepted {
+				t.Fatalf("status = %d, want 202; body=%s", w.Code, w.Body.String())
+			}
+			var resp dto.SendOrderAccepted
+			_ = json.Unmarshal(w.Body.Bytes(), &resp)
+			if resp.Credit == nil || resp.Credit.Result != tt.result || !resp.Credit.Exceeded || resp.Credit.Exposure != 1050 {
+				t.Errorf("credit = %+v", resp.Credit)
+			}
+			if len(resp.Warnings) != 1 {
+				t.Errorf("warnings = %v", resp.Warnings)
+			}
+			if orders.last == nil || orders.last.Comment != tt.comment {
+				t.Errorf("queued order = %+v, want comment %q", orders.last, tt.comment)
+			}
+		})
+	}
+}
+
+// TestSendOrderHandler_CreditSkipsReturns verifies only ORDER documents are
+// checked.
+func TestSendOrderHandler_CreditSkipsReturns(t *testing.T) {
+	h := NewSendOrderHandler(&recordingOrders{}, &CreditPolicy{Provider: creditFixture(), Mode: CreditReject})
+	body := validOrderBody()
+	body["documentType"] = "RETURN"
+	if w := sendOrderRequest(t, h, body); w.Code != http.StatusAccepted {
+		t.Errorf("return: status = %d, want 202", w.Code)
+	}
+}
+
+// TestSendOrderHandler_CreditCountsQueuedOrders verifies orders still in the
+// queue count toward the exposure: two orders that each fit under the limit
+// are rejected together, and editing a queued order replaces its old total.
+func TestSendOrderHandler_CreditCountsQueuedOrders(t *testing.T) {
+	limit := 1000.0
+	q := hasavshevet.NewOrderQueue(nil, &noopLogger{})
+	orders := hasavshevet.NewAdapter(nil, config.Config{}, q, &noopLogger{})
+	credit := &CreditPolicy{
+		Provider: pendingCredit{&fakeCredit{statuses: map[string]erp.CreditStatus{
+			"CUST001": {ExtID: "CUST001", CreditLimit: &limit, OpenOrders: 750},
+		}}, q},
+		Mode: CreditReject,
+	}
+	h := NewSendOrderHandler(orders, credit)
+
+	w := sendOrderRequest(t, h, validOrderBody())
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("first order: status = %d, want 202; body=%s", w.Code, w.Body.String())
+	}
+	var accepted dto.SendOrderAccepted
+	_ = json.Unmarshal(w.Body.Bytes(), &accepted)
+
+	w = sendOrderRequest(t, h, validOrderBody())
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("second order: status = %d, want 422; body=%s", w.Code, w.Body.String())
+	}
+	var rejected map[string]any
+	_ = json.Unmarshal(w.Body.Bytes(), &rejected)
+	if details, _ := rejected["details"].(map[string]any); details["exposure"] != 1050.0 {
+		t.Errorf("second order: body = %s, want exposure 1050", w.Body.String())
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("PUT /api/sendOrder/{jobId}", NewUpdateOrderHandler(orders, credit))
+	body := validOrderBody()
+	body["total"] = 200.0
+	b, _ := json.Marshal(body)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/sendOrder/"+accepted.JobID, bytes.NewReader(b)))
+	if w.Code != http.StatusOK {
+		t.Errorf("edit: status = %d, want 200 (750 + 200 fits); body=%s", w.Code, w.Body.String())
+	}
+}
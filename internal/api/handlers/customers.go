@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"erp-connector/internal/api/dto"
+	"erp-connector/internal/api/utils"
+	"erp-connector/internal/db"
+	"erp-connector/internal/erp"
+)
+
+const customerLookupTimeout = 12 * time.Second
+
+// NewCustomerHandler serves GET /api/customers/{extId}.
+func NewCustomerHandler(lookup erp.CustomerLookup) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		extID := strings.TrimSpace(r.PathValue("extId"))
+		if extID == "" {
+			utils.WriteError(w, http.StatusBadRequest, "extId is required", "VALIDATION_ERROR", nil)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), customerLookupTimeout)
+		defer cancel()
+
+		c, err := lookup.GetCustomer(ctx, extID)
+		if err != nil {
+			writeCustomerError(w, err, extID)
+			return
+		}
+		utils.WriteJSON(w, http.StatusOK, customerDTO(c))
+	}
+}
+
+// NewCustomerSearchHandler serves GET /api/customers?q=&page=&pageSize=.
+func NewCustomerSearchHandler(lookup erp.CustomerLookup) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		page, pageSize, err := parsePaging(r)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, err.Error(), "VALIDATION_ERROR", nil)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), customerLookupTimeout)
+		defer cancel()
+
+		res, err := lookup.SearchCustomers(ctx, erp.CustomerSearch{
+			Query:    r.URL.Query().Get("q"),
+			Page:     page,
+			PageSize: pageSize,
+		})
+		if err != nil {
+			writeCustomerError(w, err, "")
+			return
+		}
+
+		customers := make([]dto.Customer, 0, len(res.Customers))
+		for _, c := range res.Customers {
+			customers = append(customers, customerDTO(c))
+		}
+		utils.WriteJSON(w, http.StatusOK, dto.CustomerSearchResponse{
+			Customers: customers,
+			Page:      page,
+			PageSize:  pageSize,
+			Total:     res.Total,
+		})
+	}
+}
+
+func writeCustomerError(w http.ResponseWriter, err error, extID string) {
+	switch {
+	case errors.Is(err, erp.ErrNotFound):
+		utils.WriteError(w, http.StatusNotFound, "Customer not found", "CUSTOMER_NOT_FOUND", map[string]any{"extId": extID})
+	case errors.Is(err, db.ErrUnavailable):
+		utils.WriteError(w, http.StatusServiceUnavailable, "Database connection unavailable", "DB_UNAVAILABLE", nil)
+	default:
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to load customers", "CUSTOMER_LOOKUP_FAILED", nil)
+	}
+}
+
+func customerDTO(c erp.Customer) dto.Customer {
+	addresses := make([]dto.CustomerAddress, 0, len(c.Addresses))
+	for _, a := range c.Addresses {
+		addresses = append(addresses, dto.CustomerAddress{
+			Type:    a.Type,
+			Street:  a.Street,
+			City:    a.City,
+			ZipCode: a.ZipCode,
+			Country: a.Country,
+		})
+	}
+	phones := c.Phones
+	if phones == nil {
+		phones = []string{}
+	}
+	return dto.Customer{
+		ExtID:        c.ExtID,
+		Name:         c.Name,
+		Addresses:    addresses,
+		Phones:       phones,
+		Email:        c.Email,
+		TaxID:        c.TaxID,
+		Agent:        c.Agent,
+		PriceList:    c.PriceList,
+		PaymentTerms: c.PaymentTerms,
+		Currency:     c.Currency,
+		CreditLimit:  c.CreditLimit,
+		Balance:      c.Balance,
+		Blocked:      c.Blocked,
+		Details:      c.Details,
+	}
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"erp-connector/internal/api/dto"
+	"erp-connector/internal/db"
+	"erp-connector/internal/erp"
+)
+
+type fakeCustomers struct {
+	customers map[string]erp.Customer
+	err       error
+	lastQuery erp.CustomerSearch
+}
+
+func (f *fakeCustomers) GetCustomer(_ context.Context, extID string) (erp.Customer, error) {
+	if f.err != nil {
+		return erp.Customer{}, f.err
+	}
+	c, ok := f.customers[extID]
+	if !ok {
+		return erp.Customer{}, erp.ErrNotFound
+	}
+	return c, nil
+}
+
+func (f *fakeCustomers) SearchCustomers(_ context.Context, q erp.CustomerSearch) (erp.CustomerPage, error) {
+	f.lastQuery = q
+	if f.err != nil {
+		return erp.CustomerPage{}, f.err
+	}
+	page := erp.CustomerPage{Page: q.Page, PageSize: q.PageSize, Total: len(f.customers)}
+	for _, c := range f.customers {
+		page.Customers = append(page.Customers, c)
+	}
+	return page, nil
+}
+
+func serveCustomers(lookup erp.CustomerLookup, target string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	mux.Handle("GET /api/customers", NewCustomerSearchHandler(lookup))
+	mux.Handle("GET /api/customers/{extId}", NewCustomerHandler(lookup))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	return w
+}
+
+func TestCustomerHandler(t *testing.T) {
+	limit := 5000.0
+	lookup := &fakeCustomers{customers: map[string]erp.Customer{
+		"C001": {
+			ExtID: "C001", Name: "Acme", Phones: []string{"03-1234567"}, CreditLimit: &limit, Blocked: true,
+			Addresses: []erp.CustomerAddress{{Type: erp.AddressBilling, Street: "1 Main St", City: "Tel Aviv"}},
+		},
+	}}
+
+	w := serveCustomers(lookup, "/api/customers/C001")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	var got dto.Customer
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.ExtID != "C001" || got.Name != "Acme" || !got.Blocked || got.CreditLimit == nil || *got.CreditLimit != 5000 {
+		t.Errorf("customer = %+v", got)
+	}
+	if len(got.Addresses) != 1 || got.Addresses[0].Type != "billing" || got.Addresses[0].City != "Tel Aviv" {
+		t.Errorf("addresses = %+v", got.Addresses)
+	}
+
+	w = serveCustomers(lookup, "/api/customers/NOPE")
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unknown customer status = %d", w.Code)
+	}
+	var errResp map[string]any
+	_ = json.Unmarshal(w.Body.Bytes(), &errResp)
+	if errResp["code"] != "CUSTOMER_NOT_FOUND" {
+		t.Errorf("body = %s", w.Body.String())
+	}
+}
+
+func TestCustomerSearchHandler(t *testing.T) {
+	lookup := &fakeCustomers{customers: map[string]erp.Customer{"C001": {ExtID: "C001", Name: "Acme"}}}
+
+	w := serveCustomers(lookup, "/api/customers?q=acm&page=2&pageSize=10")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if lookup.lastQuery != (erp.CustomerSearch{Query: "acm", Page: 2, PageSize: 10}) {
+		t.Errorf("query = %+v", lookup.lastQuery)
+	}
+	var got dto.CustomerSearchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Page != 2 || got.PageSize != 10 || got.Total != 1 || len(got.Customers) != 1 || got.Customers[0].Phones == nil {
+		t.Errorf("response = %+v", got)
+	}
+
+	for _, target := range []string{"/api/customers?page=0", "/api/customers?pageSize=100000", "/api/customers?page=x"} {
+		if w := serveCustomers(lookup, target); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", target, w.Code)
+		}
+	}
+
+	lookup.err = db.ErrUnavailable
+	if w := serveCustomers(lookup, "/api/customers"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("db down: status = %d, want 503", w.Code)
+	}
+}
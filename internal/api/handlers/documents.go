@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"erp-connector/internal/api/dto"
+	"erp-connector/internal/api/utils"
+	"erp-connector/internal/db"
+	"erp-connector/internal/erp"
+)
+
+const documentsTimeout = 20 * time.Second
+
+// NewCustomerDocumentsHandler serves GET /api/customers/{extId}/documents
+// with kind (comma separated), status=open, from, to, page and pageSize
+// query parameters.
+func NewCustomerDocumentsHandler(lookup erp.DocumentLookup) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		search, err := parseDocumentSearch(r)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, err.Error(), "VALIDATION_ERROR", nil)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), documentsTimeout)
+		defer cancel()
+
+		res, err := lookup.CustomerDocuments(ctx, search)
+		if err != nil {
+			switch {
+			case errors.Is(err, db.ErrUnavailable):
+				utils.WriteError(w, http.StatusServiceUnavailable, "Database connection unavailable", "DB_UNAVAILABLE", nil)
+			default:
+				utils.WriteError(w, http.StatusInternalServerError, "Failed to load documents", "DOCUMENTS_FAILED", nil)
+			}
+			return
+		}
+
+		docs := make([]dto.Document, 0, len(res.Documents))
+		for _, d := range res.Documents {
+			docs = append(docs, documentDTO(d))
+		}
+		utils.WriteJSON(w, http.StatusOK, dto.DocumentsResponse{
+			Documents: docs,
+			Page:      search.Page,
+			PageSize:  search.PageSize,
+			Total:     res.Total,
+		})
+	}
+}
+
+func parseDocumentSearch(r *http.Request) (erp.DocumentSearch, error) {
+	extID := strings.TrimSpace(r.PathValue("extId"))
+	if extID == "" {
+		return erp.DocumentSearch{}, errors.New("extId is required")
+	}
+	page, pageSize, err := parsePaging(r)
+	if err != nil {
+		return erp.DocumentSearch{}, err
+	}
+	q := r.URL.Query()
+	search := erp.DocumentSearch{ExtID: extID, Page: page, PageSize: pageSize}
+
+	for _, k := range strings.Split(q.Get("kind"), ",") {
+		if k = strings.TrimSpace(k); k == "" {
+			continue
+		}
+		if !slices.Contains(erp.DocumentKinds(), k) {
+			return erp.DocumentSearch{}, fmt.Errorf("kind must be one of %s", strings.Join(erp.DocumentKinds(), ", "))
+		}
+		search.Kinds = append(search.Kinds, k)
+	}
+	switch strings.TrimSpace(q.Get("status")) {
+	case "", "all":
+	case erp.DocStatusOpen:
+		search.OpenOnly = true
+	default:
+		return erp.DocumentSearch{}, errors.New("status must be open or all")
+	}
+	if search.From, err = parseOptionalDate(q.Get("from")); err != nil {
+		return erp.DocumentSearch{}, errors.New("from must be YYYY-MM-DD")
+	}
+	if search.To, err = parseOptionalDate(q.Get("to")); err != nil {
+		return erp.DocumentSearch{}, errors.New("to must be YYYY-MM-DD")
+	}
+	return search, nil
+}
+
+func parseOptionalDate(v string) (time.Time, error) {
+	if v = strings.TrimSpace(v); v == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation("2006-01-02", v, time.Local)
+}
+
+func documentDTO(d erp.Document) dto.Document {
+	lines := make([]dto.DocumentLine, 0, len(d.Lines))
+	for _, l := range d.Lines {
+		lines = append(lines, dto.DocumentLine{
+			LineNum:      l.LineNum,
+			SKU:          l.SKU,
+			Name:         l.Name,
+			Quantity:     l.Quantity,
+			OpenQuantity: l.OpenQuantity,
+			Price:        l.Price,
+			DiscountPct:  l.DiscountPct,
+			Total:        l.Total,
+			Status:       l.Status,
+		})
+	}
+	return dto.Document{
+		Kind:        d.Kind,
+		Number:      d.Number,
+		Date:        d.Date,
+		DueDate:     d.DueDate,
+		Status:      d.Status,
+		Currency:    d.Currency,
+		NetTotal:    d.NetTotal,
+		Total:       d.Total,
+		VAT:         d.VAT,
+		Reference:   d.Reference,
+		OrderNumber: d.OrderNumber,
+		Lines:       lines,
+		Details:     d.Details,
+	}
+}
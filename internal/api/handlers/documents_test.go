@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"erp-connector/internal/api/dto"
+	"erp-connector/internal/erp"
+)
+
+type fakeDocuments struct {
+	last erp.DocumentSearch
+	page erp.DocumentPage
+}
+
+func (f *fakeDocuments) CustomerDocuments(_ context.Context, q erp.DocumentSearch) (erp.DocumentPage, error) {
+	f.last = q
+	return f.page, nil
+}
+
+func serveDocuments(lookup erp.DocumentLookup, target string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	mux.Handle("GET /api/customers/{extId}/documents", NewCustomerDocumentsHandler(lookup))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	return w
+}
+
+func TestCustomerDocumentsHandler(t *testing.T) {
+	lookup := &fakeDocuments{page: erp.DocumentPage{Total: 1, Documents: []erp.Document{{
+		Kind: erp.DocOrder, Number: "4711", Status: erp.DocStatusOpen, NetTotal: 150, OrderNumber: "1000295",
+		Lines: []erp.DocumentLine{{LineNum: 1, SKU: "SKU-1", Quantity: 2, OpenQuantity: 2, Price: 75, Total: 150, Status: erp.DocStatusOpen}},
+	}}}}
+
+	w := serveDocuments(lookup, "/api/customers/C001/documents?kind=order,invoice&status=open&from=2026-01-01&to=2026-03-31&pageSize=10")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	q := lookup.last
+	if q.ExtID != "C001" || len(q.Kinds) != 2 || !q.OpenOnly || q.From.IsZero() || q.To.Day() != 31 || q.PageSize != 10 {
+		t.Errorf("search = %+v", q)
+	}
+	var resp dto.DocumentsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Total != 1 || len(resp.Documents) != 1 || resp.Documents[0].OrderNumber != "1000295" || len(resp.Documents[0].Lines) != 1 {
+		t.Errorf("response = %+v", resp)
+	}
+
+	for _, target := range []string{
+		"/api/customers/C001/documents?kind=quote",
+		"/api/customers/C001/documents?status=closed",
+		"/api/customers/C001/documents?from=01/01/2026",
+	} {
+		if w := serveDocuments(lookup, target); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", target, w.Code)
+		}
+	}
+}
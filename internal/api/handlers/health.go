@@ -2,24 +2,45 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"time"
 
+	"erp-connector/internal/api/dto"
 	"erp-connector/internal/api/utils"
-	"erp-connector/internal/config"
 	"erp-connector/internal/db"
+	"erp-connector/internal/db/migrate"
+	"erp-connector/internal/erp"
 )
 
-func NewHealthHandler(cfg config.Config, dbPassword string) http.HandlerFunc {
+// NewHealthHandler checks the company's ERP through its adapter: a ping of the
+// shared SQL pool, or an API probe for ERPs reached over HTTP. While the
+// daemon is in degraded mode it answers 503 DB_UNAVAILABLE without waiting
+// for a dial timeout. When the ERP has connector stored procedures (objects),
+// any that are missing, outdated or modified are listed as drift; drift does
+// not fail the check.
+func NewHealthHandler(adapter erp.Adapter, dbHandle *db.Handle, objects []migrate.Object) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 		defer cancel()
 
-		if err := db.TestConnection(ctx, cfg, dbPassword); err != nil {
-			utils.WriteError(w, http.StatusServiceUnavailable, "Database connection failed", "DB_UNAVAILABLE", nil)
+		if err := adapter.Health(ctx); err != nil {
+			if errors.Is(err, db.ErrUnavailable) {
+				utils.WriteError(w, http.StatusServiceUnavailable, "Database connection failed", "DB_UNAVAILABLE", nil)
+				return
+			}
+			utils.WriteError(w, http.StatusServiceUnavailable, "ERP service unreachable", "ERP_UNAVAILABLE", nil)
 			return
 		}
 
-		utils.WriteJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+		resp := dto.HealthResponse{Status: "ok"}
+		if dbConn := dbHandle.DB(); dbConn != nil && len(objects) > 0 {
+			if statuses, err := migrate.Check(ctx, dbConn, objects); err == nil {
+				if drift := dbObjectDTOs(statuses, true); len(drift) > 0 {
+					resp.Drift = drift
+				}
+			}
+		}
+		utils.WriteJSON(w, http.StatusOK, resp)
 	}
 }
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"erp-connector/internal/api/dto"
+	"erp-connector/internal/api/utils"
+	"erp-connector/internal/db"
+	"erp-connector/internal/erp"
+)
+
+const itemLookupTimeout = 12 * time.Second
+
+// NewItemHandler serves GET /api/items/{sku}.
+func NewItemHandler(lookup erp.ItemLookup) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sku := strings.TrimSpace(r.PathValue("sku"))
+		if sku == "" {
+			utils.WriteError(w, http.StatusBadRequest, "sku is required", "VALIDATION_ERROR", nil)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), itemLookupTimeout)
+		defer cancel()
+
+		it, err := lookup.GetItem(ctx, sku)
+		if err != nil {
+			writeItemError(w, err, sku)
+			return
+		}
+		utils.WriteJSON(w, http.StatusOK, itemDTO(it))
+	}
+}
+
+// NewItemSearchHandler serves GET /api/items with q, barcode, group,
+// manufacturer, modifiedSince, page and pageSize query parameters.
+func NewItemSearchHandler(lookup erp.ItemLookup) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		page, pageSize, err := parsePaging(r)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, err.Error(), "VALIDATION_ERROR", nil)
+			return
+		}
+		query := r.URL.Query()
+		search := erp.ItemSearch{
+			Query:        query.Get("q"),
+			Barcode:      query.Get("barcode"),
+			Group:        query.Get("group"),
+			Manufacturer: query.Get("manufacturer"),
+			Page:         page,
+			PageSize:     pageSize,
+		}
+		if v := strings.TrimSpace(query.Get("modifiedSince")); v != "" {
+			since, err := parseModifiedSince(v)
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, "modifiedSince must be YYYY-MM-DD or RFC 3339", "VALIDATION_ERROR", nil)
+				return
+			}
+			search.ModifiedSince = since
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), itemLookupTimeout)
+		defer cancel()
+
+		res, err := lookup.SearchItems(ctx, search)
+		if err != nil {
+			writeItemError(w, err, "")
+			return
+		}
+
+		items := make([]dto.Item, 0, len(res.Items))
+		for _, it := range res.Items {
+			items = append(items, itemDTO(it))
+		}
+		utils.WriteJSON(w, http.StatusOK, dto.ItemSearchResponse{
+			Items:    items,
+			Page:     page,
+			PageSize: pageSize,
+			Total:    res.Total,
+		})
+	}
+}
+
+// parseModifiedSince accepts a timestamp or a date; a bare date is midnight
+// local time, matching how the ERPs store change dates.
+func parseModifiedSince(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", v, time.Local)
+}
+
+func writeItemError(w http.ResponseWriter, err error, sku string) {
+	switch {
+	case errors.Is(err, erp.ErrNotFound):
+		utils.WriteError(w, http.StatusNotFound, "Item not found", "ITEM_NOT_FOUND", map[string]any{"sku": sku})
+	case errors.Is(err, erp.ErrInvalidRequest):
+		utils.WriteError(w, http.StatusBadRequest, err.Error(), "VALIDATION_ERROR", nil)
+	case errors.Is(err, db.ErrUnavailable):
+		utils.WriteError(w, http.StatusServiceUnavailable, "Database connection unavailable", "DB_UNAVAILABLE", nil)
+	default:
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to load items", "ITEM_LOOKUP_FAILED", nil)
+	}
+}
+
+func itemDTO(it erp.Item) dto.Item {
+	barcodes := it.Barcodes
+	if barcodes == nil {
+		barcodes = []string{}
+	}
+	return dto.Item{
+		SKU:          it.SKU,
+		Name:         it.Name,
+		Barcodes:     barcodes,
+		Group:        it.Group,
+		Manufacturer: it.Manufacturer,
+		Units:        dto.ItemUnits{Sales: it.SalesUnit, Inventory: it.InventoryUnit},
+		PackSize:     it.PackSize,
+		VATClass:     it.VATClass,
+		Active:       it.Active,
+		ModifiedAt:   it.ModifiedAt,
+		Details:      it.Details,
+	}
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"erp-connector/internal/api/dto"
+	"erp-connector/internal/erp"
+)
+
+type fakeItems struct {
+	items     map[string]erp.Item
+	lastQuery erp.ItemSearch
+	searchErr error
+}
+
+func (f *fakeItems) GetItem(_ context.Context, sku string) (erp.Item, error) {
+	it, ok := f.items[sku]
+	if !ok {
+		return erp.Item{}, erp.ErrNotFound
+	}
+	return it, nil
+}
+
+func (f *fakeItems) SearchItems(_ context.Context, q erp.ItemSearch) (erp.ItemPage, error) {
+	f.lastQuery = q
+	if f.searchErr != nil {
+		return erp.ItemPage{}, f.searchErr
+	}
+	page := erp.ItemPage{Page: q.Page, PageSize: q.PageSize, Total: len(f.items)}
+	for _, it := range f.items {
+		page.Items = append(page.Items, it)
+	}
+	return page, nil
+}
+
+func serveItems(lookup erp.ItemLookup, target string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	mux.Handle("GET /api/items", NewItemSearchHandler(lookup))
+	mux.Handle("GET /api/items/{sku}", NewItemHandler(lookup))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	return w
+}
+
+func TestItemHandler(t *testing.T) {
+	lookup := &fakeItems{items: map[string]erp.Item{
+		"SKU-1": {SKU: "SKU-1", Name: "Widget", Barcodes: []string{"7290000000001"}, SalesUnit: "Box", InventoryUnit: "Unit", PackSize: 12, Active: true},
+	}}
+
+	w := serveItems(lookup, "/api/items/SKU-1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	var got dto.Item
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.SKU != "SKU-1" || got.Units.Sales != "Box" || got.Units.Inventory != "Unit" || got.PackSize != 12 || !got.Active {
+		t.Errorf("item = %+v", got)
+	}
+
+	if w := serveItems(lookup, "/api/items/NOPE"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown item status = %d", w.Code)
+	}
+}
+
+func TestItemSearchHandler(t *testing.T) {
+	lookup := &fakeItems{items: map[string]erp.Item{"SKU-1": {SKU: "SKU-1"}}}
+
+	w := serveItems(lookup, "/api/items?q=wid&barcode=729&group=10&manufacturer=Acme&modifiedSince=2026-03-01T08:00:00Z&pageSize=20")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	want := erp.ItemSearch{
+		Query: "wid", Barcode: "729", Group: "10", Manufacturer: "Acme",
+		ModifiedSince: time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC), Page: 1, PageSize: 20,
+	}
+	got := lookup.lastQuery
+	if !got.ModifiedSince.Equal(want.ModifiedSince) {
+		t.Errorf("modifiedSince = %v, want %v", got.ModifiedSince, want.ModifiedSince)
+	}
+	got.ModifiedSince, want.ModifiedSince = time.Time{}, time.Time{}
+	if got != want {
+		t.Errorf("query = %+v, want %+v", got, want)
+	}
+	var resp dto.ItemSearchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Total != 1 || len(resp.Items) != 1 || resp.Items[0].Barcodes == nil {
+		t.Errorf("response = %+v", resp)
+	}
+
+	if w := serveItems(lookup, "/api/items?modifiedSince=yesterday"); w.Code != http.StatusBadRequest {
+		t.Errorf("bad modifiedSince status = %d", w.Code)
+	}
+
+	lookup.searchErr = fmt.Errorf("%w: manufacturer filter is not available", erp.ErrInvalidRequest)
+	if w := serveItems(lookup, "/api/items?manufacturer=x"); w.Code != http.StatusBadRequest {
+		t.Errorf("unsupported filter status = %d", w.Code)
+	}
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"erp-connector/internal/api/dto"
+	"erp-connector/internal/api/utils"
+	"erp-connector/internal/db"
+	"erp-connector/internal/db/migrate"
+)
+
+const migrateTimeout = 2 * time.Minute
+
+// NewMigrationsHandler serves GET /api/admin/migrations (state of every
+// connector stored procedure) and POST /api/admin/migrations?dryRun=true
+// (install or upgrade them, or only return the DDL).
+func NewMigrationsHandler(dbHandle *db.Handle, objects []migrate.Object) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dbConn := dbHandle.DB()
+		if dbConn == nil {
+			utils.WriteError(w, http.StatusServiceUnavailable, "Database connection unavailable", "DB_UNAVAILABLE", nil)
+			return
+		}
+		if len(objects) == 0 {
+			utils.WriteJSON(w, http.StatusOK, dto.MigrationsResponse{Objects: []dto.DBObject{}})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), migrateTimeout)
+		defer cancel()
+
+		if r.Method == http.MethodGet {
+			statuses, err := migrate.Check(ctx, dbConn, objects)
+			if err != nil {
+				utils.WriteError(w, http.StatusInternalServerError, "Failed to read connector SQL objects", "MIGRATION_FAILED",
+					map[string]any{"error": err.Error()})
+				return
+			}
+			utils.WriteJSON(w, http.StatusOK, dto.MigrationsResponse{Objects: dbObjectDTOs(statuses, false)})
+			return
+		}
+
+		dryRun := false
+		if v := r.URL.Query().Get("dryRun"); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, "dryRun must be true or false", "VALIDATION_ERROR", nil)
+				return
+			}
+			dryRun = b
+		}
+		res, err := migrate.Apply(ctx, dbConn, objects, dryRun)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, "Failed to install connector SQL objects", "MIGRATION_FAILED",
+				map[string]any{"error": err.Error(), "applied": res.Applied})
+			return
+		}
+		out := dto.MigrationsResponse{Objects: dbObjectDTOs(res.Before, false), DryRun: dryRun, Applied: res.Applied}
+		if dryRun {
+			out.DDL = res.DDL
+		}
+		utils.WriteJSON(w, http.StatusOK, out)
+	}
+}
+
+// dbObjectDTOs converts statuses; with driftOnly it keeps only objects that
+// differ from what the connector installs.
+func dbObjectDTOs(statuses []migrate.Status, driftOnly bool) []dto.DBObject {
+	out := []dto.DBObject{}
+	for _, st := range statuses {
+		if driftOnly && !st.Drift() {
+			continue
+		}
+		out = append(out, dto.DBObject{
+			Name:             st.Name,
+			State:            st.State,
+			Version:          st.Version,
+			InstalledVersion: st.InstalledVersion,
+		})
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"erp-connector/internal/api/utils"
+	"erp-connector/internal/erp"
+)
+
+// NewCancelOrderHandler serves DELETE /api/sendOrder/{jobId}: it cancels a
+// job that is still queued. Jobs that have started answer 409
+// JOB_NOT_QUEUED.
+func NewCancelOrderHandler(orders erp.OrderEditor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		jobID := strings.TrimSpace(r.PathValue("jobId"))
+		if jobID == "" {
+			utils.WriteError(w, http.StatusBadRequest, "jobId is required", "VALIDATION_ERROR", nil)
+			return
+		}
+		job, err := orders.CancelOrder(jobID)
+		if err != nil {
+			writeOrderEditError(w, orders, jobID, err)
+			return
+		}
+		utils.WriteJSON(w, http.StatusOK, orderJobResponse(job))
+	}
+}
+
+// NewUpdateOrderHandler serves PUT /api/sendOrder/{jobId}: it validates the
+// body like POST /api/sendOrder (credit check included) and replaces the
+// order of a job that is still queued. The job keeps its ID, order number and
+// place by submission time; with another priority that place is in the new
+// priority's lane.
+func NewUpdateOrderHandler(orders erp.OrderEditor, credit *CreditPolicy) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		jobID := strings.TrimSpace(r.PathValue("jobId"))
+		if jobID == "" {
+			utils.WriteError(w, http.StatusBadRequest, "jobId is required", "VALIDATION_ERROR", nil)
+			return
+		}
+		orderReq, ok := decodeSendOrder(w, r)
+		if !ok {
+			return
+		}
+		if credit != nil && orderReq.DocumentType == "ORDER" {
+			if _, _, ok := credit.check(r.Context(), w, &orderReq, jobID); !ok {
+				return
+			}
+		}
+
+		job, err := orders.UpdateOrder(jobID, orderReq)
+		if err != nil {
+			writeOrderEditError(w, orders, jobID, err)
+			return
+		}
+		utils.WriteJSON(w, http.StatusOK, orderJobResponse(job))
+	}
+}
+
+// writeOrderEditError maps CancelOrder / UpdateOrder errors to responses.
+func writeOrderEditError(w http.ResponseWriter, orders erp.OrderEditor, jobID string, err error) {
+	switch {
+	case errors.Is(err, erp.ErrNotFound):
+		utils.WriteError(w, http.StatusNotFound, "Job not found", "JOB_NOT_FOUND", map[string]any{"jobId": jobID})
+	case errors.Is(err, erp.ErrJobNotQueued):
+		details := map[string]any{"jobId": jobID}
+		if jobs, ok := orders.(erp.OrderJobs); ok {
+			if job, ok := jobs.OrderJob(jobID); ok {
+				details["status"] = string(job.Status)
+			}
+		}
+		utils.WriteError(w, http.StatusConflict, "Job has already started and can no longer be changed", "JOB_NOT_QUEUED", details)
+	case errors.Is(err, erp.ErrNotSupported):
+		utils.WriteError(w, http.StatusNotImplemented, "Order editing is not supported", "NOT_IMPLEMENTED", nil)
+	default:
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to change order", "ORDER_EDIT_FAILED", nil)
+	}
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"erp-connector/internal/api/dto"
+	"erp-connector/internal/erp"
+)
+
+func TestOrderEditHandlers(t *testing.T) {
+	orders := newTestQueueWithNumberStore(t)
+	first, _ := orders.SubmitOrder(erp.OrderRequest{HistoryID: "H-1"})
+	second, _ := orders.SubmitOrder(erp.OrderRequest{HistoryID: "H-2"})
+
+	editor := orders.(erp.OrderEditor)
+	mux := http.NewServeMux()
+	mux.Handle("PUT /api/sendOrder/{jobId}", NewUpdateOrderHandler(editor, nil))
+	mux.Handle("DELETE /api/sendOrder/{jobId}", NewCancelOrderHandler(editor))
+	do := func(method, path string, body any) (*httptest.ResponseRecorder, dto.SendOrderJob) {
+		t.Helper()
+		var b []byte
+		if body != nil {
+			b, _ = json.Marshal(body)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(method, path, bytes.NewReader(b)))
+		var resp dto.SendOrderJob
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w, resp
+	}
+
+	w, resp := do(http.MethodPut, "/api/sendOrder/"+first, validOrderBody())
+	if w.Code != http.StatusOK || resp.Status != "queued" || resp.JobID != first {
+		t.Fatalf("PUT: status = %d body=%s", w.Code, w.Body.String())
+	}
+	bad := validOrderBody()
+	delete(bad, "userExtId")
+	if w, _ := do(http.MethodPut, "/api/sendOrder/"+first, bad); w.Code != http.StatusBadRequest {
+		t.Errorf("PUT invalid body: status = %d, want 400", w.Code)
+	}
+
+	w, resp = do(http.MethodDelete, "/api/sendOrder/"+second, nil)
+	if w.Code != http.StatusOK || resp.Status != "cancelled" || resp.JobID != second {
+		t.Fatalf("DELETE: status = %d body=%s", w.Code, w.Body.String())
+	}
+	if w, _ := do(http.MethodDelete, "/api/sendOrder/"+second, nil); w.Code != http.StatusConflict {
+		t.Errorf("DELETE cancelled job: status = %d, want 409", w.Code)
+	}
+	if w, _ := do(http.MethodPut, "/api/sendOrder/"+second, validOrderBody()); w.Code != http.StatusConflict {
+		t.Errorf("PUT cancelled job: status = %d, want 409", w.Code)
+	}
+	if w, _ := do(http.MethodDelete, "/api/sendOrder/999", nil); w.Code != http.StatusNotFound {
+		t.Errorf("DELETE unknown job: status = %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"erp-connector/internal/api/dto"
+	"erp-connector/internal/api/utils"
+	"erp-connector/internal/erp"
+)
+
+// NewOrderJobHandler serves GET /api/sendOrder/{jobId}: the state of a
+// queued order and, when it failed, the errors the ERP reported per line.
+func NewOrderJobHandler(jobs erp.OrderJobs) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		jobID := strings.TrimSpace(r.PathValue("jobId"))
+		if jobID == "" {
+			utils.WriteError(w, http.StatusBadRequest, "jobId is required", "VALIDATION_ERROR", nil)
+			return
+		}
+		job, ok := jobs.OrderJob(jobID)
+		if !ok {
+			utils.WriteError(w, http.StatusNotFound, "Job not found", "JOB_NOT_FOUND", map[string]any{"jobId": jobID})
+			return
+		}
+
+		utils.WriteJSON(w, http.StatusOK, orderJobResponse(job))
+	}
+}
+
+// orderJobResponse is the API view of a sendOrder job.
+func orderJobResponse(job erp.JobResult) dto.SendOrderJob {
+	out := dto.SendOrderJob{
+		JobID:          job.ID,
+		Status:         string(job.Status),
+		OrderNumber:    job.OrderNumber,
+		DocumentNumber: job.DocumentNumber,
+		Entity:         job.Entity,
+		VATRate:        job.VATRate,
+		VATExempt:      job.VATExempt,
+	}
+	if job.Err != nil {
+		out.Error = job.Err.Error()
+	}
+	for _, e := range job.Errors {
+		out.Errors = append(out.Errors, dto.OrderJobError{
+			Line:       e.Line,
+			SKU:        e.SKU,
+			Field:      e.Field,
+			FieldTitle: e.FieldTitle,
+			Message:    e.Message,
+			Text:       e.String(),
+		})
+	}
+	return out
+}
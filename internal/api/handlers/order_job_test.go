@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"erp-connector/internal/api/dto"
+	"erp-connector/internal/erp"
+)
+
+type fakeJobs map[string]erp.JobResult
+
+func (f fakeJobs) OrderJob(id string) (erp.JobResult, bool) {
+	r, ok := f[id]
+	return r, ok
+}
+
+func TestOrderJobHandler(t *testing.T) {
+	jobs := fakeJobs{"1000295": {
+		ID:          "1000295",
+		Status:      erp.JobStatusFailed,
+		OrderNumber: 1000295,
+		Err:         errors.New("import failed for order 1000295"),
+		Errors:      []erp.JobError{{Line: 3, SKU: "SKU-003", Field: "line22", FieldTitle: "מפתח פריט - 22", Message: "unknown SKU"}},
+	}}
+	mux := http.NewServeMux()
+	mux.Handle("GET /api/sendOrder/{jobId}", NewOrderJobHandler(jobs))
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/sendOrder/1000295", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d body=%s", w.Code, w.Body.String())
+	}
+	var resp dto.SendOrderJob
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != "failed" || len(resp.Errors) != 1 {
+		t.Fatalf("response = %s", w.Body.String())
+	}
+	if got, want := resp.Errors[0].Text, "line 3 (SKU-003): מפתח פריט - 22: unknown SKU"; got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/sendOrder/nope", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown job: status = %d, want 404", w.Code)
+	}
+}
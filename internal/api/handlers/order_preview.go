@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"erp-connector/internal/api/dto"
+	"erp-connector/internal/api/utils"
+	"erp-connector/internal/db"
+	"erp-connector/internal/erp"
+)
+
+// writeOrderPreview answers POST /api/sendOrder?dryRun=true with the document
+// orders would build for req.
+func writeOrderPreview(w http.ResponseWriter, r *http.Request, orders erp.OrderSubmitter, req erp.OrderRequest,
+	credit *dto.SendOrderCredit, warnings []string, start time.Time) {
+	previewer, ok := orders.(erp.OrderPreviewer)
+	if !ok {
+		utils.WriteError(w, http.StatusNotImplemented, "Order preview not implemented for this ERP", "NOT_IMPLEMENTED", nil)
+		return
+	}
+	preview, err := previewer.PreviewOrder(r.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrUnavailable):
+			utils.WriteError(w, http.StatusServiceUnavailable, "Database connection unavailable", "DB_UNAVAILABLE", nil)
+		case errors.Is(err, erp.ErrInvalidRequest):
+			utils.WriteError(w, http.StatusBadRequest, err.Error(), "VALIDATION_ERROR", nil)
+		case errors.Is(err, erp.ErrNotFound):
+			utils.WriteError(w, http.StatusUnprocessableEntity, "Customer not found", "CUSTOMER_NOT_FOUND",
+				map[string]any{"extId": req.UserExtID})
+		case errors.Is(err, erp.ErrNotSupported):
+			utils.WriteError(w, http.StatusNotImplemented, "Order preview not implemented for this ERP", "NOT_IMPLEMENTED", nil)
+		default:
+			utils.WriteError(w, http.StatusUnprocessableEntity, err.Error(), "PREVIEW_FAILED", nil)
+		}
+		return
+	}
+
+	resp := dto.SendOrderPreview{
+		Status:      "preview",
+		OrderNumber: preview.OrderNumber,
+		Account: dto.SendOrderAccount{
+			AccountKey: preview.Account.AccountKey,
+			FullName:   preview.Account.FullName,
+			Address:    preview.Account.Address,
+			City:       preview.Account.City,
+			Phone:      preview.Account.Phone,
+		},
+		CurrencyRate: preview.CurrencyRate,
+		VATRate:      preview.VATRate,
+		VATExempt:    preview.VATExempt,
+		Records:      make([]dto.SendOrderPreviewRow, 0, len(preview.Records)),
+		Warnings:     append(preview.Warnings, warnings...),
+		Credit:       credit,
+		Meta:         dto.SendOrderMeta{DurationMs: time.Since(start).Milliseconds()},
+	}
+	for _, rec := range preview.Records {
+		row := dto.SendOrderPreviewRow{Line: rec.Line, Fields: make([]dto.SendOrderPreviewField, 0, len(rec.Fields))}
+		for _, f := range rec.Fields {
+			row.Fields = append(row.Fields, dto.SendOrderPreviewField{
+				Field:     f.Key,
+				Title:     f.Title,
+				Start:     f.Start,
+				Width:     f.Width,
+				Value:     f.Value,
+				Truncated: f.Truncated,
+			})
+		}
+		resp.Records = append(resp.Records, row)
+	}
+	utils.WriteJSON(w, http.StatusOK, resp)
+}
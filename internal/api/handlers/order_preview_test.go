@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"erp-connector/internal/erp"
+)
+
+type fakePreviewer struct {
+	preview   erp.OrderPreview
+	err       error
+	submitted bool
+	previewed erp.OrderRequest
+}
+
+func (f *fakePreviewer) SubmitOrder(req erp.OrderRequest) (string, error) {
+	f.submitted = true
+	return "1", nil
+}
+
+func (f *fakePreviewer) PreviewOrder(ctx context.Context, req erp.OrderRequest) (erp.OrderPreview, error) {
+	f.previewed = req
+	return f.preview, f.err
+}
+
+func previewRequest(t *testing.T, handler http.HandlerFunc, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	b, _ := json.Marshal(validOrderBody())
+	req := httptest.NewRequest(http.MethodPost, "/api/sendOrder?"+query, bytes.NewReader(b))
+	w := httptest.NewRecorder()
+	handler(w, req)
+	return w
+}
+
+func TestSendOrderHandler_DryRun(t *testing.T) {
+	vat := 18.0
+	f := &fakePreviewer{preview: erp.OrderPreview{
+		OrderNumber: 1000295,
+		Account:     erp.AccountInfo{AccountKey: "CUST001", FullName: "Acme"},
+		VATRate:     &vat,
+		Records: []erp.PreviewRecord{{Line: 1, Fields: []erp.PreviewField{
+			{Key: "line28", Title: "שם פריט - 28", Start: 598, Width: 100, Value: "Test Item"},
+		}}},
+		Warnings: []string{"line 1: line37 (הערות - 37) is 300 characters, cut to 250"},
+	}}
+	w := previewRequest(t, NewSendOrderHandler(f, nil), "dryRun=true")
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d, want 200; body: %s", w.Code, w.Body.String())
+	}
+	if f.submitted {
+		t.Error("dry run must not submit the order")
+	}
+	if f.previewed.Details[0].SKU != "SKU-001" {
+		t.Errorf("previewed request = %+v", f.previewed)
+	}
+
+	var resp struct {
+		Status      string `json:"status"`
+		OrderNumber int64  `json:"orderNumber"`
+		VATRate     float64
+		Records     []struct {
+			Line   int `json:"line"`
+			Fields []struct {
+				Field, Value string
+			} `json:"fields"`
+		} `json:"records"`
+		Warnings []string `json:"warnings"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != "preview" || resp.OrderNumber != 1000295 || resp.VATRate != 18 || len(resp.Warnings) != 1 {
+		t.Errorf("response = %s", w.Body.String())
+	}
+	if len(resp.Records) != 1 || resp.Records[0].Fields[0].Field != "line28" || resp.Records[0].Fields[0].Value != "Test Item" {
+		t.Errorf("records = %+v", resp.Records)
+	}
+}
+
+func TestSendOrderHandler_DryRunErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+		wantErr  string
+	}{
+		{"invalid", fmt.Errorf("%w: details[0]: unit is 6 characters", erp.ErrInvalidRequest), http.StatusBadRequest, "VALIDATION_ERROR"},
+		{"unknown customer", fmt.Errorf("query account: account %w", erp.ErrNotFound), http.StatusUnprocessableEntity, "CUSTOMER_NOT_FOUND"},
+		{"other", fmt.Errorf("no VAT rate configured for 2010-01-01"), http.StatusUnprocessableEntity, "PREVIEW_FAILED"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakePreviewer{err: tt.err}
+			w := previewRequest(t, NewSendOrderHandler(f, nil), "dryRun=1")
+			if w.Code != tt.wantCode || !bytes.Contains(w.Body.Bytes(), []byte(tt.wantErr)) {
+				t.Errorf("got %d %s, want %d %s", w.Code, w.Body.String(), tt.wantCode, tt.wantErr)
+			}
+			if f.submitted {
+				t.Error("dry run must not submit the order")
+			}
+		})
+	}
+}
+
+func TestSendOrderHandler_DryRunUnsupported(t *testing.T) {
+	w := previewRequest(t, NewSendOrderHandler(&submitOnly{}, nil), "dryRun=true")
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("got %d, want 501", w.Code)
+	}
+	if w := previewRequest(t, NewSendOrderHandler(&submitOnly{}, nil), "dryRun=maybe"); w.Code != http.StatusBadRequest {
+		t.Errorf("bad dryRun: got %d, want 400", w.Code)
+	}
+}
+
+type submitOnly struct{}
+
+func (submitOnly) SubmitOrder(req erp.OrderRequest) (string, error) { return "1", nil }
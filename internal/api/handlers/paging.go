@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// parsePaging reads the 1-based page and pageSize query parameters of list
+// endpoints. Missing values default to page 1 of defaultPageSize rows.
+func parsePaging(r *http.Request) (page, pageSize int, err error) {
+	page, pageSize = 1, defaultPageSize
+	q := r.URL.Query()
+	if v := strings.TrimSpace(q.Get("page")); v != "" {
+		page, err = strconv.Atoi(v)
+		if err != nil || page < 1 {
+			return 0, 0, fmt.Errorf("page must be a positive integer")
+		}
+	}
+	if v := strings.TrimSpace(q.Get("pageSize")); v != "" {
+		pageSize, err = strconv.Atoi(v)
+		if err != nil || pageSize < 1 || pageSize > maxPageSize {
+			return 0, 0, fmt.Errorf("pageSize must be between 1 and %d", maxPageSize)
+		}
+	}
+	return page, pageSize, nil
+}
@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -10,10 +9,8 @@ import (
 
 	"erp-connector/internal/api/dto"
 	"erp-connector/internal/api/utils"
-	"erp-connector/internal/config"
+	"erp-connector/internal/db"
 	"erp-connector/internal/erp"
-	"erp-connector/internal/erp/hasavshevet"
-	"erp-connector/internal/erp/sap"
 )
 
 const (
@@ -21,13 +18,9 @@ const (
 	priceStockMaxBytes = 1 << 20
 )
 
-func NewPriceAndStockHandler(cfg config.Config, dbConn *sql.DB) http.HandlerFunc {
+// NewPriceAndStockHandler serves price/stock from the company's ERP adapter.
+func NewPriceAndStockHandler(provider erp.PriceStockProvider) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if dbConn == nil {
-			utils.WriteError(w, http.StatusServiceUnavailable, "Database connection unavailable", "DB_UNAVAILABLE", nil)
-			return
-		}
-
 		r.Body = http.MaxBytesReader(w, r.Body, priceStockMaxBytes)
 		defer r.Body.Close()
 
@@ -46,44 +39,50 @@ func NewPriceAndStockHandler(cfg config.Config, dbConn *sql.DB) http.HandlerFunc
 		defer cancel()
 
 		erpReq := erp.PriceStockRequest{
-			SKUList:    req.SKUList,
-			Warehouses: req.Warehouses,
-			UserExtID:  req.UserExtID,
-			Date:       req.Date,
-		}
-		if cfg.ERP != config.ERPHasavshevet {
-			erpReq.PriceList = req.PriceList
+			SKUList:      req.SKUList,
+			PriceList:    req.PriceList,
+			Warehouses:   req.Warehouses,
+			UserExtID:    req.UserExtID,
+			Date:         req.Date,
+			Quantities:   req.Quantities,
+			DocumentType: req.DocumentType,
 		}
 
 		start := time.Now()
-		var result erp.PriceStockResult
-		var err error
-
-		switch cfg.ERP {
-		case config.ERPHasavshevet:
-			result, err = hasavshevet.FetchPriceAndStock(ctx, dbConn, cfg, erpReq)
-		case config.ERPSAP:
-			result, err = sap.FetchPriceAndStock(ctx, dbConn, cfg, erpReq)
-		default:
-			utils.WriteError(w, http.StatusBadRequest, "Unsupported ERP type", "ERP_NOT_SUPPORTED", nil)
-			return
-		}
-
+		result, err := provider.FetchPriceAndStock(ctx, erpReq)
 		if err != nil {
-			if errors.Is(err, sap.ErrNotImplemented) {
+			switch {
+			case errors.Is(err, db.ErrUnavailable):
+				utils.WriteError(w, http.StatusServiceUnavailable, "Database connection unavailable", "DB_UNAVAILABLE", nil)
+			case errors.Is(err, erp.ErrInvalidRequest):
+				utils.WriteError(w, http.StatusBadRequest, err.Error(), "VALIDATION_ERROR", nil)
+			case errors.Is(err, erp.ErrNotSupported):
 				utils.WriteError(w, http.StatusNotImplemented, "Price/stock not implemented", "NOT_IMPLEMENTED", nil)
-				return
+			default:
+				utils.WriteError(w, http.StatusInternalServerError, "Failed to load price and stock", "PRICE_STOCK_FAILED", nil)
 			}
-			utils.WriteError(w, http.StatusInternalServerError, "Failed to load price and stock", "PRICE_STOCK_FAILED", nil)
 			return
 		}
 
 		items := make([]dto.PriceStockItem, 0, len(result.Items))
 		for _, item := range result.Items {
+			var whStock map[string]dto.WarehouseStock
+			if len(item.WarehouseStock) > 0 {
+				whStock = make(map[string]dto.WarehouseStock, len(item.WarehouseStock))
+				for wh, ws := range item.WarehouseStock {
+					whStock[wh] = dto.WarehouseStock{
+						OnHand:    ws.OnHand,
+						Committed: ws.Committed,
+						OnOrder:   ws.OnOrder,
+						Available: ws.Available,
+					}
+				}
+			}
 			items = append(items, dto.PriceStockItem{
 				SKU:              item.SKU,
 				Prices:           item.Prices,
 				StockByWarehouse: item.StockByWarehouse,
+				WarehouseStock:   whStock,
 				Details:          item.Details,
 			})
 		}
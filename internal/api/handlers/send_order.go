@@ -3,163 +3,245 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"slices"
+	"strconv"
 	"time"
 
 	"erp-connector/internal/api/dto"
 	"erp-connector/internal/api/utils"
-	"erp-connector/internal/erp/hasavshevet"
+	"erp-connector/internal/erp"
 )
 
 const sendOrderMaxBytes = 1 << 20 // 1 MiB
 
 // NewSendOrderHandler returns a handler that validates an order request,
-// enqueues it on the Hasavshevet single-worker queue, and returns 202 Accepted
+// enqueues it through the ERP adapter's order queue, and returns 202 Accepted
 // with a job ID that can be used to track processing status.
 //
 // Using async processing means the HTTP response is returned immediately;
 // the caller does not block while IMOVEIN files are written and has.exe runs.
-func NewSendOrderHandler(queue *hasavshevet.OrderQueue) http.HandlerFunc {
+//
+// When credit is non-nil, ORDER documents are credit checked before they are
+// queued (see CreditPolicy).
+//
+// With ?dryRun=true the order is validated and built as the ERP would receive
+// it and returned with 200 OK instead of being queued (see writeOrderPreview).
+func NewSendOrderHandler(orders erp.OrderSubmitter, credit *CreditPolicy) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
-		r.Body = http.MaxBytesReader(w, r.Body, sendOrderMaxBytes)
-		defer r.Body.Close()
+		dryRun := false
+		if v := r.URL.Query().Get("dryRun"); v != "" {
+			var err error
+			if dryRun, err = strconv.ParseBool(v); err != nil {
+				utils.WriteError(w, http.StatusBadRequest, "dryRun must be true or false", "VALIDATION_ERROR", nil)
+				return
+			}
+		}
 
-		var req dto.SendOrderRequest
-		dec := json.NewDecoder(r.Body)
-		if err := dec.Decode(&req); err != nil {
-			utils.WriteError(w, http.StatusBadRequest, "Invalid JSON body", "INVALID_JSON", nil)
+		orderReq, ok := decodeSendOrder(w, r)
+		if !ok {
 			return
 		}
-		if err := ensureEOF(dec); err != nil {
-			utils.WriteError(w, http.StatusBadRequest, "Invalid JSON body", "INVALID_JSON", nil)
+
+		var creditResult *dto.SendOrderCredit
+		var warnings []string
+		if credit != nil && orderReq.DocumentType == "ORDER" {
+			var ok bool
+			creditResult, warnings, ok = credit.check(r.Context(), w, &orderReq, "")
+			if !ok {
+				return
+			}
+		}
+
+		if dryRun {
+			writeOrderPreview(w, r, orders, orderReq, creditResult, warnings, start)
 			return
 		}
 
-		// Validate required top-level fields
-		var missing []string
-		if req.DocumentType == "" {
-			missing = append(missing, "documentType")
+		lastOrderNumber, err := orders.SubmitOrder(orderReq)
+		if err != nil {
+			utils.WriteError(w, http.StatusServiceUnavailable,
+				"Order queue full; try again later", "QUEUE_FULL", nil)
+			return
 		}
-		if req.UserExtID == "" {
-			missing = append(missing, "userExtId")
+
+		accepted := dto.SendOrderAccepted{
+			Status:   "queued",
+			JobID:    lastOrderNumber,
+			Credit:   creditResult,
+			Warnings: warnings,
 		}
-		if req.DueDate == "" {
-			missing = append(missing, "dueDate")
+		if queue, ok := orders.(erp.OrderQueuePosition); ok {
+			if pos, ok := queue.QueuePosition(lastOrderNumber); ok {
+				accepted.QueuePosition = pos.Position
+				if pos.Estimated {
+					wait := int64(pos.EstimatedWait.Round(time.Second) / time.Second)
+					accepted.EstimatedWaitSeconds = &wait
+				}
+			}
 		}
-		if req.CreatedDate == "" {
-			missing = append(missing, "createdDate")
+		accepted.Meta = dto.SendOrderMeta{DurationMs: time.Since(start).Milliseconds()}
+		utils.WriteJSON(w, http.StatusAccepted, accepted)
+	}
+}
+
+// decodeSendOrder reads and validates a sendOrder body and maps it to the
+// ERP request. On failure it has written the error response.
+func decodeSendOrder(w http.ResponseWriter, r *http.Request) (erp.OrderRequest, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, sendOrderMaxBytes)
+	defer r.Body.Close()
+
+	var req dto.SendOrderRequest
+	dec := json.NewDecoder(r.Body)
+	if err := dec.Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON body", "INVALID_JSON", nil)
+		return erp.OrderRequest{}, false
+	}
+	if err := ensureEOF(dec); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON body", "INVALID_JSON", nil)
+		return erp.OrderRequest{}, false
+	}
+
+	// Validate required top-level fields
+	var missing []string
+	if req.DocumentType == "" {
+		missing = append(missing, "documentType")
+	}
+	if req.UserExtID == "" {
+		missing = append(missing, "userExtId")
+	}
+	if req.DueDate == "" {
+		missing = append(missing, "dueDate")
+	}
+	if req.CreatedDate == "" {
+		missing = append(missing, "createdDate")
+	}
+	if req.Discount == nil {
+		missing = append(missing, "discount")
+	}
+	if req.HistoryID == "" {
+		missing = append(missing, "historyId")
+	}
+	if req.Total == nil {
+		missing = append(missing, "total")
+	}
+	if len(req.Details) == 0 {
+		missing = append(missing, "details (must be non-empty array)")
+	}
+	if len(missing) > 0 {
+		utils.WriteError(w, http.StatusBadRequest,
+			"Missing required fields: "+joinStrings(missing), "VALIDATION_ERROR", nil)
+		return erp.OrderRequest{}, false
+	}
+
+	// Validate document type
+	switch req.DocumentType {
+	case "ORDER", "QUOATE", "RETURN":
+	default:
+		utils.WriteError(w, http.StatusBadRequest,
+			"Invalid documentType; allowed: ORDER, QUOATE, RETURN", "VALIDATION_ERROR", nil)
+		return erp.OrderRequest{}, false
+	}
+
+	if req.Priority != "" && !slices.Contains(erp.OrderPriorities(), req.Priority) {
+		utils.WriteError(w, http.StatusBadRequest,
+			"Invalid priority; allowed: "+joinStrings(erp.OrderPriorities()), "VALIDATION_ERROR", nil)
+		return erp.OrderRequest{}, false
+	}
+
+	// Validate each detail line
+	for i, item := range req.Details {
+		var itemMissing []string
+		if item.Title == "" {
+			itemMissing = append(itemMissing, "title")
 		}
-		if req.Discount == nil {
-			missing = append(missing, "discount")
+		if item.SKU == "" {
+			itemMissing = append(itemMissing, "sku")
+		}
+		if item.Quantity == nil {
+			itemMissing = append(itemMissing, "quantity")
+		} else if *item.Quantity == 0 {
+			utils.WriteError(w, http.StatusBadRequest,
+				"details["+itoa(i)+"]: quantity cannot be zero (Hasavshevet spec line23)",
+				"VALIDATION_ERROR", nil)
+			return erp.OrderRequest{}, false
 		}
-		if req.HistoryID == "" {
-			missing = append(missing, "historyId")
+		if item.OriginalPrice == nil {
+			itemMissing = append(itemMissing, "originalPrice")
 		}
-		if req.Total == nil {
-			missing = append(missing, "total")
+		if item.SinglePrice == nil {
+			itemMissing = append(itemMissing, "singlePrice")
 		}
-		if len(req.Details) == 0 {
-			missing = append(missing, "details (must be non-empty array)")
+		if item.TotalPrice == nil {
+			itemMissing = append(itemMissing, "totalPrice")
 		}
-		if len(missing) > 0 {
+		if item.Discount == nil {
+			itemMissing = append(itemMissing, "discount")
+		}
+		if item.Warehouse < 0 {
 			utils.WriteError(w, http.StatusBadRequest,
-				"Missing required fields: "+joinStrings(missing), "VALIDATION_ERROR", nil)
-			return
+				"details["+itoa(i)+"]: warehouse cannot be negative",
+				"VALIDATION_ERROR", nil)
+			return erp.OrderRequest{}, false
 		}
-
-		// Validate document type
-		switch req.DocumentType {
-		case "ORDER", "QUOATE", "RETURN":
-		default:
+		if len(itemMissing) > 0 {
 			utils.WriteError(w, http.StatusBadRequest,
-				"Invalid documentType; allowed: ORDER, QUOATE, RETURN", "VALIDATION_ERROR", nil)
-			return
+				"Missing fields in details["+itoa(i)+"]: "+joinStrings(itemMissing),
+				"VALIDATION_ERROR", nil)
+			return erp.OrderRequest{}, false
 		}
+	}
 
-		// Validate each detail line
-		for i, item := range req.Details {
-			var itemMissing []string
-			if item.Title == "" {
-				itemMissing = append(itemMissing, "title")
-			}
-			if item.SKU == "" {
-				itemMissing = append(itemMissing, "sku")
-			}
-			if item.Quantity == nil {
-				itemMissing = append(itemMissing, "quantity")
-			} else if *item.Quantity == 0 {
-				utils.WriteError(w, http.StatusBadRequest,
-					"details["+itoa(i)+"]: quantity cannot be zero (Hasavshevet spec line23)",
-					"VALIDATION_ERROR", nil)
-				return
-			}
-			if item.OriginalPrice == nil {
-				itemMissing = append(itemMissing, "originalPrice")
-			}
-			if item.SinglePrice == nil {
-				itemMissing = append(itemMissing, "singlePrice")
-			}
-			if item.TotalPrice == nil {
-				itemMissing = append(itemMissing, "totalPrice")
-			}
-			if item.Discount == nil {
-				itemMissing = append(itemMissing, "discount")
-			}
-			if len(itemMissing) > 0 {
-				utils.WriteError(w, http.StatusBadRequest,
-					"Missing fields in details["+itoa(i)+"]: "+joinStrings(itemMissing),
-					"VALIDATION_ERROR", nil)
-				return
-			}
+	// Map DTO → internal request
+	details := make([]erp.OrderLineItem, 0, len(req.Details))
+	for _, d := range req.Details {
+		var packs float64
+		if d.Packs != nil {
+			packs = *d.Packs
 		}
-
-		// Map DTO → internal request
-		details := make([]hasavshevet.OrderLineItem, 0, len(req.Details))
-		for _, d := range req.Details {
-			var packs float64
-			if d.Packs != nil {
-				packs = *d.Packs
+		var extra erp.OrderLineExtra
+		if d.Extra != nil {
+			extra = erp.OrderLineExtra{
+				Text1: d.Extra.Text1, Text2: d.Extra.Text2,
+				Sum1: d.Extra.Sum1, Sum2: d.Extra.Sum2,
+				Date1: d.Extra.Date1, Date2: d.Extra.Date2,
+				Num1: d.Extra.Num1, Num2: d.Extra.Num2,
 			}
-			details = append(details, hasavshevet.OrderLineItem{
-				Title:         d.Title,
-				SKU:           d.SKU,
-				Quantity:      *d.Quantity,
-				Packs:         packs,
-				OriginalPrice: *d.OriginalPrice,
-				SinglePrice:   *d.SinglePrice,
-				TotalPrice:    *d.TotalPrice,
-				Discount:      *d.Discount,
-			})
-		}
-
-		orderReq := hasavshevet.OrderRequest{
-			DocumentType:  req.DocumentType,
-			UserExtID:     req.UserExtID,
-			DueDate:       req.DueDate,
-			CreatedDate:   req.CreatedDate,
-			Comment:       req.Comment,
-			Discount:      *req.Discount,
-			HistoryID:     req.HistoryID,
-			Total:         *req.Total,
-			Currency:      req.Currency,
-			CustomerEmail: req.CustomerEmail,
-			Details:       details,
-		}
-
-		lastOrderNumber, err := queue.Submit(orderReq)
-		if err != nil {
-			utils.WriteError(w, http.StatusServiceUnavailable,
-				"Order queue full; try again later", "QUEUE_FULL", nil)
-			return
 		}
-
-		utils.WriteJSON(w, http.StatusAccepted, dto.SendOrderAccepted{
-			Status: "queued",
-			JobID:  lastOrderNumber,
-			Meta:   dto.SendOrderMeta{DurationMs: time.Since(start).Milliseconds()},
+		details = append(details, erp.OrderLineItem{
+			Title:         d.Title,
+			SKU:           d.SKU,
+			Quantity:      *d.Quantity,
+			Packs:         packs,
+			OriginalPrice: *d.OriginalPrice,
+			SinglePrice:   *d.SinglePrice,
+			TotalPrice:    *d.TotalPrice,
+			Discount:      *d.Discount,
+			Warehouse:     d.Warehouse,
+			Remarks:       d.Remarks,
+			Unit:          d.Unit,
+			DueDate:       d.DueDate,
+			Batch:         d.Batch,
+			Serial:        d.Serial,
+			Extra:         extra,
 		})
 	}
+
+	return erp.OrderRequest{
+		DocumentType:  req.DocumentType,
+		UserExtID:     req.UserExtID,
+		DueDate:       req.DueDate,
+		CreatedDate:   req.CreatedDate,
+		Comment:       req.Comment,
+		Discount:      *req.Discount,
+		HistoryID:     req.HistoryID,
+		Total:         *req.Total,
+		Currency:      req.Currency,
+		CustomerEmail: req.CustomerEmail,
+		Priority:      req.Priority,
+		Details:       details,
+	}, true
 }
 
 // joinStrings joins string slices with ", " separator.
@@ -8,23 +8,27 @@ import (
 	"path/filepath"
 	"testing"
 
+	"erp-connector/internal/api/dto"
 	"erp-connector/internal/config"
+	"erp-connector/internal/erp"
 	"erp-connector/internal/erp/hasavshevet"
 )
 
-// newTestQueue returns an OrderQueue with a nil sender.
-// The queue is not started, so submitted jobs are never executed.
+// newTestQueue returns a Hasavshevet adapter over an OrderQueue with a nil
+// sender. The queue is not started, so submitted jobs are never executed.
 // This is safe for handler-level tests that only exercise validation.
-func newTestQueue() *hasavshevet.OrderQueue {
-	return hasavshevet.NewOrderQueue(nil, &noopLogger{})
+func newTestQueue() erp.OrderSubmitter {
+	q := hasavshevet.NewOrderQueue(nil, &noopLogger{})
+	return hasavshevet.NewAdapter(nil, config.Config{}, q, &noopLogger{})
 }
 
-func newTestQueueWithNumberStore(t *testing.T) *hasavshevet.OrderQueue {
+func newTestQueueWithNumberStore(t *testing.T) erp.OrderSubmitter {
 	t.Helper()
 	dir := t.TempDir()
 	numStore := hasavshevet.NewOrderNumberStore(filepath.Join(dir, "lastOrderNumber.json"))
 	sender := hasavshevet.NewSender(nil, config.Config{}, numStore, &noopLogger{})
-	return hasavshevet.NewOrderQueue(sender, &noopLogger{})
+	q := hasavshevet.NewOrderQueue(sender, &noopLogger{})
+	return hasavshevet.NewAdapter(nil, config.Config{}, q, &noopLogger{})
 }
 
 type noopLogger struct{}
@@ -52,7 +56,7 @@ func ptr[T any](v T) *T { return &v }
 
 // TestSendOrderHandler_EmptyBody returns 400 for an empty JSON object.
 func TestSendOrderHandler_EmptyBody(t *testing.T) {
-	h := NewSendOrderHandler(newTestQueue())
+	h := NewSendOrderHandler(newTestQueue(), nil)
 	w := sendOrderRequest(t, h, map[string]any{})
 	if w.Code != http.StatusBadRequest {
 		t.Errorf("empty body: got %d, want 400", w.Code)
@@ -61,7 +65,7 @@ func TestSendOrderHandler_EmptyBody(t *testing.T) {
 
 // TestSendOrderHandler_MissingDocumentType returns 400.
 func TestSendOrderHandler_MissingDocumentType(t *testing.T) {
-	h := NewSendOrderHandler(newTestQueue())
+	h := NewSendOrderHandler(newTestQueue(), nil)
 	body := validOrderBody()
 	delete(body, "documentType")
 	w := sendOrderRequest(t, h, body)
@@ -72,7 +76,7 @@ func TestSendOrderHandler_MissingDocumentType(t *testing.T) {
 
 // TestSendOrderHandler_InvalidDocumentType returns 400.
 func TestSendOrderHandler_InvalidDocumentType(t *testing.T) {
-	h := NewSendOrderHandler(newTestQueue())
+	h := NewSendOrderHandler(newTestQueue(), nil)
 	body := validOrderBody()
 	body["documentType"] = "INVOICE"
 	w := sendOrderRequest(t, h, body)
@@ -83,7 +87,7 @@ func TestSendOrderHandler_InvalidDocumentType(t *testing.T) {
 
 // TestSendOrderHandler_MissingDiscount returns 400 (discount can be 0, but not absent).
 func TestSendOrderHandler_MissingDiscount(t *testing.T) {
-	h := NewSendOrderHandler(newTestQueue())
+	h := NewSendOrderHandler(newTestQueue(), nil)
 	body := validOrderBody()
 	delete(body, "discount")
 	w := sendOrderRequest(t, h, body)
@@ -94,7 +98,7 @@ func TestSendOrderHandler_MissingDiscount(t *testing.T) {
 
 // TestSendOrderHandler_ZeroQuantity returns 400 per Hasavshevet spec (line23 ≠ 0).
 func TestSendOrderHandler_ZeroQuantity(t *testing.T) {
-	h := NewSendOrderHandler(newTestQueue())
+	h := NewSendOrderHandler(newTestQueue(), nil)
 	body := validOrderBody()
 	details := body["details"].([]any)
 	details[0].(map[string]any)["quantity"] = 0.0
@@ -106,7 +110,7 @@ func TestSendOrderHandler_ZeroQuantity(t *testing.T) {
 
 // TestSendOrderHandler_MissingSKU returns 400 per Hasavshevet spec (line22 required).
 func TestSendOrderHandler_MissingSKU(t *testing.T) {
-	h := NewSendOrderHandler(newTestQueue())
+	h := NewSendOrderHandler(newTestQueue(), nil)
 	body := validOrderBody()
 	details := body["details"].([]any)
 	delete(details[0].(map[string]any), "sku")
@@ -119,11 +123,9 @@ func TestSendOrderHandler_MissingSKU(t *testing.T) {
 // TestSendOrderHandler_ValidRequest returns 202 Accepted with a jobId.
 func TestSendOrderHandler_ValidRequest(t *testing.T) {
 	q := newTestQueueWithNumberStore(t)
-	// Start the queue so Submit doesn't block (but with no sender, jobs are never processed)
-	// We need to start the queue to prevent "queue full" on the channel send.
-	// Actually, since defaultQueueSize=64 and we only submit once, Submit won't block
-	// even without Start. The channel has capacity 64.
-	h := NewSendOrderHandler(q)
+	// The queue is not started: Submit only queues the job, which is enough
+	// here (defaultQueueSize is 64).
+	h := NewSendOrderHandler(q, nil)
 	w := sendOrderRequest(t, h, validOrderBody())
 	if w.Code != http.StatusAccepted {
 		t.Errorf("valid request: got %d, want 202; body: %s", w.Code, w.Body.String())
@@ -141,11 +143,38 @@ func TestSendOrderHandler_ValidRequest(t *testing.T) {
 	}
 }
 
+// TestSendOrderHandler_Priority verifies the priority is validated and the
+// 202 response reports the job's place in the queue.
+func TestSendOrderHandler_Priority(t *testing.T) {
+	h := NewSendOrderHandler(newTestQueueWithNumberStore(t), nil)
+	sendOrderRequest(t, h, validOrderBody())
+
+	body := validOrderBody()
+	body["priority"] = "urgent"
+	w := sendOrderRequest(t, h, body)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("urgent order: got %d, want 202; body: %s", w.Code, w.Body.String())
+	}
+	var resp dto.SendOrderAccepted
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+	if resp.QueuePosition != 1 || resp.EstimatedWaitSeconds != nil {
+		t.Errorf("urgent order: queuePosition = %d, estimatedWaitSeconds = %v; want 1 ahead of the normal order, no estimate yet",
+			resp.QueuePosition, resp.EstimatedWaitSeconds)
+	}
+
+	body["priority"] = "asap"
+	if w := sendOrderRequest(t, h, body); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown priority: got %d, want 400", w.Code)
+	}
+}
+
 // TestSendOrderHandler_AllDocumentTypes verifies ORDER, QUOATE, RETURN all return 202.
 func TestSendOrderHandler_AllDocumentTypes(t *testing.T) {
 	for _, dt := range []string{"ORDER", "QUOATE", "RETURN"} {
 		t.Run(dt, func(t *testing.T) {
-			h := NewSendOrderHandler(newTestQueue())
+			h := NewSendOrderHandler(newTestQueue(), nil)
 			body := validOrderBody()
 			body["documentType"] = dt
 			w := sendOrderRequest(t, h, body)
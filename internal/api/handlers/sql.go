@@ -16,6 +16,7 @@ import (
 
 	"erp-connector/internal/api/dto"
 	"erp-connector/internal/api/utils"
+	"erp-connector/internal/db"
 )
 
 const (
@@ -39,8 +40,9 @@ type sqlValidationError struct {
 
 func (e sqlValidationError) Error() string { return e.msg }
 
-func NewSQLHandler(dbConn *sql.DB) http.HandlerFunc {
+func NewSQLHandler(dbHandle *db.Handle) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		dbConn := dbHandle.DB()
 		if dbConn == nil {
 			utils.WriteError(w, http.StatusServiceUnavailable, "Database connection unavailable", "DB_UNAVAILABLE", nil)
 			return
@@ -1,19 +1,74 @@
 package middleware
 
 import (
+	"context"
+	"crypto/subtle"
 	"net/http"
 	"strings"
 
 	"erp-connector/internal/api/utils"
 )
 
+// TokenGrant is a bearer token and the companies it may access. An empty
+// Companies list grants every company.
+type TokenGrant struct {
+	Token     string
+	Companies []string
+}
+
+// Allows reports whether the grant covers company.
+func (g TokenGrant) Allows(company string) bool {
+	if len(g.Companies) == 0 {
+		return true
+	}
+	for _, c := range g.Companies {
+		if strings.EqualFold(c, company) {
+			return true
+		}
+	}
+	return false
+}
+
+type grantKey struct{}
+
+// GrantFromContext returns the grant of the authenticated request.
+func GrantFromContext(ctx context.Context) (TokenGrant, bool) {
+	g, ok := ctx.Value(grantKey{}).(TokenGrant)
+	return g, ok
+}
+
 func Auth(token string, next http.Handler) http.Handler {
+	return AuthTokens([]TokenGrant{{Token: token}}, next)
+}
+
+// AuthTokens accepts any of grants and stores the matching grant in the
+// request context for company authorization further down the chain.
+func AuthTokens(grants []TokenGrant, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		parts := strings.Fields(r.Header.Get("Authorization"))
-		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") || parts[1] != token {
+		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
 			utils.WriteError(w, http.StatusUnauthorized, "Unauthorized", "UNAUTHORIZED", nil)
 			return
 		}
+		for _, g := range grants {
+			if g.Token != "" && subtle.ConstantTimeCompare([]byte(parts[1]), []byte(g.Token)) == 1 {
+				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), grantKey{}, g)))
+				return
+			}
+		}
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized", "UNAUTHORIZED", nil)
+	})
+}
+
+// RequireUnrestricted rejects requests whose token is limited to a list of
+// companies. It guards routes that reach past the selected company, such as
+// raw SQL against a server that hosts every company's database.
+func RequireUnrestricted(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if g, ok := GrantFromContext(r.Context()); !ok || len(g.Companies) > 0 {
+			utils.WriteError(w, http.StatusForbidden, "Token is restricted to specific companies", "TOKEN_RESTRICTED", nil)
+			return
+		}
 		next.ServeHTTP(w, r)
 	})
 }
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+
+	"erp-connector/internal/api/utils"
+	"erp-connector/internal/db"
+)
+
+// RequireDB rejects requests with 503 DB_UNAVAILABLE while the daemon runs in
+// degraded mode. It guards routes whose handlers do not read the database
+// directly but hand work to a DB-backed worker (e.g. the send-order queue).
+func RequireDB(dbHandle *db.Handle, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !dbHandle.Available() {
+			utils.WriteError(w, http.StatusServiceUnavailable, "Database connection unavailable", "DB_UNAVAILABLE", nil)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -1,7 +1,6 @@
 package api
 
 import (
-	"database/sql"
 	"errors"
 	"net"
 	"net/http"
@@ -13,15 +12,23 @@ import (
 	"erp-connector/internal/api/middleware"
 	"erp-connector/internal/api/utils"
 	"erp-connector/internal/config"
-	"erp-connector/internal/erp/hasavshevet"
+	"erp-connector/internal/db"
+	"erp-connector/internal/erp"
 	"erp-connector/internal/logger"
 )
 
+// CompanyDeps are the runtime dependencies of one company profile. Name is
+// empty when the connector serves a single unnamed company.
+type CompanyDeps struct {
+	Name    string
+	Config  config.Config // effective config, see config.ForCompany
+	DB      *db.Handle    // nil for ERPs reached over HTTP only (Priority)
+	Adapter erp.Adapter
+}
+
 type ServerDeps struct {
-	DBPassword     string
-	DB             *sql.DB
-	Logger         logger.LoggerService
-	SendOrderQueue *hasavshevet.OrderQueue
+	Logger    logger.LoggerService
+	Companies []CompanyDeps
 }
 
 func NewServer(cfg config.Config, deps ServerDeps) (*http.Server, error) {
@@ -34,36 +41,31 @@ func NewServer(cfg config.Config, deps ServerDeps) (*http.Server, error) {
 	if token == "" {
 		return nil, errors.New("bearerToken is required")
 	}
-
-	mux := http.NewServeMux()
-	withAuth := func(h http.Handler) http.Handler {
-		return middleware.Auth(token, h)
+	if err := cfg.ValidateCompanies(); err != nil {
+		return nil, err
 	}
-	withLog := func(h http.Handler) http.Handler {
-		return middleware.Logging(deps.Logger, cfg.Debug, h)
+	if len(deps.Companies) == 0 {
+		return nil, errors.New("at least one company is required")
 	}
-	wrap := func(h http.Handler) http.Handler {
-		return withLog(withAuth(h))
+
+	grants := []middleware.TokenGrant{{Token: token}}
+	for _, t := range cfg.Tokens {
+		grants = append(grants, middleware.TokenGrant{Token: strings.TrimSpace(t.Token), Companies: t.Companies})
 	}
 
-	healthHandler := handlers.NewHealthHandler(cfg, deps.DBPassword)
-	sqlHandler := handlers.NewSQLHandler(deps.DB)
-	priceStockHandler := handlers.NewPriceAndStockHandler(cfg, deps.DB)
-	folderFilesHandler := handlers.NewListFolderFilesHandler(cfg.ImageFolders)
-	fileHandler := handlers.NewFileHandler(cfg.ImageFolders)
-	sendOrderHandler := handlers.NewSendOrderHandler(deps.SendOrderQueue)
+	router := &companyRouter{defaultName: cfg.DefaultCompanyName()}
+	for _, c := range deps.Companies {
+		router.companies = append(router.companies, routedCompany{
+			deps: c,
+			mux:  newCompanyMux(c),
+		})
+	}
 
-	mux.Handle("GET /api/health", wrap(healthHandler))
-	mux.Handle("POST /api/sql", wrap(sqlHandler))
-	mux.Handle("GET /api/folders/list", wrap(folderFilesHandler))
-	mux.Handle("POST /api/file", wrap(fileHandler))
-	mux.Handle("POST /api/sendOrder", wrap(sendOrderHandler))
-	mux.Handle("POST /api/priceAndStockHandler", wrap(priceStockHandler))
-	mux.Handle("/api/", wrap(http.HandlerFunc(NotFound)))
+	handler := middleware.Logging(deps.Logger, cfg.Debug, middleware.AuthTokens(grants, router))
 
 	return &http.Server{
 		Addr:              addr,
-		Handler:           mux,
+		Handler:           handler,
 		ReadHeaderTimeout: 5 * time.Second,
 		ReadTimeout:       10 * time.Second,
 		WriteTimeout:      30 * time.Second,
@@ -71,6 +73,114 @@ func NewServer(cfg config.Config, deps ServerDeps) (*http.Server, error) {
 	}, nil
 }
 
+// newCompanyMux registers the API routes bound to one company's ERP adapter
+// and database. Routes whose capability the adapter lacks answer
+// ERP_NOT_SUPPORTED instead of reaching another ERP's code path.
+// Authentication and company selection happen in front of it (see
+// companyRouter).
+func newCompanyMux(c CompanyDeps) *http.ServeMux {
+	cfg := c.Config
+	adapter := c.Adapter
+	mux := http.NewServeMux()
+
+	requireDB := func(h http.Handler) http.Handler {
+		bound, ok := adapter.(erp.DBBoundOrders)
+		if c.DB == nil || !ok || !bound.OrdersNeedDB() {
+			return h
+		}
+		return middleware.RequireDB(c.DB, h)
+	}
+	capable := func(cp erp.Capability, h func() http.Handler) http.Handler {
+		if !erp.Supports(adapter, cp) {
+			return handlers.NewNotSupportedHandler(adapter, cp)
+		}
+		return h()
+	}
+
+	dbObjects := erp.DBObjects(adapter.ERP())
+	healthHandler := handlers.NewHealthHandler(adapter, c.DB, dbObjects)
+	capabilitiesHandler := handlers.NewCapabilitiesHandler(adapter)
+	sqlHandler := capable(erp.CapSQL, func() http.Handler {
+		return middleware.RequireUnrestricted(handlers.NewSQLHandler(c.DB))
+	})
+	priceStockHandler := capable(erp.CapPriceStock, func() http.Handler {
+		return handlers.NewPriceAndStockHandler(adapter.(erp.PriceStockProvider))
+	})
+	sendOrderHandler := capable(erp.CapSendOrder, func() http.Handler {
+		return requireDB(handlers.NewSendOrderHandler(adapter.(erp.OrderSubmitter), creditPolicy(cfg, adapter)))
+	})
+	orderJobHandler := capable(erp.CapSendOrder, func() http.Handler {
+		return handlers.NewOrderJobHandler(adapter.(erp.OrderJobs))
+	})
+	cancelOrderHandler := capable(erp.CapSendOrder, func() http.Handler {
+		return handlers.NewCancelOrderHandler(adapter.(erp.OrderEditor))
+	})
+	updateOrderHandler := capable(erp.CapSendOrder, func() http.Handler {
+		return requireDB(handlers.NewUpdateOrderHandler(adapter.(erp.OrderEditor), creditPolicy(cfg, adapter)))
+	})
+	customerHandler := capable(erp.CapCustomerLookup, func() http.Handler {
+		return handlers.NewCustomerHandler(adapter.(erp.CustomerLookup))
+	})
+	customerSearchHandler := capable(erp.CapCustomerLookup, func() http.Handler {
+		return handlers.NewCustomerSearchHandler(adapter.(erp.CustomerLookup))
+	})
+	itemHandler := capable(erp.CapItemLookup, func() http.Handler {
+		return handlers.NewItemHandler(adapter.(erp.ItemLookup))
+	})
+	itemSearchHandler := capable(erp.CapItemLookup, func() http.Handler {
+		return handlers.NewItemSearchHandler(adapter.(erp.ItemLookup))
+	})
+	creditHandler := capable(erp.CapCreditCheck, func() http.Handler {
+		return handlers.NewCustomerCreditHandler(adapter.(erp.CreditProvider))
+	})
+	documentsHandler := capable(erp.CapCustomerDocuments, func() http.Handler {
+		return handlers.NewCustomerDocumentsHandler(adapter.(erp.DocumentLookup))
+	})
+	folderFilesHandler := handlers.NewListFolderFilesHandler(cfg.ImageFolders)
+	fileHandler := handlers.NewFileHandler(cfg.ImageFolders)
+	dbStatsHandler := middleware.RequireUnrestricted(handlers.NewDBStatsHandler(c.DB, db.OptionsFromConfig(cfg.DB)))
+	migrationsHandler := middleware.RequireUnrestricted(handlers.NewMigrationsHandler(c.DB, dbObjects))
+
+	mux.Handle("GET /api/health", healthHandler)
+	mux.Handle("GET /api/capabilities", capabilitiesHandler)
+	mux.Handle("POST /api/sql", sqlHandler)
+	mux.Handle("GET /api/folders/list", folderFilesHandler)
+	mux.Handle("POST /api/file", fileHandler)
+	mux.Handle("POST /api/sendOrder", sendOrderHandler)
+	mux.Handle("GET /api/sendOrder/{jobId}", orderJobHandler)
+	mux.Handle("PUT /api/sendOrder/{jobId}", updateOrderHandler)
+	mux.Handle("DELETE /api/sendOrder/{jobId}", cancelOrderHandler)
+	mux.Handle("POST /api/priceAndStockHandler", priceStockHandler)
+	mux.Handle("GET /api/customers", customerSearchHandler)
+	mux.Handle("GET /api/customers/{extId}", customerHandler)
+	mux.Handle("GET /api/customers/{extId}/credit", creditHandler)
+	mux.Handle("GET /api/customers/{extId}/documents", documentsHandler)
+	mux.Handle("GET /api/items", itemSearchHandler)
+	mux.Handle("GET /api/items/{sku}", itemHandler)
+	mux.Handle("GET /api/admin/dbStats", dbStatsHandler)
+	mux.Handle("GET /api/admin/migrations", migrationsHandler)
+	mux.Handle("POST /api/admin/migrations", migrationsHandler)
+	mux.Handle("/api/", http.HandlerFunc(NotFound))
+	return mux
+}
+
+// creditPolicy returns the sendOrder credit check for cfg.creditCheck, or nil
+// when it is off or the ERP cannot report customer credit.
+func creditPolicy(cfg config.Config, adapter erp.Adapter) *handlers.CreditPolicy {
+	if !cfg.CreditCheck.Enabled() || !erp.Supports(adapter, erp.CapCreditCheck) {
+		return nil
+	}
+	flag := strings.TrimSpace(cfg.CreditCheck.FlagText)
+	if flag == "" {
+		flag = config.DefaultCreditFlagText
+	}
+	return &handlers.CreditPolicy{
+		Provider: adapter.(erp.CreditProvider),
+		Mode:     cfg.CreditCheck.Mode,
+		FlagText: flag,
+	}
+}
+
 func validateListenAddr(addr string) error {
 	if addr == "" {
 		return errors.New("apiListen is required")
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"erp-connector/internal/api/middleware"
+	"erp-connector/internal/config"
+	"erp-connector/internal/erp"
+)
+
+type sqlOnlyAdapter struct{}
+
+func (sqlOnlyAdapter) ERP() config.ERPType              { return config.ERPHasavshevet }
+func (sqlOnlyAdapter) Capabilities() []erp.Capability   { return []erp.Capability{erp.CapSQL} }
+func (sqlOnlyAdapter) Health(ctx context.Context) error { return nil }
+func (sqlOnlyAdapter) Start(ctx context.Context)        {}
+
+// newTestServerMux serves one company's real routes behind the "master" and
+// "acme-only" tokens. The company has no database, so routes that pass
+// authorization answer 503 DB_UNAVAILABLE.
+func newTestServerMux() http.Handler {
+	mux := newCompanyMux(CompanyDeps{
+		Name:    "acme",
+		Config:  config.Config{ERP: config.ERPHasavshevet},
+		Adapter: sqlOnlyAdapter{},
+	})
+	return middleware.AuthTokens([]middleware.TokenGrant{
+		{Token: "master"},
+		{Token: "acme-only", Companies: []string{"acme"}},
+	}, mux)
+}
+
+// TestServer_SQLNeedsUnrestrictedToken verifies a company token cannot run
+// raw SQL, which could name another company's database.
+func TestServer_SQLNeedsUnrestrictedToken(t *testing.T) {
+	h := newTestServerMux()
+	for token, want := range map[string]int{"master": http.StatusServiceUnavailable, "acme-only": http.StatusForbidden} {
+		req := httptest.NewRequest(http.MethodPost, "/api/sql", strings.NewReader(`{"query":"SELECT * FROM [B_DB].dbo.Accounts"}`))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("%s: code = %d, want %d; body: %s", token, w.Code, want, w.Body.String())
+		}
+		if want == http.StatusForbidden && !strings.Contains(w.Body.String(), "TOKEN_RESTRICTED") {
+			t.Errorf("%s: body = %s, want TOKEN_RESTRICTED", token, w.Body.String())
+		}
+	}
+}
+
+// TestServer_AdminNeedsUnrestrictedToken verifies the pool statistics and
+// migration routes refuse company tokens.
+func TestServer_AdminNeedsUnrestrictedToken(t *testing.T) {
+	h := newTestServerMux()
+	routes := []struct{ method, path string }{
+		{http.MethodGet, "/api/admin/dbStats"},
+		{http.MethodGet, "/api/admin/migrations"},
+		{http.MethodPost, "/api/admin/migrations?dryRun=true"},
+	}
+	for _, rt := range routes {
+		for token, want := range map[string]int{"master": http.StatusServiceUnavailable, "acme-only": http.StatusForbidden} {
+			req := httptest.NewRequest(rt.method, rt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != want {
+				t.Errorf("%s %s as %s: code = %d, want %d; body: %s", rt.method, rt.path, token, w.Code, want, w.Body.String())
+			}
+		}
+	}
+}
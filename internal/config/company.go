@@ -0,0 +1,261 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ErrUnknownCompany is returned by ForCompany for a name not in Companies.
+var ErrUnknownCompany = errors.New("unknown company")
+
+var companyNameRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// CompanyNames returns the configured company names in config order, or nil
+// when the connector runs a single unnamed company.
+func (c Config) CompanyNames() []string {
+	if len(c.Companies) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(c.Companies))
+	for _, co := range c.Companies {
+		out = append(out, co.Name)
+	}
+	return out
+}
+
+// DefaultCompanyName returns the company used when a request names none, or
+// "" when there is no default (single-company mode, or several companies and
+// no defaultCompany).
+func (c Config) DefaultCompanyName() string {
+	if c.DefaultCompany != "" {
+		return c.DefaultCompany
+	}
+	if len(c.Companies) == 1 {
+		return c.Companies[0].Name
+	}
+	return ""
+}
+
+// ForCompany returns the effective configuration for one company: the
+// top-level config with the company's overrides applied and Companies,
+// DefaultCompany and Tokens cleared. An empty name in single-company mode
+// returns c unchanged.
+func (c Config) ForCompany(name string) (Config, error) {
+	if len(c.Companies) == 0 {
+		if name != "" {
+			return Config{}, fmt.Errorf("%w: %s", ErrUnknownCompany, name)
+		}
+		return c, nil
+	}
+
+	var co *CompanyConfig
+	for i := range c.Companies {
+		if strings.EqualFold(c.Companies[i].Name, name) {
+			co = &c.Companies[i]
+			break
+		}
+	}
+	if co == nil {
+		return Config{}, fmt.Errorf("%w: %s", ErrUnknownCompany, name)
+	}
+
+	out := c
+	out.Companies = nil
+	out.DefaultCompany = ""
+	out.Tokens = nil
+	if co.ERP != "" {
+		out.ERP = co.ERP
+	}
+	out.DB = mergeDBConfig(c.DB, co.DB)
+	if co.SendOrderDir != "" {
+		out.SendOrderDir = co.SendOrderDir
+	}
+	if co.HasExePath != "" {
+		out.HasExePath = co.HasExePath
+	}
+	if co.HasParamFile != "" {
+		out.HasParamFile = co.HasParamFile
+	}
+	if co.HasBatFile != "" {
+		out.HasBatFile = co.HasBatFile
+	}
+	if co.PDF != nil {
+		out.PDF = *co.PDF
+	}
+	if co.Priority != nil {
+		out.Priority = *co.Priority
+	}
+	if co.SAP != nil {
+		out.SAP = *co.SAP
+	}
+	if co.Hasavshevet != nil {
+		out.Hasavshevet = *co.Hasavshevet
+	}
+	if co.CreditCheck != nil {
+		out.CreditCheck = *co.CreditCheck
+	}
+	return out, nil
+}
+
+// ValidateCompanies checks company profiles, token restrictions and each
+// company's creditCheck mode and Hasavshevet document mapping. Every Hasavshevet company needs its own
+// sendOrderDir: the IMOVEIN files and the order number store live there and
+// must not be shared between companies.
+func (c Config) ValidateCompanies() error {
+	if err := validateDBPool("db", c.DB); err != nil {
+		return err
+	}
+	if err := validateCreditCheck("creditCheck", c.CreditCheck); err != nil {
+		return err
+	}
+	if err := validateHasavshevet("hasavshevet", c.Hasavshevet); err != nil {
+		return err
+	}
+	seen := make(map[string]bool, len(c.Companies))
+	dirs := make(map[string]string, len(c.Companies))
+	for i, co := range c.Companies {
+		name := strings.TrimSpace(co.Name)
+		if name == "" {
+			return fmt.Errorf("companies[%d].name is required", i)
+		}
+		if !companyNameRe.MatchString(name) {
+			return fmt.Errorf("companies[%d].name %q may only contain letters, digits, '-' and '_'", i, name)
+		}
+		key := strings.ToLower(name)
+		if seen[key] {
+			return fmt.Errorf("duplicate company name %q", name)
+		}
+		seen[key] = true
+
+		eff, err := c.ForCompany(co.Name)
+		if err != nil {
+			return err
+		}
+		if err := validateDBPool(fmt.Sprintf("companies[%d].db", i), eff.DB); err != nil {
+			return err
+		}
+		if err := validateCreditCheck(fmt.Sprintf("companies[%d].creditCheck", i), eff.CreditCheck); err != nil {
+			return err
+		}
+		if err := validateHasavshevet(fmt.Sprintf("companies[%d].hasavshevet", i), eff.Hasavshevet); err != nil {
+			return err
+		}
+		if eff.ERP == ERPHasavshevet && eff.SendOrderDir != "" {
+			dir := strings.ToLower(path.Clean(strings.ReplaceAll(eff.SendOrderDir, `\`, "/")))
+			if other, ok := dirs[dir]; ok {
+				return fmt.Errorf("companies %q and %q share sendOrderDir %q", other, name, eff.SendOrderDir)
+			}
+			dirs[dir] = name
+		}
+	}
+
+	if c.DefaultCompany != "" && !seen[strings.ToLower(c.DefaultCompany)] {
+		return fmt.Errorf("defaultCompany %q is not a configured company", c.DefaultCompany)
+	}
+
+	for i, t := range c.Tokens {
+		if strings.TrimSpace(t.Token) == "" {
+			return fmt.Errorf("tokens[%d].token is required", i)
+		}
+		if t.Token == c.BearerToken {
+			return fmt.Errorf("tokens[%d] duplicates bearerToken", i)
+		}
+		if len(t.Companies) == 0 {
+			return fmt.Errorf("tokens[%d].companies is required", i)
+		}
+		for _, name := range t.Companies {
+			if !seen[strings.ToLower(name)] {
+				return fmt.Errorf("tokens[%d] references unknown company %q", i, name)
+			}
+		}
+	}
+	return nil
+}
+
+// validateDBPool checks the connection pool settings: 0 keeps the default,
+// -1 means no limit (except for maxIdleConns) and other negative values are
+// rejected.
+func validateDBPool(field string, db DBConfig) error {
+	limits := []struct {
+		key string
+		v   int
+	}{
+		{"maxOpenConns", db.MaxOpenConns},
+		{"connMaxIdleTimeSeconds", db.ConnMaxIdleTimeSeconds},
+		{"connMaxLifetimeMinutes", db.ConnMaxLifetimeMinutes},
+	}
+	for _, l := range limits {
+		if l.v < NoLimit {
+			return fmt.Errorf("%s.%s %d must be 0 (default), -1 (no limit) or positive", field, l.key, l.v)
+		}
+	}
+	if db.MaxIdleConns < 0 {
+		return fmt.Errorf("%s.maxIdleConns %d must not be negative", field, db.MaxIdleConns)
+	}
+	return nil
+}
+
+func validateCreditCheck(field string, cc CreditCheckConfig) error {
+	if !slices.Contains(CreditCheckModes(), cc.Mode) {
+		return fmt.Errorf("%s.mode %q must be one of off, reject, warn, flag", field, cc.Mode)
+	}
+	return nil
+}
+
+// mergeDBConfig overlays the non-zero fields of override onto base.
+func mergeDBConfig(base, override DBConfig) DBConfig {
+	out := base
+	if override.Driver != "" {
+		out.Driver = override.Driver
+	}
+	if override.Host != "" {
+		out.Host = override.Host
+	}
+	if override.Port != 0 {
+		out.Port = override.Port
+	}
+	if override.User != "" {
+		out.User = override.User
+	}
+	if override.Database != "" {
+		out.Database = override.Database
+	}
+	if override.Instance != "" {
+		out.Instance = override.Instance
+	}
+	if override.Encrypt != "" {
+		out.Encrypt = override.Encrypt
+	}
+	if override.TrustServerCertificate {
+		out.TrustServerCertificate = true
+	}
+	if override.ApplicationIntent != "" {
+		out.ApplicationIntent = override.ApplicationIntent
+	}
+	if override.ConnectTimeoutSeconds != 0 {
+		out.ConnectTimeoutSeconds = override.ConnectTimeoutSeconds
+	}
+	if override.AppName != "" {
+		out.AppName = override.AppName
+	}
+	if override.WindowsAuth {
+		out.WindowsAuth = true
+	}
+	if override.MaxOpenConns != 0 {
+		out.MaxOpenConns = override.MaxOpenConns
+	}
+	if override.MaxIdleConns != 0 {
+		out.MaxIdleConns = override.MaxIdleConns
+	}
+	if override.ConnMaxIdleTimeSeconds != 0 {
+		out.ConnMaxIdleTimeSeconds = override.ConnMaxIdleTimeSeconds
+	}
+	if override.ConnMaxLifetimeMinutes != 0 {
+		out.ConnMaxLifetimeMinutes = override.ConnMaxLifetimeMinutes
+	}
+	return out
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func multiCompanyConfig() Config {
+	cfg := Default()
+	cfg.BearerToken = "master"
+	cfg.SendOrderDir = `C:\orders`
+	cfg.DB.Host = "sql01"
+	cfg.DB.User = "sa"
+	cfg.DB.Database = "MAIN"
+	cfg.Companies = []CompanyConfig{
+		{Name: "acme", DB: DBConfig{Database: "ACME"}, SendOrderDir: `C:\orders\acme`},
+		{Name: "globex", ERP: ERPSAP, DB: DBConfig{Host: "sql02", Port: 1500}, PDF: &PDFConfig{PrintAfterOrder: true}},
+	}
+	return cfg
+}
+
+// TestForCompany_Overrides verifies company fields override the top-level
+// config and empty fields inherit it.
+func TestForCompany_Overrides(t *testing.T) {
+	cfg := multiCompanyConfig()
+
+	acme, err := cfg.ForCompany("ACME")
+	if err != nil {
+		t.Fatalf("ForCompany(acme): %v", err)
+	}
+	if acme.DB.Database != "ACME" || acme.DB.Host != "sql01" || acme.DB.User != "sa" {
+		t.Errorf("acme db = %+v, want database override on inherited host/user", acme.DB)
+	}
+	if acme.ERP != ERPHasavshevet || acme.SendOrderDir != `C:\orders\acme` {
+		t.Errorf("acme erp/sendOrderDir = %s/%s", acme.ERP, acme.SendOrderDir)
+	}
+	if acme.Companies != nil || acme.Tokens != nil {
+		t.Error("effective config must not carry company list or tokens")
+	}
+
+	globex, err := cfg.ForCompany("globex")
+	if err != nil {
+		t.Fatalf("ForCompany(globex): %v", err)
+	}
+	if globex.ERP != ERPSAP || globex.DB.Host != "sql02" || globex.DB.Port != 1500 || globex.DB.Database != "MAIN" {
+		t.Errorf("globex = erp %s db %+v", globex.ERP, globex.DB)
+	}
+	if !globex.PDF.PrintAfterOrder || acme.PDF.PrintAfterOrder {
+		t.Error("pdf block should be replaced only for globex")
+	}
+
+	if _, err := cfg.ForCompany("initech"); !errors.Is(err, ErrUnknownCompany) {
+		t.Errorf("unknown company err = %v, want ErrUnknownCompany", err)
+	}
+}
+
+// TestForCompany_SingleCompany verifies legacy configs resolve the unnamed
+// company to the top-level config.
+func TestForCompany_SingleCompany(t *testing.T) {
+	cfg := Default()
+	cfg.DB.Database = "ERPDB"
+	got, err := cfg.ForCompany("")
+	if err != nil || got.DB.Database != "ERPDB" {
+		t.Fatalf("ForCompany(\"\") = %+v, %v", got.DB, err)
+	}
+	if _, err := cfg.ForCompany("acme"); !errors.Is(err, ErrUnknownCompany) {
+		t.Errorf("named company without profiles: err = %v", err)
+	}
+	if cfg.CompanyNames() != nil || cfg.DefaultCompanyName() != "" {
+		t.Error("single-company config must report no names and no default")
+	}
+}
+
+func TestValidateCompanies(t *testing.T) {
+	if err := multiCompanyConfig().ValidateCompanies(); err != nil {
+		t.Fatalf("valid config rejected: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(*Config)
+	}{
+		{"empty name", func(c *Config) { c.Companies[0].Name = "" }},
+		{"bad name", func(c *Config) { c.Companies[0].Name = "a/b" }},
+		{"duplicate name", func(c *Config) { c.Companies[1].Name = "Acme" }},
+		{"shared sendOrderDir", func(c *Config) {
+			c.Companies[1].ERP = ""
+			c.Companies[1].SendOrderDir = `C:\orders\acme\`
+		}},
+		{"unknown default", func(c *Config) { c.DefaultCompany = "initech" }},
+		{"token without companies", func(c *Config) { c.Tokens = []TokenConfig{{Token: "t1"}} }},
+		{"token unknown company", func(c *Config) {
+			c.Tokens = []TokenConfig{{Token: "t1", Companies: []string{"initech"}}}
+		}},
+		{"token equals bearerToken", func(c *Config) {
+			c.Tokens = []TokenConfig{{Token: "master", Companies: []string{"acme"}}}
+		}},
+		{"bad creditCheck mode", func(c *Config) { c.CreditCheck.Mode = "block" }},
+		{"bad company creditCheck mode", func(c *Config) {
+			c.Companies[1].CreditCheck = &CreditCheckConfig{Mode: "Reject"}
+		}},
+		{"unknown sendOrder document type", func(c *Config) {
+			c.Hasavshevet.SendOrder = map[string]HasavshevetDocumentMapping{"INVOICE": {DocumentID: 1}}
+		}},
+		{"document ID too wide", func(c *Config) {
+			c.Hasavshevet.SendOrder = map[string]HasavshevetDocumentMapping{"ORDER": {DocumentID: 130}}
+		}},
+		{"unknown remarks placeholder", func(c *Config) {
+			c.Hasavshevet.SendOrder = map[string]HasavshevetDocumentMapping{"ORDER": {RemarksTemplate: "{agent} {comment}"}}
+		}},
+		{"bad company unit", func(c *Config) {
+			c.Companies[1].Hasavshevet = &HasavshevetConfig{SendOrder: map[string]HasavshevetDocumentMapping{"RETURN": {Unit: "carton"}}}
+		}},
+		{"erp VAT source without query", func(c *Config) { c.Hasavshevet.VAT.Source = VATSourceERP }},
+		{"VAT rates out of order", func(c *Config) {
+			c.Hasavshevet.VAT.Rates = []VATRate{{From: "2025-01-01", Rate: 18}, {From: "2015-10-01", Rate: 17}}
+		}},
+		{"bad VAT exempt column", func(c *Config) { c.Hasavshevet.VAT.ExemptColumn = "Vat; DROP" }},
+		{"negative batch window", func(c *Config) { c.Hasavshevet.BatchWindowSeconds = -1 }},
+		{"negative pool size", func(c *Config) { c.DB.MaxOpenConns = -2 }},
+		{"negative idle pool", func(c *Config) { c.DB.MaxIdleConns = -1 }},
+		{"negative company lifetime", func(c *Config) { c.Companies[0].DB.ConnMaxLifetimeMinutes = -5 }},
+		{"negative batch size", func(c *Config) { c.Hasavshevet.BatchMaxOrders = -5 }},
+		{"negative priority skips", func(c *Config) { c.Hasavshevet.PriorityMaxSkips = -1 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := multiCompanyConfig()
+			tt.mutate(&cfg)
+			if err := cfg.ValidateCompanies(); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
@@ -0,0 +1,372 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Hasavshevet sendOrder document types (the API's documentType values).
+const (
+	HasavshevetOrder  = "ORDER"
+	HasavshevetQuote  = "QUOATE"
+	HasavshevetReturn = "RETURN"
+)
+
+// HasavshevetDocumentTypes lists the documentType values sendOrder imports.
+func HasavshevetDocumentTypes() []string {
+	return []string{HasavshevetOrder, HasavshevetQuote, HasavshevetReturn}
+}
+
+// DefaultHasavshevetLocalCurrency is the currency code of shekel orders.
+const DefaultHasavshevetLocalCurrency = `ש"ח`
+
+// DefaultHasavshevetUnit is the unit of measure written on order lines.
+const DefaultHasavshevetUnit = "יח'"
+
+// DefaultHasavshevetRemarksTemplate copies the order comment into the header
+// remarks.
+const DefaultHasavshevetRemarksTemplate = "{comment}"
+
+// HasavshevetRemarksPlaceholders lists the placeholders a remarksTemplate may
+// use; each is replaced with the matching order field.
+func HasavshevetRemarksPlaceholders() []string {
+	return []string{"{comment}", "{historyId}", "{orderNumber}", "{userExtId}", "{createdDate}", "{dueDate}"}
+}
+
+// HasavshevetDocumentMapping is the IMOVEIN header a sendOrder document type
+// is imported with. Zero fields keep the default for the type (see
+// DefaultHasavshevetDocumentMappings).
+type HasavshevetDocumentMapping struct {
+	// DocumentID is the header DocumentID (IMOVEIN line4) for orders in the
+	// local currency.
+	DocumentID int `yaml:"documentId,omitempty"`
+	// ForeignCurrencyDocumentID is used for any other currency; 0 uses
+	// DocumentID.
+	ForeignCurrencyDocumentID int `yaml:"foreignCurrencyDocumentId,omitempty"`
+	// CurrencyDocumentIDs overrides the DocumentID per currency code, e.g.
+	// {"$": 32, "EUR": 33}.
+	CurrencyDocumentIDs map[string]int `yaml:"currencyDocumentIds,omitempty"`
+	// Warehouse is the header warehouse (line12).
+	Warehouse int `yaml:"warehouse,omitempty"`
+	// Copies is the number of printed copies (line19).
+	Copies int `yaml:"copies,omitempty"`
+	// Unit is the unit of measure written on every line (line29).
+	Unit string `yaml:"unit,omitempty"`
+	// Agent replaces the customer's agent (line11); empty keeps the agent
+	// from the account.
+	Agent string `yaml:"agent,omitempty"`
+	// RemarksTemplate builds the header remarks (line37) from
+	// HasavshevetRemarksPlaceholders, e.g. "{historyId} {comment}".
+	RemarksTemplate string `yaml:"remarksTemplate,omitempty"`
+}
+
+// DefaultHasavshevetDocumentMappings are the codes sendOrder has always used:
+// orders 30 (shekel) / 32 (foreign currency), quotes 40, returns 74, all in
+// warehouse 1 with one copy.
+func DefaultHasavshevetDocumentMappings() map[string]HasavshevetDocumentMapping {
+	base := HasavshevetDocumentMapping{
+		Warehouse:       1,
+		Copies:          1,
+		Unit:            DefaultHasavshevetUnit,
+		RemarksTemplate: DefaultHasavshevetRemarksTemplate,
+	}
+	order, quote, ret := base, base, base
+	order.DocumentID, order.ForeignCurrencyDocumentID = 30, 32
+	quote.DocumentID = 40
+	ret.DocumentID = 74
+	return map[string]HasavshevetDocumentMapping{
+		HasavshevetOrder:  order,
+		HasavshevetQuote:  quote,
+		HasavshevetReturn: ret,
+	}
+}
+
+// LocalCurrencyCode returns the configured local currency code or
+// DefaultHasavshevetLocalCurrency.
+func (h HasavshevetConfig) LocalCurrencyCode() string {
+	if c := strings.TrimSpace(h.LocalCurrency); c != "" {
+		return c
+	}
+	return DefaultHasavshevetLocalCurrency
+}
+
+// DocumentMapping returns the mapping for a sendOrder documentType: the
+// configured fields over the defaults. It reports false for unknown types.
+func (h HasavshevetConfig) DocumentMapping(documentType string) (HasavshevetDocumentMapping, bool) {
+	m, ok := DefaultHasavshevetDocumentMappings()[documentType]
+	if !ok {
+		return HasavshevetDocumentMapping{}, false
+	}
+	o := h.SendOrder[documentType]
+	if o.DocumentID != 0 {
+		m.DocumentID = o.DocumentID
+	}
+	if o.ForeignCurrencyDocumentID != 0 {
+		m.ForeignCurrencyDocumentID = o.ForeignCurrencyDocumentID
+	}
+	if len(o.CurrencyDocumentIDs) > 0 {
+		m.CurrencyDocumentIDs = maps.Clone(o.CurrencyDocumentIDs)
+	}
+	if o.Warehouse != 0 {
+		m.Warehouse = o.Warehouse
+	}
+	if o.Copies != 0 {
+		m.Copies = o.Copies
+	}
+	if o.Unit != "" {
+		m.Unit = o.Unit
+	}
+	if o.Agent != "" {
+		m.Agent = o.Agent
+	}
+	if o.RemarksTemplate != "" {
+		m.RemarksTemplate = o.RemarksTemplate
+	}
+	return m, true
+}
+
+// DefaultOrderDocumentIDs are the Stock.DocumentID codes reported as orders
+// when documents.orders is not set: customer orders (11) and every ORDER code
+// sendOrder imports with.
+func (h HasavshevetConfig) DefaultOrderDocumentIDs() []int {
+	m, _ := h.DocumentMapping(HasavshevetOrder)
+	ids := []int{11, m.DocumentID}
+	if m.ForeignCurrencyDocumentID != 0 {
+		ids = append(ids, m.ForeignCurrencyDocumentID)
+	}
+	for _, id := range m.CurrencyDocumentIDs {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
+
+// DefaultDeliveryNoteDocumentIDs is the Stock.DocumentID of Hasavshevet's
+// standard delivery note (3), reported when documents.deliveryNotes is not
+// set.
+func DefaultDeliveryNoteDocumentIDs() []int { return []int{3} }
+
+// DefaultInvoiceDocumentIDs is the Stock.DocumentID of Hasavshevet's standard
+// tax invoice (1), reported when documents.invoices is not set.
+func DefaultInvoiceDocumentIDs() []int { return []int{1} }
+
+// DocumentIDs returns the document codes in effect: the configured lists,
+// with the defaults for the kinds left empty. A default code configured for
+// another kind is left out, so listing e.g. invoices: [3] does not also
+// report 3 as a delivery note.
+func (h HasavshevetConfig) DocumentIDs() HasavshevetDocumentIDs {
+	ids := h.Documents
+	configured := slices.Concat(ids.Orders, ids.DeliveryNotes, ids.Invoices)
+	orDefault := func(codes, defaults []int) []int {
+		if len(codes) > 0 {
+			return codes
+		}
+		return slices.DeleteFunc(defaults, func(id int) bool { return slices.Contains(configured, id) })
+	}
+	return HasavshevetDocumentIDs{
+		Orders:        orDefault(ids.Orders, h.DefaultOrderDocumentIDs()),
+		DeliveryNotes: orDefault(ids.DeliveryNotes, DefaultDeliveryNoteDocumentIDs()),
+		Invoices:      orDefault(ids.Invoices, DefaultInvoiceDocumentIDs()),
+	}
+}
+
+// DocumentIDFor returns the header DocumentID for an order in currency.
+func (m HasavshevetDocumentMapping) DocumentIDFor(currency, localCurrency string) int {
+	if id, ok := m.CurrencyDocumentIDs[currency]; ok {
+		return id
+	}
+	if currency != localCurrency && m.ForeignCurrencyDocumentID != 0 {
+		return m.ForeignCurrencyDocumentID
+	}
+	return m.DocumentID
+}
+
+// IMOVEIN widths of the mapped header fields; values must fit them.
+const (
+	imoveInDocumentIDDigits = 2 // line4
+	imoveInAgentWidth       = 9 // line11
+	imoveInWarehouseDigits  = 9 // line12
+	imoveInCopiesDigits     = 2 // line19
+	imoveInCurrencyWidth    = 4 // line20
+	imoveInUnitWidth        = 5 // line29
+)
+
+var remarksPlaceholderRe = regexp.MustCompile(`\{[^{}]*\}`)
+
+// validateHasavshevet checks the VAT, batching and queue settings and the
+// sendOrder document mapping: known document types, codes that fit their
+// IMOVEIN fields and known remarks placeholders.
+func validateHasavshevet(field string, h HasavshevetConfig) error {
+	if err := validateVAT(field+".vat", h.VAT); err != nil {
+		return err
+	}
+	if h.BatchWindowSeconds < 0 {
+		return fmt.Errorf("%s.batchWindowSeconds %d must not be negative", field, h.BatchWindowSeconds)
+	}
+	if h.BatchMaxOrders < 0 {
+		return fmt.Errorf("%s.batchMaxOrders %d must not be negative", field, h.BatchMaxOrders)
+	}
+	if h.PriorityMaxSkips < 0 {
+		return fmt.Errorf("%s.priorityMaxSkips %d must not be negative", field, h.PriorityMaxSkips)
+	}
+	if utf8.RuneCountInString(strings.TrimSpace(h.LocalCurrency)) > imoveInCurrencyWidth {
+		return fmt.Errorf("%s.localCurrency %q is longer than %d characters", field, h.LocalCurrency, imoveInCurrencyWidth)
+	}
+	for _, docType := range slices.Sorted(maps.Keys(h.SendOrder)) {
+		m := h.SendOrder[docType]
+		f := fmt.Sprintf("%s.sendOrder.%s", field, docType)
+		if !slices.Contains(HasavshevetDocumentTypes(), docType) {
+			return fmt.Errorf("%s: unknown document type; allowed: %s", f, strings.Join(HasavshevetDocumentTypes(), ", "))
+		}
+		if err := checkDigits(f+".documentId", m.DocumentID, imoveInDocumentIDDigits); err != nil {
+			return err
+		}
+		if err := checkDigits(f+".foreignCurrencyDocumentId", m.ForeignCurrencyDocumentID, imoveInDocumentIDDigits); err != nil {
+			return err
+		}
+		for _, currency := range slices.Sorted(maps.Keys(m.CurrencyDocumentIDs)) {
+			cf := fmt.Sprintf("%s.currencyDocumentIds[%s]", f, currency)
+			if strings.TrimSpace(currency) == "" || utf8.RuneCountInString(currency) > imoveInCurrencyWidth {
+				return fmt.Errorf("%s: currency code must be 1-%d characters", cf, imoveInCurrencyWidth)
+			}
+			if id := m.CurrencyDocumentIDs[currency]; id == 0 {
+				return fmt.Errorf("%s: document ID is required", cf)
+			}
+			if err := checkDigits(cf, m.CurrencyDocumentIDs[currency], imoveInDocumentIDDigits); err != nil {
+				return err
+			}
+		}
+		if err := checkDigits(f+".warehouse", m.Warehouse, imoveInWarehouseDigits); err != nil {
+			return err
+		}
+		if err := checkDigits(f+".copies", m.Copies, imoveInCopiesDigits); err != nil {
+			return err
+		}
+		if utf8.RuneCountInString(m.Unit) > imoveInUnitWidth {
+			return fmt.Errorf("%s.unit %q is longer than %d characters", f, m.Unit, imoveInUnitWidth)
+		}
+		if utf8.RuneCountInString(m.Agent) > imoveInAgentWidth {
+			return fmt.Errorf("%s.agent %q is longer than %d characters", f, m.Agent, imoveInAgentWidth)
+		}
+		for _, p := range remarksPlaceholderRe.FindAllString(m.RemarksTemplate, -1) {
+			if !slices.Contains(HasavshevetRemarksPlaceholders(), p) {
+				return fmt.Errorf("%s.remarksTemplate: unknown placeholder %s; allowed: %s",
+					f, p, strings.Join(HasavshevetRemarksPlaceholders(), " "))
+			}
+		}
+	}
+	return nil
+}
+
+// checkDigits rejects negative values and values wider than digits.
+func checkDigits(field string, v, digits int) error {
+	if v < 0 || len(fmt.Sprint(v)) > digits {
+		return fmt.Errorf("%s %d must be between 0 and %s", field, v, strings.Repeat("9", digits))
+	}
+	return nil
+}
+
+// VAT rate sources for HasavshevetVATConfig.Source.
+const (
+	VATSourceTable = "table"
+	VATSourceERP   = "erp"
+)
+
+// DefaultHasavshevetVATExemptColumn is the Accounts column read for
+// per-customer VAT exemption when vat.exemptColumn is not set.
+const DefaultHasavshevetVATExemptColumn = "VatExampt"
+
+// HasavshevetVATConfig decides the VAT rate of an imported document by its
+// date. With Source "table" (the default) the rate comes from Rates; with
+// "erp" ERPQuery is run against the company database with @date and must
+// return the rate in percent. Customers whose ExemptColumn in Accounts is
+// set are imported with 0% and the exemption flag; "-" disables the check.
+type HasavshevetVATConfig struct {
+	Source       string    `yaml:"source,omitempty"`
+	Rates        []VATRate `yaml:"rates,omitempty"`
+	ERPQuery     string    `yaml:"erpQuery,omitempty"`
+	ExemptColumn string    `yaml:"exemptColumn,omitempty"`
+}
+
+// VATRate is the rate in percent effective from From (YYYY-MM-DD) until the
+// next entry.
+type VATRate struct {
+	From string  `yaml:"from"`
+	Rate float64 `yaml:"rate"`
+}
+
+// DefaultVATRates are the Israeli standard VAT rates since September 2012.
+func DefaultVATRates() []VATRate {
+	return []VATRate{
+		{From: "2012-09-01", Rate: 17},
+		{From: "2013-06-02", Rate: 18},
+		{From: "2015-10-01", Rate: 17},
+		{From: "2025-01-01", Rate: 18},
+	}
+}
+
+// RateOn returns the table rate effective on date, or false when date is
+// before the first entry. An empty Rates uses DefaultVATRates.
+func (v HasavshevetVATConfig) RateOn(date time.Time) (float64, bool) {
+	rates := v.Rates
+	if len(rates) == 0 {
+		rates = DefaultVATRates()
+	}
+	day := date.Format(time.DateOnly)
+	rate, ok := 0.0, false
+	for _, r := range rates {
+		// Entries are validated as ascending YYYY-MM-DD, which sort as text.
+		if r.From <= day {
+			rate, ok = r.Rate, true
+		}
+	}
+	return rate, ok
+}
+
+// ExemptColumnName returns the Accounts exemption column, "" when disabled.
+func (v HasavshevetVATConfig) ExemptColumnName() string {
+	switch c := strings.TrimSpace(v.ExemptColumn); c {
+	case "":
+		return DefaultHasavshevetVATExemptColumn
+	case "-":
+		return ""
+	default:
+		return c
+	}
+}
+
+var sqlIdentifierRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,127}$`)
+
+func validateVAT(field string, v HasavshevetVATConfig) error {
+	switch v.Source {
+	case "", VATSourceTable:
+	case VATSourceERP:
+		if strings.TrimSpace(v.ERPQuery) == "" {
+			return fmt.Errorf("%s.erpQuery is required when source is erp", field)
+		}
+	default:
+		return fmt.Errorf("%s.source %q must be table or erp", field, v.Source)
+	}
+	prev := ""
+	for i, r := range v.Rates {
+		if _, err := time.Parse(time.DateOnly, r.From); err != nil {
+			return fmt.Errorf("%s.rates[%d].from %q must be YYYY-MM-DD", field, i, r.From)
+		}
+		if r.From <= prev {
+			return fmt.Errorf("%s.rates[%d].from %s must be after %s", field, i, r.From, prev)
+		}
+		if r.Rate < 0 || r.Rate >= 100 {
+			return fmt.Errorf("%s.rates[%d].rate %g must be between 0 and 100", field, i, r.Rate)
+		}
+		prev = r.From
+	}
+	if c := v.ExemptColumnName(); c != "" && !sqlIdentifierRe.MatchString(c) {
+		return fmt.Errorf("%s.exemptColumn %q is not a column name", field, v.ExemptColumn)
+	}
+	return nil
+}
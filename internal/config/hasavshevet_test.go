@@ -0,0 +1,101 @@
+package config
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDocumentMapping_Defaults(t *testing.T) {
+	var h HasavshevetConfig
+	tests := []struct {
+		docType, currency string
+		want              int
+	}{
+		{"ORDER", `ש"ח`, 30},
+		{"ORDER", "$", 32},
+		{"QUOATE", "$", 40},
+		{"RETURN", `ש"ח`, 74},
+	}
+	for _, tt := range tests {
+		m, ok := h.DocumentMapping(tt.docType)
+		if !ok {
+			t.Fatalf("%s: no mapping", tt.docType)
+		}
+		if got := m.DocumentIDFor(tt.currency, h.LocalCurrencyCode()); got != tt.want {
+			t.Errorf("%s %s: DocumentID = %d, want %d", tt.docType, tt.currency, got, tt.want)
+		}
+		if m.Warehouse != 1 || m.Copies != 1 || m.Unit != DefaultHasavshevetUnit || m.RemarksTemplate != "{comment}" {
+			t.Errorf("%s: mapping = %+v", tt.docType, m)
+		}
+	}
+	if _, ok := h.DocumentMapping("INVOICE"); ok {
+		t.Error("unknown document type must not have a mapping")
+	}
+	if got := h.DefaultOrderDocumentIDs(); !slices.Equal(got, []int{11, 30, 32}) {
+		t.Errorf("DefaultOrderDocumentIDs = %v", got)
+	}
+}
+
+func TestDocumentIDs(t *testing.T) {
+	var h HasavshevetConfig
+	ids := h.DocumentIDs()
+	if !slices.Equal(ids.Orders, []int{11, 30, 32}) || !slices.Equal(ids.DeliveryNotes, []int{3}) || !slices.Equal(ids.Invoices, []int{1}) {
+		t.Errorf("defaults = %+v", ids)
+	}
+
+	h.Documents = HasavshevetDocumentIDs{Invoices: []int{3, 5}}
+	ids = h.DocumentIDs()
+	if len(ids.DeliveryNotes) != 0 || !slices.Equal(ids.Invoices, []int{3, 5}) {
+		t.Errorf("overrides = %+v, want the configured invoice code kept out of the delivery notes", ids)
+	}
+}
+
+func TestDocumentMapping_Overrides(t *testing.T) {
+	h := HasavshevetConfig{
+		LocalCurrency: "ILS",
+		SendOrder: map[string]HasavshevetDocumentMapping{
+			"ORDER": {DocumentID: 31, CurrencyDocumentIDs: map[string]int{"EUR": 33}, Warehouse: 5, Agent: "7"},
+		},
+	}
+	m, _ := h.DocumentMapping("ORDER")
+	for currency, want := range map[string]int{"ILS": 31, "$": 32, "EUR": 33} {
+		if got := m.DocumentIDFor(currency, h.LocalCurrencyCode()); got != want {
+			t.Errorf("%s: DocumentID = %d, want %d", currency, got, want)
+		}
+	}
+	if m.Warehouse != 5 || m.Copies != 1 || m.Agent != "7" {
+		t.Errorf("mapping = %+v, want overrides over the defaults", m)
+	}
+	if got := h.DefaultOrderDocumentIDs(); !slices.Equal(got, []int{11, 31, 32, 33}) {
+		t.Errorf("DefaultOrderDocumentIDs = %v", got)
+	}
+}
+
+func TestVATRateOn(t *testing.T) {
+	var v HasavshevetVATConfig
+	tests := []struct {
+		date string
+		want float64
+	}{
+		{"2015-09-30", 18},
+		{"2015-10-01", 17},
+		{"2024-12-31", 17},
+		{"2025-01-01", 18},
+	}
+	for _, tt := range tests {
+		date, _ := time.Parse(time.DateOnly, tt.date)
+		if got, ok := v.RateOn(date); !ok || got != tt.want {
+			t.Errorf("RateOn(%s) = %v, %v, want %v", tt.date, got, ok, tt.want)
+		}
+	}
+	if _, ok := v.RateOn(time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)); ok {
+		t.Error("date before the first rate must have no rate")
+	}
+	if got := v.ExemptColumnName(); got != DefaultHasavshevetVATExemptColumn {
+		t.Errorf("ExemptColumnName = %q", got)
+	}
+	if got := (HasavshevetVATConfig{ExemptColumn: "-"}).ExemptColumnName(); got != "" {
+		t.Errorf("disabled ExemptColumnName = %q", got)
+	}
+}
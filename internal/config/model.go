@@ -13,12 +13,165 @@ const (
 	DBDriverMSSQL DBDriver = "mssql"
 )
 
+// NoLimit in a db pool setting (maxOpenConns, connMaxIdleTimeSeconds,
+// connMaxLifetimeMinutes) lifts the limit instead of using the default.
+const NoLimit = -1
+
+// DBConfig describes how the connector reaches the ERP's SQL Server.
+// Only driver/host/port/user/database were required historically; every other
+// field is optional and left to the driver default when empty, so older config
+// files keep connecting exactly as before.
 type DBConfig struct {
 	Driver   DBDriver `yaml:"driver"`
-	Host     string   `yaml:"host"`
-	Port     int      `yaml:"port"`
+	Host     string   `yaml:"host"` // may also be written as HOST\INSTANCE
+	Port     int      `yaml:"port"` // ignored when Instance is set (resolved via SQL Browser)
 	User     string   `yaml:"user"`
 	Database string   `yaml:"database"`
+
+	// Instance is the SQL Server named instance (e.g. SQLEXPRESS). The port is
+	// looked up through the SQL Server Browser service (UDP 1434).
+	Instance string `yaml:"instance,omitempty"`
+	// Encrypt is passed through to the driver: "true", "false", "disable" or
+	// "strict". Empty keeps the driver default (login packet encrypted only).
+	Encrypt                string `yaml:"encrypt,omitempty"`
+	TrustServerCertificate bool   `yaml:"trustServerCertificate,omitempty"`
+	// ApplicationIntent is "ReadWrite" (default) or "ReadOnly" for routing to
+	// an Always On readable secondary.
+	ApplicationIntent     string `yaml:"applicationIntent,omitempty"`
+	ConnectTimeoutSeconds int    `yaml:"connectTimeoutSeconds,omitempty"` // 0 → driver default
+	// AppName is reported to SQL Server (sys.dm_exec_sessions.program_name) so
+	// DBAs can identify connector sessions. Defaults to "erp-connector".
+	AppName string `yaml:"appName,omitempty"`
+	// WindowsAuth uses integrated Windows authentication (SSPI) with the
+	// identity of the running process instead of User + stored password.
+	WindowsAuth bool `yaml:"windowsAuth,omitempty"`
+
+	// Connection pool sizing. Zero keeps the built-in default (10 open /
+	// 10 idle, no idle time limit, 30 minute lifetime); NoLimit (-1) lifts
+	// the open connection, idle time or lifetime limit.
+	MaxOpenConns           int `yaml:"maxOpenConns,omitempty"`
+	MaxIdleConns           int `yaml:"maxIdleConns,omitempty"`
+	ConnMaxIdleTimeSeconds int `yaml:"connMaxIdleTimeSeconds,omitempty"`
+	ConnMaxLifetimeMinutes int `yaml:"connMaxLifetimeMinutes,omitempty"`
+}
+
+// PriorityConfig describes how the connector reaches Priority's REST/OData
+// API. Priority is not read through SQL Server, so db is unused for it. The
+// API user's password is stored in secrets under the usual ERP key
+// (db_password_priority), never in YAML.
+type PriorityConfig struct {
+	// ServiceURL is the OData service root including tabula.ini and the
+	// company, e.g. https://erp.example.com/odata/Priority/tabula.ini/demo
+	ServiceURL string `yaml:"serviceURL"`
+	User       string `yaml:"user"`
+	// AppID / AppKey are sent as X-App-Id / X-App-Key when Priority's
+	// application licensing is enabled for the API.
+	AppID                  string `yaml:"appId,omitempty"`
+	AppKey                 string `yaml:"appKey,omitempty"`
+	TimeoutSeconds         int    `yaml:"timeoutSeconds,omitempty"` // 0 → 30s
+	TrustServerCertificate bool   `yaml:"trustServerCertificate,omitempty"`
+	// DefaultWarehouse is used for order lines when the request names none.
+	DefaultWarehouse string `yaml:"defaultWarehouse,omitempty"`
+}
+
+// SAPConfig holds SAP Business One settings beyond the SQL connection in db.
+// Price/stock keep reading the company database; orders are created through
+// the Service Layer REST API, whose password is stored in secrets under
+// sap_service_layer_password, never in YAML.
+type SAPConfig struct {
+	// ServiceLayerURL is the Service Layer root, e.g.
+	// https://sap-host:50000/b1s/v1. Empty disables order submission.
+	ServiceLayerURL string `yaml:"serviceLayerURL,omitempty"`
+	// CompanyDB is the company schema to log in to; empty uses db.database.
+	CompanyDB              string `yaml:"companyDB,omitempty"`
+	User                   string `yaml:"user,omitempty"`
+	TimeoutSeconds         int    `yaml:"timeoutSeconds,omitempty"` // 0 → 30s
+	TrustServerCertificate bool   `yaml:"trustServerCertificate,omitempty"`
+	// Drafts creates every document as a draft (ODRF) for review in SAP
+	// instead of a final ORDR/OQUT/ORDN document.
+	Drafts bool `yaml:"drafts,omitempty"`
+	// DefaultWarehouse is sent as WarehouseCode on order lines; empty keeps
+	// the item's default warehouse.
+	DefaultWarehouse string `yaml:"defaultWarehouse,omitempty"`
+}
+
+// HasavshevetConfig holds Hasavshevet settings beyond the import paths.
+type HasavshevetConfig struct {
+	// Documents lists which Stock.DocumentID codes are reported by
+	// GET /api/customers/{extId}/documents.
+	Documents HasavshevetDocumentIDs `yaml:"documents,omitempty"`
+	// ImportVerifySeconds is how long sendOrder waits, after has.exe /
+	// digi.bat ran, for the imported Stock document (Asmahta2 = order
+	// number) before failing the job. 0 uses 60 seconds; negative disables
+	// the check.
+	ImportVerifySeconds int `yaml:"importVerifySeconds,omitempty"`
+	// ImportErrorFiles are the file patterns (e.g. "*.err") has.exe writes
+	// rejected records to, searched in sendOrderDir and the importer's
+	// directory after each run. Empty uses *.err, *error*.txt and *.log;
+	// only the lines of a .log that read like errors count.
+	ImportErrorFiles []string `yaml:"importErrorFiles,omitempty"`
+	// BatchWindowSeconds, when positive, lets the sendOrder queue gather the
+	// orders that arrive within that many seconds of the first into one
+	// IMOVEIN file and one importer run. 0 imports each order on its own.
+	BatchWindowSeconds int `yaml:"batchWindowSeconds,omitempty"`
+	// BatchMaxOrders caps the orders of one batch; 0 uses 20.
+	BatchMaxOrders int `yaml:"batchMaxOrders,omitempty"`
+	// PriorityMaxSkips is how many jobs from higher priority lanes may be
+	// taken ahead of a waiting lower priority job before it goes next; 0
+	// uses 4.
+	PriorityMaxSkips int `yaml:"priorityMaxSkips,omitempty"`
+	// LocalCurrency is the currency code of shekel orders; empty uses
+	// DefaultHasavshevetLocalCurrency.
+	LocalCurrency string `yaml:"localCurrency,omitempty"`
+	// SendOrder maps documentType (ORDER, QUOATE, RETURN) to the IMOVEIN
+	// header it is imported with; see HasavshevetDocumentMapping.
+	SendOrder map[string]HasavshevetDocumentMapping `yaml:"sendOrder,omitempty"`
+	// VAT decides the VAT rate written to IMOVEIN; see HasavshevetVATConfig.
+	VAT HasavshevetVATConfig `yaml:"vat,omitempty"`
+}
+
+// HasavshevetDocumentIDs groups document codes by kind. Codes differ between
+// installations; an empty list uses the default for its kind:
+// DefaultOrderDocumentIDs (customer orders and the sendOrder ORDER codes),
+// DefaultDeliveryNoteDocumentIDs or DefaultInvoiceDocumentIDs.
+type HasavshevetDocumentIDs struct {
+	Orders        []int `yaml:"orders,omitempty"`
+	DeliveryNotes []int `yaml:"deliveryNotes,omitempty"`
+	Invoices      []int `yaml:"invoices,omitempty"`
+}
+
+// Credit check modes for CreditCheckConfig.Mode.
+const (
+	CreditCheckOff    = "off"
+	CreditCheckReject = "reject"
+	CreditCheckWarn   = "warn"
+	CreditCheckFlag   = "flag"
+)
+
+// CreditCheckModes lists the accepted values for CreditCheckConfig.Mode. The
+// empty string means off.
+func CreditCheckModes() []string {
+	return []string{"", CreditCheckOff, CreditCheckReject, CreditCheckWarn, CreditCheckFlag}
+}
+
+// CreditCheckConfig controls the credit check sendOrder runs before queueing
+// an ORDER: the customer's open balance, open deliveries and open orders plus
+// the order total are compared with the credit limit.
+type CreditCheckConfig struct {
+	// Mode is what happens to an order over the limit or for a blocked
+	// customer: reject it, accept it with a warning, or accept it with
+	// FlagText prepended to its comment. Empty or "off" disables the check.
+	Mode string `yaml:"mode,omitempty"`
+	// FlagText marks flagged orders; empty uses DefaultCreditFlagText.
+	FlagText string `yaml:"flagText,omitempty"`
+}
+
+// DefaultCreditFlagText is prepended to the comment of flagged orders.
+const DefaultCreditFlagText = "CREDIT CHECK"
+
+// Enabled reports whether orders are checked.
+func (c CreditCheckConfig) Enabled() bool {
+	return c.Mode != "" && c.Mode != CreditCheckOff
 }
 
 // PDFConfig holds print/email toggles + remote-template integration. Branding
@@ -75,6 +228,54 @@ type Config struct {
 	DB         DBConfig  `yaml:"db"`
 	PDF        PDFConfig `yaml:"pdf"`
 	SMTP       SMTPConfig `yaml:"smtp"`
+	// Priority holds the OData connection used when erp is "priority".
+	Priority PriorityConfig `yaml:"priority,omitempty"`
+	// SAP holds the Service Layer connection used for orders when erp is "sap".
+	SAP SAPConfig `yaml:"sap,omitempty"`
+	// Hasavshevet holds settings used when erp is "hasavshevet".
+	Hasavshevet HasavshevetConfig `yaml:"hasavshevet,omitempty"`
+	// CreditCheck runs a credit check before sendOrder queues an order.
+	CreditCheck CreditCheckConfig `yaml:"creditCheck,omitempty"`
+
+	// Companies lists named company profiles served by this connector. Empty
+	// means a single unnamed company described by the top-level fields.
+	Companies []CompanyConfig `yaml:"companies,omitempty"`
+	// DefaultCompany is used when a request names no company. When empty and
+	// exactly one company is configured, that company is the default.
+	DefaultCompany string `yaml:"defaultCompany,omitempty"`
+	// Tokens are additional bearer tokens restricted to specific companies.
+	// BearerToken keeps access to every company.
+	Tokens []TokenConfig `yaml:"tokens,omitempty"`
+}
+
+// CompanyConfig is a named company profile: typically a separate ERP database
+// on the same SQL Server. Empty fields inherit the top-level value; non-zero
+// DB fields override the matching top-level db field.
+type CompanyConfig struct {
+	Name         string     `yaml:"name"`
+	ERP          ERPType    `yaml:"erp,omitempty"`
+	DB           DBConfig   `yaml:"db,omitempty"`
+	SendOrderDir string     `yaml:"sendOrderDir,omitempty"`
+	HasExePath   string     `yaml:"hasExePath,omitempty"`
+	HasParamFile string     `yaml:"hasParamFile,omitempty"`
+	HasBatFile   string     `yaml:"hasBatFile,omitempty"`
+	PDF          *PDFConfig `yaml:"pdf,omitempty"` // nil inherits the top-level pdf block
+	// Priority replaces the top-level priority block when set. Each Priority
+	// company is a separate OData service root.
+	Priority *PriorityConfig `yaml:"priority,omitempty"`
+	// SAP replaces the top-level sap block when set.
+	SAP *SAPConfig `yaml:"sap,omitempty"`
+	// Hasavshevet replaces the top-level hasavshevet block when set.
+	Hasavshevet *HasavshevetConfig `yaml:"hasavshevet,omitempty"`
+	// CreditCheck replaces the top-level creditCheck block when set.
+	CreditCheck *CreditCheckConfig `yaml:"creditCheck,omitempty"`
+}
+
+// TokenConfig is a bearer token limited to the listed companies.
+type TokenConfig struct {
+	Name      string   `yaml:"name,omitempty"` // label for logs only
+	Token     string   `yaml:"token"`
+	Companies []string `yaml:"companies"`
 }
 
 func ErpValues() []ERPType {
@@ -90,6 +291,18 @@ func ErpOption() []string {
 	return out
 }
 
+// DBEncryptValues lists the accepted values for DBConfig.Encrypt. The empty
+// string keeps the driver default.
+func DBEncryptValues() []string {
+	return []string{"", "true", "false", "disable", "strict"}
+}
+
+// DBApplicationIntentValues lists the accepted values for
+// DBConfig.ApplicationIntent. The empty string means ReadWrite.
+func DBApplicationIntentValues() []string {
+	return []string{"", "ReadWrite", "ReadOnly"}
+}
+
 func DBDriverValues() []DBDriver {
 	return []DBDriver{DBDriverMSSQL}
 }
@@ -120,6 +333,7 @@ func Default() Config {
 			Port:     1433,
 			Database: "",
 			User:     "",
+			AppName:  "erp-connector",
 		},
 		PDF: PDFConfig{
 			PrintAfterOrder:      false,
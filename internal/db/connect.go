@@ -7,12 +7,16 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// Options configures the connection pool. Zero limits mean no limit.
 type Options struct {
 	MaxOpenConns    int
 	MaxIdleConns    int
+	ConnMaxIdleTime time.Duration
 	ConnMaxLifetime time.Duration
 	PingTimeout     time.Duration
 }
@@ -26,7 +30,61 @@ func DefaultOptions() Options {
 	}
 }
 
+// OptionsFromConfig returns DefaultOptions overridden by the pool settings in
+// dbCfg. Zero keeps the default and config.NoLimit removes the limit;
+// config.ValidateCompanies rejects other negative values. database/sql itself
+// clamps MaxIdleConns down to MaxOpenConns.
+func OptionsFromConfig(dbCfg config.DBConfig) Options {
+	opt := DefaultOptions()
+	switch {
+	case dbCfg.MaxOpenConns == config.NoLimit:
+		opt.MaxOpenConns = 0
+	case dbCfg.MaxOpenConns > 0:
+		opt.MaxOpenConns = dbCfg.MaxOpenConns
+	}
+	if dbCfg.MaxIdleConns > 0 {
+		opt.MaxIdleConns = dbCfg.MaxIdleConns
+	}
+	switch {
+	case dbCfg.ConnMaxIdleTimeSeconds == config.NoLimit:
+		opt.ConnMaxIdleTime = 0
+	case dbCfg.ConnMaxIdleTimeSeconds > 0:
+		opt.ConnMaxIdleTime = time.Duration(dbCfg.ConnMaxIdleTimeSeconds) * time.Second
+	}
+	switch {
+	case dbCfg.ConnMaxLifetimeMinutes == config.NoLimit:
+		opt.ConnMaxLifetime = 0
+	case dbCfg.ConnMaxLifetimeMinutes > 0:
+		opt.ConnMaxLifetime = time.Duration(dbCfg.ConnMaxLifetimeMinutes) * time.Minute
+	}
+	return opt
+}
+
 func Open(cfg config.Config, password string, opt Options) (*sql.DB, error) {
+	db, err := openPool(cfg, password, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	if opt.PingTimeout <= 0 {
+		opt.PingTimeout = 5 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opt.PingTimeout)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// openPool builds the DSN and configures the pool without touching the
+// network. Errors returned here are configuration errors and will not go away
+// by retrying.
+func openPool(cfg config.Config, password string, opt Options) (*sql.DB, error) {
 	driverName, dsn, err := buildDSN(cfg, password)
 	if err != nil {
 		return nil, err
@@ -43,27 +101,21 @@ func Open(cfg config.Config, password string, opt Options) (*sql.DB, error) {
 	if opt.MaxIdleConns > 0 {
 		db.SetMaxIdleConns(opt.MaxIdleConns)
 	}
+	if opt.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(opt.ConnMaxIdleTime)
+	}
 	if opt.ConnMaxLifetime > 0 {
 		db.SetConnMaxLifetime(opt.ConnMaxLifetime)
 	}
 
-	if opt.PingTimeout <= 0 {
-		opt.PingTimeout = 5 * time.Second
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), opt.PingTimeout)
-	defer cancel()
-
-	if err := db.PingContext(ctx); err != nil {
-		_ = db.Close()
-		return nil, err
-	}
-
 	return db, nil
 }
 
+// defaultAppName is reported to SQL Server when db.appName is not configured.
+const defaultAppName = "erp-connector"
+
 func buildDSN(cfg config.Config, password string) (driverName string, dsn string, err error) {
-	host := cfg.DB.Host
+	host, instance := splitInstance(cfg.DB.Host, cfg.DB.Instance)
 	port := cfg.DB.Port
 	user := cfg.DB.User
 	dbName := cfg.DB.Database
@@ -71,24 +123,66 @@ func buildDSN(cfg config.Config, password string) (driverName string, dsn string
 	if host == "" {
 		return "", "", errors.New("db.host is required")
 	}
-	if port <= 0 || port > 65535 {
+	if instance == "" && (port <= 0 || port > 65535) {
 		return "", "", errors.New("db.port is invalid")
 	}
-	if user == "" {
+	if user == "" && !cfg.DB.WindowsAuth {
 		return "", "", errors.New("db.user is required")
 	}
+	if !containsString(config.DBEncryptValues(), strings.ToLower(strings.TrimSpace(cfg.DB.Encrypt))) {
+		return "", "", fmt.Errorf("db.encrypt is invalid: %q", cfg.DB.Encrypt)
+	}
+	intent, ok := normalizeApplicationIntent(cfg.DB.ApplicationIntent)
+	if !ok {
+		return "", "", fmt.Errorf("db.applicationIntent is invalid: %q", cfg.DB.ApplicationIntent)
+	}
+	if intent == "ReadOnly" && dbName == "" {
+		return "", "", errors.New("db.database is required when db.applicationIntent is ReadOnly")
+	}
+	if cfg.DB.ConnectTimeoutSeconds < 0 {
+		return "", "", errors.New("db.connectTimeoutSeconds is invalid")
+	}
 
 	switch cfg.DB.Driver {
 	case config.DBDriverMSSQL:
 		u := &url.URL{
 			Scheme: "sqlserver",
-			User:   url.UserPassword(user, password),
 			Host:   fmt.Sprintf("%s:%d", host, port),
 		}
+		if instance != "" {
+			// Named instances are resolved through the SQL Browser service;
+			// an explicit port would bypass it.
+			u.Host = host
+			u.Path = "/" + instance
+		}
+		// Integrated auth: the driver falls back to SSPI (winsspi on Windows)
+		// when no user is supplied.
+		if !cfg.DB.WindowsAuth {
+			u.User = url.UserPassword(user, password)
+		}
+
 		q := url.Values{}
 		if dbName != "" {
 			q.Set("database", dbName)
 		}
+		if enc := strings.ToLower(strings.TrimSpace(cfg.DB.Encrypt)); enc != "" {
+			q.Set("encrypt", enc)
+		}
+		if cfg.DB.TrustServerCertificate {
+			q.Set("TrustServerCertificate", "true")
+		}
+		if intent != "" {
+			q.Set("ApplicationIntent", intent)
+		}
+		if cfg.DB.ConnectTimeoutSeconds > 0 {
+			q.Set("connection timeout", strconv.Itoa(cfg.DB.ConnectTimeoutSeconds))
+			q.Set("dial timeout", strconv.Itoa(cfg.DB.ConnectTimeoutSeconds))
+		}
+		appName := strings.TrimSpace(cfg.DB.AppName)
+		if appName == "" {
+			appName = defaultAppName
+		}
+		q.Set("app name", appName)
 		u.RawQuery = q.Encode()
 
 		return "sqlserver", u.String(), nil
@@ -98,6 +192,39 @@ func buildDSN(cfg config.Config, password string) (driverName string, dsn string
 	}
 }
 
+// splitInstance separates a "HOST\INSTANCE" host value. An explicit
+// db.instance wins over the one embedded in the host string.
+func splitInstance(host, instance string) (string, string) {
+	host = strings.TrimSpace(host)
+	instance = strings.TrimSpace(instance)
+	if i := strings.IndexByte(host, '\\'); i >= 0 {
+		if instance == "" {
+			instance = strings.TrimSpace(host[i+1:])
+		}
+		host = strings.TrimSpace(host[:i])
+	}
+	return host, instance
+}
+
+func normalizeApplicationIntent(v string) (string, bool) {
+	v = strings.TrimSpace(v)
+	for _, allowed := range config.DBApplicationIntentValues() {
+		if strings.EqualFold(v, allowed) {
+			return allowed, true
+		}
+	}
+	return "", false
+}
+
+func containsString(values []string, v string) bool {
+	for _, item := range values {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
 func TestConnection(ctx context.Context, cfg config.Config, password string) error {
 	if ctx == nil {
 		ctx = context.Background()
@@ -0,0 +1,185 @@
+package db
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/microsoft/go-mssqldb/msdsn"
+
+	"erp-connector/internal/config"
+)
+
+func baseDBConfig() config.Config {
+	cfg := config.Default()
+	cfg.DB.Host = "sql01"
+	cfg.DB.User = "sa"
+	cfg.DB.Database = "ERPDB"
+	return cfg
+}
+
+// TestBuildDSN_LegacyConfig verifies a config without any of the optional
+// connection fields still produces a plain host:port DSN.
+func TestBuildDSN_LegacyConfig(t *testing.T) {
+	cfg := baseDBConfig()
+	cfg.DB.AppName = ""
+
+	driver, dsn, err := buildDSN(cfg, "secret")
+	if err != nil {
+		t.Fatalf("buildDSN error: %v", err)
+	}
+	if driver != "sqlserver" {
+		t.Errorf("driver = %q, want sqlserver", driver)
+	}
+
+	p, err := msdsn.Parse(dsn)
+	if err != nil {
+		t.Fatalf("driver rejected DSN %q: %v", dsn, err)
+	}
+	if p.Host != "sql01" || p.Port != 1433 || p.Instance != "" {
+		t.Errorf("host/port/instance = %q/%d/%q, want sql01/1433/\"\"", p.Host, p.Port, p.Instance)
+	}
+	if p.User != "sa" || p.Password != "secret" || p.Database != "ERPDB" {
+		t.Errorf("credentials/database not passed through: %+v", p)
+	}
+	if p.AppName != defaultAppName {
+		t.Errorf("app name = %q, want %q", p.AppName, defaultAppName)
+	}
+}
+
+// TestBuildDSN_NamedInstanceInHost verifies HOST\INSTANCE is split and the
+// port is left to the SQL Browser service.
+func TestBuildDSN_NamedInstanceInHost(t *testing.T) {
+	cfg := baseDBConfig()
+	cfg.DB.Host = `sql01\SQLEXPRESS`
+	cfg.DB.Port = 0
+
+	_, dsn, err := buildDSN(cfg, "secret")
+	if err != nil {
+		t.Fatalf("buildDSN error: %v", err)
+	}
+	p, err := msdsn.Parse(dsn)
+	if err != nil {
+		t.Fatalf("driver rejected DSN %q: %v", dsn, err)
+	}
+	if p.Host != "sql01" || p.Instance != "SQLEXPRESS" {
+		t.Errorf("host/instance = %q/%q, want sql01/SQLEXPRESS", p.Host, p.Instance)
+	}
+	if strings.Contains(dsn, ":0") {
+		t.Errorf("DSN should not carry a port for named instances: %s", dsn)
+	}
+}
+
+// TestBuildDSN_Options verifies encryption, intent, timeouts and app name
+// reach the driver.
+func TestBuildDSN_Options(t *testing.T) {
+	cfg := baseDBConfig()
+	cfg.DB.Encrypt = "true"
+	cfg.DB.TrustServerCertificate = true
+	cfg.DB.ApplicationIntent = "readonly"
+	cfg.DB.ConnectTimeoutSeconds = 20
+	cfg.DB.AppName = "connector-site-7"
+
+	_, dsn, err := buildDSN(cfg, "secret")
+	if err != nil {
+		t.Fatalf("buildDSN error: %v", err)
+	}
+	p, err := msdsn.Parse(dsn)
+	if err != nil {
+		t.Fatalf("driver rejected DSN %q: %v", dsn, err)
+	}
+	if p.Encryption != msdsn.EncryptionRequired {
+		t.Errorf("encryption = %v, want required", p.Encryption)
+	}
+	if p.TLSConfig == nil || !p.TLSConfig.InsecureSkipVerify {
+		t.Errorf("TrustServerCertificate not applied")
+	}
+	if !p.ReadOnlyIntent {
+		t.Errorf("ApplicationIntent=ReadOnly not applied")
+	}
+	if p.ConnTimeout.Seconds() != 20 || p.DialTimeout.Seconds() != 20 {
+		t.Errorf("timeouts = %v/%v, want 20s", p.ConnTimeout, p.DialTimeout)
+	}
+	if p.AppName != "connector-site-7" {
+		t.Errorf("app name = %q", p.AppName)
+	}
+}
+
+// TestBuildDSN_WindowsAuth verifies integrated auth omits credentials and
+// does not require db.user.
+func TestBuildDSN_WindowsAuth(t *testing.T) {
+	cfg := baseDBConfig()
+	cfg.DB.User = ""
+	cfg.DB.WindowsAuth = true
+
+	_, dsn, err := buildDSN(cfg, "ignored")
+	if err != nil {
+		t.Fatalf("buildDSN error: %v", err)
+	}
+	if strings.Contains(dsn, "ignored") || strings.Contains(dsn, "@") {
+		t.Errorf("DSN must not carry credentials with windowsAuth: %s", dsn)
+	}
+}
+
+func TestBuildDSN_Validation(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*config.Config)
+	}{
+		{"missing host", func(c *config.Config) { c.DB.Host = "" }},
+		{"bad port", func(c *config.Config) { c.DB.Port = 70000 }},
+		{"missing user", func(c *config.Config) { c.DB.User = "" }},
+		{"bad encrypt", func(c *config.Config) { c.DB.Encrypt = "maybe" }},
+		{"bad intent", func(c *config.Config) { c.DB.ApplicationIntent = "WriteOnly" }},
+		{"readonly without database", func(c *config.Config) {
+			c.DB.ApplicationIntent = "ReadOnly"
+			c.DB.Database = ""
+		}},
+		{"negative timeout", func(c *config.Config) { c.DB.ConnectTimeoutSeconds = -1 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := baseDBConfig()
+			tt.mutate(&cfg)
+			if _, _, err := buildDSN(cfg, "secret"); err == nil {
+				t.Errorf("expected validation error")
+			}
+		})
+	}
+}
+
+// TestOptionsFromConfig verifies configured pool sizes override the defaults
+// and zero values keep them.
+func TestOptionsFromConfig(t *testing.T) {
+	def := DefaultOptions()
+	if got := OptionsFromConfig(config.DBConfig{}); got != def {
+		t.Errorf("empty config = %+v, want defaults %+v", got, def)
+	}
+
+	got := OptionsFromConfig(config.DBConfig{
+		MaxOpenConns:           40,
+		MaxIdleConns:           4,
+		ConnMaxIdleTimeSeconds: 90,
+		ConnMaxLifetimeMinutes: 10,
+	})
+	if got.MaxOpenConns != 40 || got.MaxIdleConns != 4 {
+		t.Errorf("open/idle = %d/%d, want 40/4", got.MaxOpenConns, got.MaxIdleConns)
+	}
+	if got.ConnMaxIdleTime.Seconds() != 90 || got.ConnMaxLifetime.Minutes() != 10 {
+		t.Errorf("idle time/lifetime = %v/%v, want 90s/10m", got.ConnMaxIdleTime, got.ConnMaxLifetime)
+	}
+	if got.PingTimeout != def.PingTimeout {
+		t.Errorf("ping timeout changed: %v", got.PingTimeout)
+	}
+	if def.ConnMaxIdleTime != 0 {
+		t.Errorf("default idle time = %v, want no limit", def.ConnMaxIdleTime)
+	}
+
+	got = OptionsFromConfig(config.DBConfig{
+		MaxOpenConns:           config.NoLimit,
+		ConnMaxIdleTimeSeconds: config.NoLimit,
+		ConnMaxLifetimeMinutes: config.NoLimit,
+	})
+	if got.MaxOpenConns != 0 || got.ConnMaxIdleTime != 0 || got.ConnMaxLifetime != 0 {
+		t.Errorf("no limit = %+v, want zero limits", got)
+	}
+}
@@ -0,0 +1,259 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"erp-connector/internal/config"
+	"erp-connector/internal/logger"
+)
+
+// ErrUnavailable is returned by callers that need the database while the
+// Handle is in degraded mode.
+var ErrUnavailable = errors.New("database unavailable")
+
+// errClosed is returned by Connect after Close.
+var errClosed = fmt.Errorf("%w: handle closed", ErrUnavailable)
+
+const (
+	reconnectMinBackoff = 1 * time.Second
+	reconnectMaxBackoff = 30 * time.Second
+	healthCheckInterval = 15 * time.Second
+)
+
+// Handle owns the daemon's connection pool and keeps it usable across ERP
+// database outages. The daemon starts even when SQL Server is not reachable
+// yet: DB() returns nil until the first successful ping, and Run keeps
+// retrying with exponential backoff in the background. Once connected, the
+// pool is pinged periodically so an outage flips the handle back into
+// degraded mode instead of letting every request hang on dial timeouts.
+//
+// All methods are safe on a nil *Handle (always unavailable), which keeps
+// handler-level tests free of database setup.
+type Handle struct {
+	open func() (*sql.DB, error)
+	ping time.Duration
+	log  logger.LoggerService
+
+	mu        sync.RWMutex
+	pool      *sql.DB
+	available bool
+	checked   bool
+	lastErr   error
+	onReady   []func(*sql.DB)
+	readyOnce bool
+	closed    bool
+}
+
+// NewHandle returns a Handle for cfg. Call Run to start connecting.
+func NewHandle(cfg config.Config, password string, opt Options, log logger.LoggerService) *Handle {
+	if opt.PingTimeout <= 0 {
+		opt.PingTimeout = 5 * time.Second
+	}
+	return &Handle{
+		open: func() (*sql.DB, error) { return openPool(cfg, password, opt) },
+		ping: opt.PingTimeout,
+		log:  log,
+	}
+}
+
+// DB returns the pool while the database is reachable, or nil in degraded mode.
+func (h *Handle) DB() *sql.DB {
+	if h == nil {
+		return nil
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if !h.available {
+		return nil
+	}
+	return h.pool
+}
+
+// Pool returns the underlying pool regardless of availability (nil before the
+// configuration could be applied). Used for statistics only.
+func (h *Handle) Pool() *sql.DB {
+	if h == nil {
+		return nil
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.pool
+}
+
+// Available reports whether the last connectivity check succeeded.
+func (h *Handle) Available() bool {
+	return h.DB() != nil
+}
+
+// LastError returns the error from the most recent failed connectivity check.
+func (h *Handle) LastError() error {
+	if h == nil {
+		return ErrUnavailable
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.lastErr
+}
+
+// Ping checks the database right now. Every failure wraps ErrUnavailable so
+// callers can map it to DB_UNAVAILABLE.
+func (h *Handle) Ping(ctx context.Context) error {
+	dbConn := h.DB()
+	if dbConn == nil {
+		return ErrUnavailable
+	}
+	if err := dbConn.PingContext(ctx); err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	return nil
+}
+
+// OnReady registers fn to run once, the first time the database becomes
+// reachable. If it already is, fn runs immediately on the caller's goroutine.
+func (h *Handle) OnReady(fn func(*sql.DB)) {
+	h.mu.Lock()
+	if h.readyOnce {
+		pool := h.pool
+		h.mu.Unlock()
+		fn(pool)
+		return
+	}
+	h.onReady = append(h.onReady, fn)
+	h.mu.Unlock()
+}
+
+// Connect makes a single attempt to reach the database and updates the
+// handle's state. It returns the configuration or ping error, if any.
+func (h *Handle) Connect(ctx context.Context) error {
+	h.mu.Lock()
+	pool, closed := h.pool, h.closed
+	h.mu.Unlock()
+	if closed {
+		return errClosed
+	}
+
+	if pool == nil {
+		opened, err := h.open()
+		if err != nil {
+			h.setState(nil, err)
+			return err
+		}
+		// open runs without mu: Close or another Connect may have run
+		// meanwhile. A pool opened after Close is discarded.
+		h.mu.Lock()
+		switch {
+		case h.closed:
+			h.mu.Unlock()
+			_ = opened.Close()
+			return errClosed
+		case h.pool != nil:
+			pool = h.pool
+			h.mu.Unlock()
+			_ = opened.Close()
+		default:
+			h.pool, pool = opened, opened
+			h.mu.Unlock()
+		}
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, h.ping)
+	defer cancel()
+	err := pool.PingContext(pingCtx)
+	h.setState(pool, err)
+	return err
+}
+
+// Run keeps the handle connected until ctx is cancelled: it retries with
+// exponential backoff while the database is down and health-checks it while
+// it is up. Configuration errors (bad DSN) are not retried.
+func (h *Handle) Run(ctx context.Context) {
+	backoff := reconnectMinBackoff
+	for {
+		var wait time.Duration
+		err := h.Connect(ctx)
+		switch {
+		case errors.Is(err, errClosed):
+			return
+		case err == nil:
+			backoff = reconnectMinBackoff
+			wait = healthCheckInterval
+		case h.Pool() == nil:
+			h.log.Error("database configuration invalid; not retrying", err)
+			return
+		default:
+			wait = backoff
+			backoff *= 2
+			if backoff > reconnectMaxBackoff {
+				backoff = reconnectMaxBackoff
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Close releases the pool. The handle stays unavailable afterwards: Connect
+// fails and Run returns.
+func (h *Handle) Close() error {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	pool := h.pool
+	h.pool = nil
+	h.available = false
+	h.closed = true
+	h.mu.Unlock()
+	if pool == nil {
+		return nil
+	}
+	return pool.Close()
+}
+
+// setState records the outcome of a connectivity check, logs transitions and
+// fires OnReady callbacks on the first success. Results that arrive after
+// Close are dropped.
+func (h *Handle) setState(pool *sql.DB, err error) {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return
+	}
+	was := h.available
+	first := !h.checked
+	h.checked = true
+	h.available = err == nil && pool != nil
+	h.lastErr = err
+	var ready []func(*sql.DB)
+	if h.available && !h.readyOnce {
+		h.readyOnce = true
+		ready = h.onReady
+		h.onReady = nil
+	}
+	now := h.available
+	h.mu.Unlock()
+
+	switch {
+	case now && !was:
+		h.log.Success("database connection available")
+	case !now && was:
+		h.log.Error("database connection lost; API running in degraded mode", err)
+	case !now && first:
+		h.log.Error("database unreachable; API running in degraded mode", err)
+	case !now:
+		h.log.Warn(fmt.Sprintf("database still unreachable: %v", err))
+	}
+
+	for _, fn := range ready {
+		fn(pool)
+	}
+}
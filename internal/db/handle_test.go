@@ -0,0 +1,160 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+// flakyDriver is a database/sql driver whose connections succeed only while
+// up is true. It lets Handle be exercised without a SQL Server.
+type flakyDriver struct {
+	up *atomic.Bool
+}
+
+func (d flakyDriver) Open(string) (driver.Conn, error) {
+	if !d.up.Load() {
+		return nil, errors.New("connection refused")
+	}
+	return flakyConn{up: d.up}, nil
+}
+
+type flakyConn struct {
+	up *atomic.Bool
+}
+
+func (c flakyConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c flakyConn) Close() error                        { return nil }
+func (c flakyConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c flakyConn) Ping(context.Context) error {
+	if !c.up.Load() {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+var flakyUp atomic.Bool
+
+func init() {
+	sql.Register("flaky-test", flakyDriver{up: &flakyUp})
+}
+
+type noopLogger struct{}
+
+func (noopLogger) Info(string)         {}
+func (noopLogger) Error(string, error) {}
+func (noopLogger) Warn(string)         {}
+func (noopLogger) Success(string)      {}
+func (noopLogger) Close() error        { return nil }
+
+func newFlakyHandle() *Handle {
+	return &Handle{
+		open: func() (*sql.DB, error) { return sql.Open("flaky-test", "") },
+		ping: DefaultOptions().PingTimeout,
+		log:  noopLogger{},
+	}
+}
+
+// TestHandle_DegradedThenRecovers verifies DB() is nil while the database is
+// down, becomes usable after a successful Connect, and that OnReady fires
+// exactly once.
+func TestHandle_DegradedThenRecovers(t *testing.T) {
+	flakyUp.Store(false)
+	h := newFlakyHandle()
+	defer h.Close()
+
+	readyCalls := 0
+	h.OnReady(func(*sql.DB) { readyCalls++ })
+
+	if err := h.Connect(context.Background()); err == nil {
+		t.Fatal("expected connect error while database is down")
+	}
+	if h.DB() != nil || h.Available() {
+		t.Fatal("handle must be unavailable while database is down")
+	}
+	if h.LastError() == nil {
+		t.Error("LastError should be set in degraded mode")
+	}
+	if readyCalls != 0 {
+		t.Fatalf("OnReady fired while down: %d", readyCalls)
+	}
+
+	flakyUp.Store(true)
+	if err := h.Connect(context.Background()); err != nil {
+		t.Fatalf("connect after recovery: %v", err)
+	}
+	if h.DB() == nil {
+		t.Fatal("DB() should return the pool after recovery")
+	}
+	if readyCalls != 1 {
+		t.Fatalf("OnReady calls = %d, want 1", readyCalls)
+	}
+
+	// Outage and second recovery do not re-fire OnReady.
+	flakyUp.Store(false)
+	_ = h.Connect(context.Background())
+	if h.Available() {
+		t.Fatal("handle should flip back to degraded mode on ping failure")
+	}
+	flakyUp.Store(true)
+	if err := h.Connect(context.Background()); err != nil {
+		t.Fatalf("second recovery: %v", err)
+	}
+	if readyCalls != 1 {
+		t.Errorf("OnReady calls after second recovery = %d, want 1", readyCalls)
+	}
+
+	// Registering after the first success runs immediately.
+	late := false
+	h.OnReady(func(*sql.DB) { late = true })
+	if !late {
+		t.Error("OnReady registered after connect should run immediately")
+	}
+}
+
+// TestHandle_CloseDuringConnect verifies a pool opened while Close runs is
+// closed instead of stored, and the handle does not become ready.
+func TestHandle_CloseDuringConnect(t *testing.T) {
+	flakyUp.Store(true)
+	var opened *sql.DB
+	h := newFlakyHandle()
+	h.open = func() (*sql.DB, error) {
+		_ = h.Close()
+		pool, err := sql.Open("flaky-test", "")
+		opened = pool
+		return pool, err
+	}
+	ready := false
+	h.OnReady(func(*sql.DB) { ready = true })
+
+	if err := h.Connect(context.Background()); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("Connect after Close = %v, want ErrUnavailable", err)
+	}
+	if h.Pool() != nil || h.Available() || ready {
+		t.Fatal("handle stored a pool or became ready after Close")
+	}
+	if err := opened.Ping(); err == nil {
+		t.Error("pool opened during Close was not closed")
+	}
+	if err := h.Connect(context.Background()); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("second Connect after Close = %v, want ErrUnavailable", err)
+	}
+}
+
+// TestHandle_NilSafe verifies a nil handle behaves as permanently unavailable.
+func TestHandle_NilSafe(t *testing.T) {
+	var h *Handle
+	if h.DB() != nil || h.Pool() != nil || h.Available() {
+		t.Error("nil handle must be unavailable")
+	}
+	if !errors.Is(h.LastError(), ErrUnavailable) {
+		t.Errorf("nil handle LastError = %v, want ErrUnavailable", h.LastError())
+	}
+	if err := h.Close(); err != nil {
+		t.Errorf("nil handle Close = %v", err)
+	}
+}
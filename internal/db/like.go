@@ -0,0 +1,11 @@
+package db
+
+import "strings"
+
+var likeEscaper = strings.NewReplacer("[", "[[]", "%", "[%]", "_", "[_]")
+
+// EscapeLike quotes the SQL Server LIKE wildcards in s so user search text
+// matches literally. Wrap the result in % yourself for contains/prefix.
+func EscapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
@@ -0,0 +1,18 @@
+package db
+
+import "testing"
+
+func TestEscapeLike(t *testing.T) {
+	cases := map[string]string{
+		"abc":    "abc",
+		"50%":    "50[%]",
+		"A_1":    "A[_]1",
+		"[x]":    "[[]x]",
+		"%_[all": "[%][_][[]all",
+	}
+	for in, want := range cases {
+		if got := EscapeLike(in); got != want {
+			t.Errorf("EscapeLike(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+// Package migrate installs and upgrades the SQL objects (stored procedures)
+// the connector owns in an ERP database. Installed versions are recorded in
+// the ErpConnectorObjects table together with a hash of the definition SQL
+// Server stored, so objects changed by hand show up as drift.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MetadataTable records the installed version of each object.
+const MetadataTable = "dbo.ErpConnectorObjects"
+
+const metadataTableSQL = `
+IF OBJECT_ID(N'dbo.ErpConnectorObjects', N'U') IS NULL
+CREATE TABLE dbo.ErpConnectorObjects
+(
+	ObjectName     nvarchar(256) NOT NULL PRIMARY KEY,
+	Version        int           NOT NULL,
+	DefinitionHash char(64)      NOT NULL,
+	InstalledAt    datetime2     NOT NULL DEFAULT SYSUTCDATETIME(),
+	InstalledBy    nvarchar(128) NOT NULL DEFAULT SUSER_SNAME()
+);
+`
+
+const recordSQL = `
+MERGE dbo.ErpConnectorObjects AS T
+USING (SELECT @name AS ObjectName) AS S ON T.ObjectName = S.ObjectName
+WHEN MATCHED THEN
+	UPDATE SET Version = @version, DefinitionHash = @hash,
+		InstalledAt = SYSUTCDATETIME(), InstalledBy = SUSER_SNAME()
+WHEN NOT MATCHED THEN
+	INSERT (ObjectName, Version, DefinitionHash) VALUES (@name, @version, @hash);
+`
+
+// Object is one SQL object the connector installs. Script must be a single
+// CREATE OR ALTER batch; bump Version whenever Script changes.
+type Object struct {
+	Name    string // schema-qualified, e.g. dbo.GPRICE_Bulk
+	Version int
+	Script  string
+}
+
+// Object states reported by Check.
+const (
+	StateCurrent   = "current"   // installed at this version, unchanged
+	StateMissing   = "missing"   // not in the database
+	StateUnmanaged = "unmanaged" // present but never recorded (older connector)
+	StateOutdated  = "outdated"  // recorded at an older version
+	StateModified  = "modified"  // definition changed since it was installed
+	StateNewer     = "newer"     // recorded at a newer version than this connector's
+)
+
+// Status is the state of one object in the database.
+type Status struct {
+	Name             string
+	Version          int // version this connector installs
+	InstalledVersion int // 0 when not recorded
+	State            string
+}
+
+// Drift reports whether the object differs from what this connector installs.
+func (s Status) Drift() bool { return s.State != StateCurrent }
+
+// NeedsInstall reports whether Apply (re)installs the object. Newer versions
+// are left alone so an older connector never downgrades them.
+func (s Status) NeedsInstall() bool {
+	return s.State != StateCurrent && s.State != StateNewer
+}
+
+// installed is what the database holds for one object.
+type installed struct {
+	exists   bool
+	recorded bool
+	version  int
+	hash     string // recorded definition hash
+	current  string // hash of the current definition
+}
+
+func state(obj Object, in installed) string {
+	switch {
+	case !in.exists:
+		return StateMissing
+	case !in.recorded:
+		return StateUnmanaged
+	case in.version > obj.Version:
+		return StateNewer
+	case in.version < obj.Version:
+		return StateOutdated
+	case in.hash != in.current:
+		return StateModified
+	default:
+		return StateCurrent
+	}
+}
+
+// Check reports the state of every object without changing the database.
+func Check(ctx context.Context, dbConn *sql.DB, objects []Object) ([]Status, error) {
+	if dbConn == nil {
+		return nil, errors.New("db connection is required")
+	}
+	hasTable, err := metadataExists(ctx, dbConn)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Status, 0, len(objects))
+	for _, obj := range objects {
+		in, err := inspect(ctx, dbConn, obj.Name, hasTable)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", obj.Name, err)
+		}
+		out = append(out, Status{
+			Name:             obj.Name,
+			Version:          obj.Version,
+			InstalledVersion: in.version,
+			State:            state(obj, in),
+		})
+	}
+	return out, nil
+}
+
+// Result is the outcome of Apply. DDL holds the statements that were run, or
+// would be run on a dry run.
+type Result struct {
+	Before  []Status
+	Applied []string
+	DDL     string
+}
+
+// Apply installs every object that NeedsInstall and records its version. With
+// dryRun it only returns the DDL it would run.
+func Apply(ctx context.Context, dbConn *sql.DB, objects []Object, dryRun bool) (Result, error) {
+	before, err := Check(ctx, dbConn, objects)
+	if err != nil {
+		return Result{}, err
+	}
+	res := Result{Before: before, DDL: Script(objects, before)}
+	if dryRun {
+		return res, nil
+	}
+
+	if _, err := dbConn.ExecContext(ctx, metadataTableSQL); err != nil {
+		return res, fmt.Errorf("create %s: %w", MetadataTable, err)
+	}
+	for i, obj := range objects {
+		if !before[i].NeedsInstall() {
+			continue
+		}
+		if err := install(ctx, dbConn, obj); err != nil {
+			return res, fmt.Errorf("%s: %w", obj.Name, err)
+		}
+		res.Applied = append(res.Applied, obj.Name)
+	}
+	return res, nil
+}
+
+// Script renders the DDL Apply runs for the given statuses, as batches
+// separated by GO so it can be reviewed or run in SSMS.
+func Script(objects []Object, statuses []Status) string {
+	var b strings.Builder
+	b.WriteString(strings.TrimSpace(metadataTableSQL))
+	b.WriteString("\nGO\n")
+	for i, obj := range objects {
+		st := statuses[i]
+		if !st.NeedsInstall() {
+			continue
+		}
+		fmt.Fprintf(&b, "\n-- %s: %s (installed %d, connector %d)\n", obj.Name, st.State, st.InstalledVersion, obj.Version)
+		b.WriteString(strings.TrimSpace(obj.Script))
+		b.WriteString("\nGO\n")
+		fmt.Fprintf(&b, "-- then recorded in %s as version %d\n", MetadataTable, obj.Version)
+	}
+	return b.String()
+}
+
+func install(ctx context.Context, dbConn *sql.DB, obj Object) error {
+	tx, err := dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, obj.Script); err != nil {
+		return err
+	}
+	var def sql.NullString
+	if err := tx.QueryRowContext(ctx, `SELECT OBJECT_DEFINITION(OBJECT_ID(@name));`,
+		sql.Named("name", obj.Name)).Scan(&def); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, recordSQL,
+		sql.Named("name", obj.Name),
+		sql.Named("version", obj.Version),
+		sql.Named("hash", definitionHash(def.String))); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func metadataExists(ctx context.Context, dbConn *sql.DB) (bool, error) {
+	var id sql.NullInt64
+	err := dbConn.QueryRowContext(ctx, `SELECT OBJECT_ID(@name, N'U');`, sql.Named("name", MetadataTable)).Scan(&id)
+	return id.Valid, err
+}
+
+func inspect(ctx context.Context, dbConn *sql.DB, name string, hasTable bool) (installed, error) {
+	var in installed
+	var def sql.NullString
+	if err := dbConn.QueryRowContext(ctx, `SELECT OBJECT_DEFINITION(OBJECT_ID(@name));`,
+		sql.Named("name", name)).Scan(&def); err != nil {
+		return in, err
+	}
+	in.exists = def.Valid
+	in.current = definitionHash(def.String)
+	if !hasTable {
+		return in, nil
+	}
+
+	err := dbConn.QueryRowContext(ctx, `
+		SELECT Version, DefinitionHash FROM dbo.ErpConnectorObjects WHERE ObjectName = @name;`,
+		sql.Named("name", name)).Scan(&in.version, &in.hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return in, nil
+	}
+	if err != nil {
+		return in, err
+	}
+	in.recorded = true
+	in.hash = strings.TrimSpace(in.hash)
+	return in, nil
+}
+
+// definitionHash hashes an object definition as stored by SQL Server.
+func definitionHash(def string) string {
+	sum := sha256.Sum256([]byte(def))
+	return hex.EncodeToString(sum[:])
+}
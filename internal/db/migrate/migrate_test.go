@@ -0,0 +1,52 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestState(t *testing.T) {
+	obj := Object{Name: "dbo.P", Version: 2}
+	tests := []struct {
+		name string
+		in   installed
+		want string
+	}{
+		{"missing", installed{}, StateMissing},
+		{"unmanaged", installed{exists: true}, StateUnmanaged},
+		{"outdated", installed{exists: true, recorded: true, version: 1, hash: "a", current: "a"}, StateOutdated},
+		{"newer", installed{exists: true, recorded: true, version: 3, hash: "a", current: "a"}, StateNewer},
+		{"modified", installed{exists: true, recorded: true, version: 2, hash: "a", current: "b"}, StateModified},
+		{"current", installed{exists: true, recorded: true, version: 2, hash: "a", current: "a"}, StateCurrent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := state(obj, tt.in); got != tt.want {
+				t.Errorf("state = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScript_OnlyObjectsToInstall(t *testing.T) {
+	objects := []Object{
+		{Name: "dbo.A", Version: 2, Script: "CREATE OR ALTER PROCEDURE dbo.A AS SELECT 1"},
+		{Name: "dbo.B", Version: 1, Script: "CREATE OR ALTER PROCEDURE dbo.B AS SELECT 2"},
+		{Name: "dbo.C", Version: 1, Script: "CREATE OR ALTER PROCEDURE dbo.C AS SELECT 3"},
+	}
+	statuses := []Status{
+		{Name: "dbo.A", Version: 2, InstalledVersion: 1, State: StateOutdated},
+		{Name: "dbo.B", Version: 1, InstalledVersion: 1, State: StateCurrent},
+		{Name: "dbo.C", Version: 1, InstalledVersion: 4, State: StateNewer},
+	}
+	ddl := Script(objects, statuses)
+	if !strings.Contains(ddl, "CREATE TABLE dbo.ErpConnectorObjects") {
+		t.Error("script must create the metadata table")
+	}
+	if !strings.Contains(ddl, "PROCEDURE dbo.A") || !strings.Contains(ddl, "dbo.A: outdated (installed 1, connector 2)") {
+		t.Errorf("outdated object missing from script:\n%s", ddl)
+	}
+	if strings.Contains(ddl, "PROCEDURE dbo.B") || strings.Contains(ddl, "PROCEDURE dbo.C") {
+		t.Errorf("current and newer objects must not be installed:\n%s", ddl)
+	}
+}
@@ -0,0 +1,133 @@
+package erp
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"erp-connector/internal/config"
+)
+
+// Capability names a feature an ERP adapter may support. The values are
+// reported verbatim by GET /api/capabilities.
+type Capability string
+
+const (
+	CapPriceStock        Capability = "priceStock"
+	CapSendOrder         Capability = "sendOrder"
+	CapCustomerLookup    Capability = "customerLookup"
+	CapItemLookup        Capability = "itemLookup"
+	CapCustomerDocuments Capability = "customerDocuments"
+	CapCreditCheck       Capability = "creditCheck"
+	CapSQL               Capability = "sql"
+)
+
+// AllCapabilities lists every capability in reporting order.
+func AllCapabilities() []Capability {
+	return []Capability{CapPriceStock, CapSendOrder, CapCustomerLookup, CapItemLookup, CapCustomerDocuments, CapCreditCheck, CapSQL}
+}
+
+var (
+	// ErrNotSupported is returned when an adapter lacks a capability.
+	ErrNotSupported = errors.New("not supported by this ERP")
+	// ErrNotFound is returned by lookups for unknown customers or items.
+	ErrNotFound = errors.New("not found")
+	// ErrInvalidRequest wraps request fields an adapter cannot accept.
+	ErrInvalidRequest = errors.New("invalid request")
+	// ErrJobNotQueued is returned when a sendOrder job is changed after it
+	// left the queue (running, done, failed or already cancelled).
+	ErrJobNotQueued = errors.New("job is no longer queued")
+)
+
+// Adapter is one ERP backend. Features are exposed through the capability
+// interfaces below; Capabilities reports which of them are usable with the
+// current configuration, which may be fewer than the interfaces implemented
+// (e.g. sendOrder without a sendOrderDir).
+type Adapter interface {
+	ERP() config.ERPType
+	Capabilities() []Capability
+	// Health checks the ERP is reachable (SQL ping or API probe).
+	Health(ctx context.Context) error
+	// Start launches background workers (order queues). It returns
+	// immediately; workers stop when ctx is cancelled.
+	Start(ctx context.Context)
+}
+
+// PriceStockProvider serves POST /api/priceAndStockHandler.
+type PriceStockProvider interface {
+	FetchPriceAndStock(ctx context.Context, req PriceStockRequest) (PriceStockResult, error)
+}
+
+// OrderSubmitter serves POST /api/sendOrder: it enqueues a validated order
+// and returns the job ID reported to the caller.
+type OrderSubmitter interface {
+	SubmitOrder(req OrderRequest) (string, error)
+}
+
+// OrderPreviewer serves POST /api/sendOrder?dryRun=true: it validates and
+// builds the ERP document for an order without submitting it.
+type OrderPreviewer interface {
+	PreviewOrder(ctx context.Context, req OrderRequest) (OrderPreview, error)
+}
+
+// OrderJobs serves GET /api/sendOrder/{jobId}: the state of a job returned
+// by SubmitOrder.
+type OrderJobs interface {
+	OrderJob(jobID string) (JobResult, bool)
+}
+
+// OrderQueuePosition is implemented by adapters whose queue can report where
+// a job stands; sendOrder returns it with 202 Accepted.
+type OrderQueuePosition interface {
+	QueuePosition(jobID string) (QueuePosition, bool)
+}
+
+// OrderEditor serves DELETE and PUT /api/sendOrder/{jobId}: it cancels a
+// queued job or replaces its order. Both return ErrNotFound for unknown jobs
+// and ErrJobNotQueued once the job has started.
+type OrderEditor interface {
+	CancelOrder(jobID string) (JobResult, error)
+	UpdateOrder(jobID string, req OrderRequest) (JobResult, error)
+}
+
+// DBBoundOrders is implemented by adapters whose order pipeline reads the SQL
+// database. sendOrder then answers DB_UNAVAILABLE while the database is down
+// instead of queueing work that cannot run.
+type DBBoundOrders interface {
+	OrdersNeedDB() bool
+}
+
+// CustomerLookup serves GET /api/customers: it resolves a customer by its
+// ERP key (ErrNotFound when unknown) and searches the customer master.
+type CustomerLookup interface {
+	GetCustomer(ctx context.Context, extID string) (Customer, error)
+	SearchCustomers(ctx context.Context, q CustomerSearch) (CustomerPage, error)
+}
+
+// ItemLookup serves GET /api/items: it resolves an item by SKU (ErrNotFound
+// when unknown) and searches the item master.
+type ItemLookup interface {
+	GetItem(ctx context.Context, sku string) (Item, error)
+	SearchItems(ctx context.Context, q ItemSearch) (ItemPage, error)
+}
+
+// DocumentLookup serves GET /api/customers/{extId}/documents: the customer's
+// orders, delivery notes and invoices with their lines.
+type DocumentLookup interface {
+	CustomerDocuments(ctx context.Context, q DocumentSearch) (DocumentPage, error)
+}
+
+// CreditProvider serves GET /api/customers/{extId}/credit and the sendOrder
+// credit check: the customer's limit and current exposure (ErrNotFound when
+// unknown).
+type CreditProvider interface {
+	CustomerCredit(ctx context.Context, extID string) (CreditStatus, error)
+}
+
+// Supports reports whether a (possibly nil) adapter advertises c.
+func Supports(a Adapter, c Capability) bool {
+	if a == nil {
+		return false
+	}
+	return slices.Contains(a.Capabilities(), c)
+}
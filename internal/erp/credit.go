@@ -0,0 +1,80 @@
+package erp
+
+import "strings"
+
+// CreditStatus is a customer's credit exposure (obligo) as kept by the ERP.
+// Amounts are in the company's local currency.
+type CreditStatus struct {
+	ExtID string
+	// CreditLimit is nil when the ERP sets no limit.
+	CreditLimit *float64
+	// Balance is the open ledger balance, nil when the ERP keeps none.
+	Balance *float64
+	// OpenDeliveries is the value of delivery notes not yet invoiced.
+	OpenDeliveries float64
+	// OpenOrders is the value of open sales order lines.
+	OpenOrders float64
+	// PendingOrders is the total of orders the connector accepted but has
+	// not created in the ERP yet (still in its order queue).
+	PendingOrders float64
+	Blocked       bool
+}
+
+// PendingOrderTotals is implemented by credit providers whose CreditStatus
+// counts PendingOrders. PendingJobTotal returns the total of a queued job of
+// extID, so checking an edited job does not count its old total next to the
+// new one.
+type PendingOrderTotals interface {
+	PendingJobTotal(jobID, extID string) (float64, bool)
+}
+
+// SameExtID compares customer keys the way SQL Server's default collation
+// does: ignoring case and surrounding spaces.
+func SameExtID(a, b string) bool {
+	return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+}
+
+// Exposure is the customer's obligo: balance plus open deliveries and orders,
+// including the orders still pending in the connector.
+func (s CreditStatus) Exposure() float64 {
+	exposure := s.OpenDeliveries + s.OpenOrders + s.PendingOrders
+	if s.Balance != nil {
+		exposure += *s.Balance
+	}
+	return exposure
+}
+
+// Available is the credit left under the limit, nil when there is no limit.
+func (s CreditStatus) Available() *float64 {
+	if s.CreditLimit == nil {
+		return nil
+	}
+	v := *s.CreditLimit - s.Exposure()
+	return &v
+}
+
+// CreditCheck is the outcome of checking one order against a CreditStatus.
+type CreditCheck struct {
+	Status     CreditStatus
+	OrderTotal float64
+	// Exposure is the obligo including this order.
+	Exposure float64
+	// Exceeded reports the exposure is over the credit limit.
+	Exceeded bool
+}
+
+// OK reports the order passes: the customer is not blocked and stays within
+// the limit.
+func (c CreditCheck) OK() bool {
+	return !c.Status.Blocked && !c.Exceeded
+}
+
+// CheckCredit adds orderTotal to the customer's exposure and compares it with
+// the credit limit. Customers without a limit never exceed it.
+func CheckCredit(s CreditStatus, orderTotal float64) CreditCheck {
+	c := CreditCheck{Status: s, OrderTotal: orderTotal, Exposure: s.Exposure() + orderTotal}
+	if s.CreditLimit != nil {
+		c.Exceeded = c.Exposure > *s.CreditLimit
+	}
+	return c
+}
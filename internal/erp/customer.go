@@ -0,0 +1,70 @@
+package erp
+
+import "strings"
+
+// Customer is an ERP customer account normalised across ERPs. Fields an ERP
+// does not keep are left empty; ERP-specific columns go to Details.
+type Customer struct {
+	ExtID        string
+	Name         string
+	Addresses    []CustomerAddress
+	Phones       []string
+	Email        string
+	TaxID        string
+	Agent        string
+	PriceList    string
+	PaymentTerms string
+	Currency     string
+	// CreditLimit is nil when the ERP sets no limit.
+	CreditLimit *float64
+	Balance     *float64
+	Blocked     bool
+	Details     map[string]any
+}
+
+// Address types used in CustomerAddress.Type.
+const (
+	AddressBilling  = "billing"
+	AddressShipping = "shipping"
+)
+
+type CustomerAddress struct {
+	Type    string
+	Street  string
+	City    string
+	ZipCode string
+	Country string
+}
+
+// CustomerSearch is one page of a customer master search. Query matches the
+// customer key prefix, the name or a phone number; empty lists everyone.
+type CustomerSearch struct {
+	Query    string
+	Page     int // 1-based
+	PageSize int
+}
+
+// Offset is the number of rows before the requested page.
+func (q CustomerSearch) Offset() int {
+	if q.Page < 1 {
+		return 0
+	}
+	return (q.Page - 1) * q.PageSize
+}
+
+type CustomerPage struct {
+	Customers []Customer
+	Page      int
+	PageSize  int
+	Total     int
+}
+
+// AppendNonEmpty appends the trimmed non-empty values to dst.
+func AppendNonEmpty(dst []string, values ...string) []string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			dst = append(dst, v)
+		}
+	}
+	return dst
+}
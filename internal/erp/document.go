@@ -0,0 +1,96 @@
+package erp
+
+import (
+	"slices"
+	"time"
+)
+
+// Document kinds reported by DocumentLookup.
+const (
+	DocOrder        = "order"
+	DocDeliveryNote = "deliveryNote"
+	DocInvoice      = "invoice"
+)
+
+// DocumentKinds lists every document kind in reporting order.
+func DocumentKinds() []string {
+	return []string{DocOrder, DocDeliveryNote, DocInvoice}
+}
+
+// Document statuses.
+const (
+	DocStatusOpen      = "open"
+	DocStatusClosed    = "closed"
+	DocStatusCancelled = "cancelled"
+)
+
+// Document is a customer sales document normalised across ERPs.
+type Document struct {
+	Kind     string
+	Number   string
+	Date     *time.Time
+	DueDate  *time.Time
+	Status   string
+	Currency string
+	// NetTotal is the sum of line totals after line discounts, before the
+	// document discount and VAT.
+	NetTotal float64
+	// Total and VAT are the ERP's document totals, nil when not kept.
+	Total *float64
+	VAT   *float64
+	// Reference is the customer reference on the document.
+	Reference string
+	// OrderNumber is the connector order number (the /api/sendOrder
+	// orderNumber) the document was created from, empty when it was not.
+	OrderNumber string
+	Lines       []DocumentLine
+	Details     map[string]any
+}
+
+type DocumentLine struct {
+	LineNum      int
+	SKU          string
+	Name         string
+	Quantity     float64
+	OpenQuantity float64
+	Price        float64
+	DiscountPct  float64
+	Total        float64
+	Status       string
+}
+
+// DocumentSearch selects one page of a customer's documents, newest first.
+type DocumentSearch struct {
+	ExtID    string
+	Kinds    []string // empty: every kind
+	OpenOnly bool
+	From     time.Time // zero: no lower bound
+	To       time.Time // zero: no upper bound; inclusive date
+	Page     int       // 1-based
+	PageSize int
+}
+
+// Offset is the number of documents before the requested page.
+func (q DocumentSearch) Offset() int {
+	if q.Page < 1 {
+		return 0
+	}
+	return (q.Page - 1) * q.PageSize
+}
+
+// WantsKind reports whether kind is selected.
+func (q DocumentSearch) WantsKind(kind string) bool {
+	return len(q.Kinds) == 0 || slices.Contains(q.Kinds, kind)
+}
+
+type DocumentPage struct {
+	Documents []Document
+	Page      int
+	PageSize  int
+	Total     int
+}
+
+// LineTotal is quantity × price after a percentage discount.
+func LineTotal(quantity, price, discountPct float64) float64 {
+	return quantity * price * (1 - discountPct/100)
+}
@@ -0,0 +1,222 @@
+package hasavshevet
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+
+	"erp-connector/internal/config"
+	"erp-connector/internal/db"
+	"erp-connector/internal/erp"
+	"erp-connector/internal/logger"
+)
+
+func init() {
+	erp.Register(config.ERPHasavshevet, erp.Registration{UsesSQL: true, New: newAdapter, Objects: DBObjects})
+}
+
+// Adapter exposes Hasavshevet through the erp.Adapter capability interfaces.
+type Adapter struct {
+	db    *db.Handle
+	cfg   config.Config
+	queue *OrderQueue
+	log   logger.LoggerService
+}
+
+// NewAdapter wraps an existing queue. queue may be nil when orders are not
+// needed (sendOrder is then not advertised).
+func NewAdapter(dbHandle *db.Handle, cfg config.Config, queue *OrderQueue, log logger.LoggerService) *Adapter {
+	return &Adapter{db: dbHandle, cfg: cfg, queue: queue, log: log}
+}
+
+// newAdapter builds the send-order pipeline. The order number file lives next
+// to the IMOVEIN files so the directory is self-contained.
+func newAdapter(d erp.Deps) (erp.Adapter, error) {
+	numStore := NewOrderNumberStore(filepath.Join(d.Config.SendOrderDir, "lastOrderNumber.json"))
+	sender := NewSender(d.DB, d.Config, numStore, d.Logger)
+	queue := NewOrderQueue(sender, d.Logger, d.Hooks...)
+	return NewAdapter(d.DB, d.Config, queue, d.Logger), nil
+}
+
+func (a *Adapter) ERP() config.ERPType { return config.ERPHasavshevet }
+
+func (a *Adapter) Capabilities() []erp.Capability {
+	caps := []erp.Capability{erp.CapPriceStock, erp.CapCustomerLookup, erp.CapItemLookup, erp.CapCustomerDocuments, erp.CapCreditCheck, erp.CapSQL}
+	if a.queue != nil && strings.TrimSpace(a.cfg.SendOrderDir) != "" {
+		caps = append(caps, erp.CapSendOrder)
+	}
+	return caps
+}
+
+func (a *Adapter) Health(ctx context.Context) error {
+	return a.db.Ping(ctx)
+}
+
+// Start runs the order queue once the database is first reachable, so queued
+// jobs never fail on a database that is still starting up.
+func (a *Adapter) Start(ctx context.Context) {
+	if a.queue == nil {
+		return
+	}
+	a.db.OnReady(func(*sql.DB) {
+		a.queue.Start(ctx)
+		if a.log != nil {
+			a.log.Info("order queue started")
+		}
+	})
+}
+
+func (a *Adapter) FetchPriceAndStock(ctx context.Context, req erp.PriceStockRequest) (erp.PriceStockResult, error) {
+	dbConn := a.db.DB()
+	if dbConn == nil {
+		return erp.PriceStockResult{}, db.ErrUnavailable
+	}
+	// Hasavshevet prices come from GPRICE per customer/document; price lists
+	// in the request do not apply.
+	req.PriceList = nil
+	return FetchPriceAndStock(ctx, dbConn, a.cfg, req)
+}
+
+func (a *Adapter) GetCustomer(ctx context.Context, extID string) (erp.Customer, error) {
+	dbConn := a.db.DB()
+	if dbConn == nil {
+		return erp.Customer{}, db.ErrUnavailable
+	}
+	return GetCustomer(ctx, dbConn, extID)
+}
+
+func (a *Adapter) SearchCustomers(ctx context.Context, q erp.CustomerSearch) (erp.CustomerPage, error) {
+	dbConn := a.db.DB()
+	if dbConn == nil {
+		return erp.CustomerPage{}, db.ErrUnavailable
+	}
+	return SearchCustomers(ctx, dbConn, q)
+}
+
+func (a *Adapter) GetItem(ctx context.Context, sku string) (erp.Item, error) {
+	dbConn := a.db.DB()
+	if dbConn == nil {
+		return erp.Item{}, db.ErrUnavailable
+	}
+	return GetItem(ctx, dbConn, sku)
+}
+
+func (a *Adapter) SearchItems(ctx context.Context, q erp.ItemSearch) (erp.ItemPage, error) {
+	dbConn := a.db.DB()
+	if dbConn == nil {
+		return erp.ItemPage{}, db.ErrUnavailable
+	}
+	return SearchItems(ctx, dbConn, q)
+}
+
+func (a *Adapter) CustomerDocuments(ctx context.Context, q erp.DocumentSearch) (erp.DocumentPage, error) {
+	dbConn := a.db.DB()
+	if dbConn == nil {
+		return erp.DocumentPage{}, db.ErrUnavailable
+	}
+	return CustomerDocuments(ctx, dbConn, a.cfg, q)
+}
+
+// CustomerCredit counts the customer's queued sendOrder jobs next to the open
+// documents in Stock.
+func (a *Adapter) CustomerCredit(ctx context.Context, extID string) (erp.CreditStatus, error) {
+	dbConn := a.db.DB()
+	if dbConn == nil {
+		return erp.CreditStatus{}, db.ErrUnavailable
+	}
+	s, err := CustomerCredit(ctx, dbConn, a.cfg, extID)
+	if err != nil || a.queue == nil {
+		return s, err
+	}
+	s.PendingOrders = a.queue.PendingTotal(s.ExtID)
+	return s, nil
+}
+
+// PendingJobTotal returns the total of a queued sendOrder job of extID.
+func (a *Adapter) PendingJobTotal(jobID, extID string) (float64, bool) {
+	if a.queue == nil {
+		return 0, false
+	}
+	return a.queue.PendingJobTotal(jobID, extID)
+}
+
+func (a *Adapter) SubmitOrder(req erp.OrderRequest) (string, error) {
+	if a.queue == nil {
+		return "", erp.ErrNotSupported
+	}
+	return a.queue.Submit(req)
+}
+
+// PreviewOrder builds the IMOVEIN records for req without sending it.
+func (a *Adapter) PreviewOrder(ctx context.Context, req erp.OrderRequest) (erp.OrderPreview, error) {
+	if a.queue == nil || a.queue.sender == nil {
+		return erp.OrderPreview{}, erp.ErrNotSupported
+	}
+	return a.queue.sender.PreviewOrder(ctx, req)
+}
+
+// OrdersNeedDB reports true: the sender resolves the customer account in the
+// company database before writing IMOVEIN.
+func (a *Adapter) OrdersNeedDB() bool { return true }
+
+// Queue returns the underlying single-worker queue.
+func (a *Adapter) Queue() *OrderQueue { return a.queue }
+
+// OrderJob reports the state of a sendOrder job.
+func (a *Adapter) OrderJob(jobID string) (erp.JobResult, bool) {
+	if a.queue == nil {
+		return erp.JobResult{}, false
+	}
+	r, ok := a.queue.Status(jobID)
+	if !ok {
+		return erp.JobResult{}, false
+	}
+	return erpJobResult(r), true
+}
+
+// QueuePosition reports where a waiting sendOrder job stands.
+func (a *Adapter) QueuePosition(jobID string) (erp.QueuePosition, bool) {
+	if a.queue == nil {
+		return erp.QueuePosition{}, false
+	}
+	return a.queue.Position(jobID)
+}
+
+// CancelOrder cancels a queued sendOrder job and gives up its order number.
+func (a *Adapter) CancelOrder(jobID string) (erp.JobResult, error) {
+	if a.queue == nil {
+		return erp.JobResult{}, erp.ErrNotSupported
+	}
+	r, err := a.queue.Cancel(jobID)
+	if err != nil {
+		return erp.JobResult{}, err
+	}
+	return erpJobResult(r), nil
+}
+
+// UpdateOrder replaces the order of a queued sendOrder job.
+func (a *Adapter) UpdateOrder(jobID string, req erp.OrderRequest) (erp.JobResult, error) {
+	if a.queue == nil {
+		return erp.JobResult{}, erp.ErrNotSupported
+	}
+	r, err := a.queue.Update(jobID, req)
+	if err != nil {
+		return erp.JobResult{}, err
+	}
+	return erpJobResult(r), nil
+}
+
+func erpJobResult(r *JobResult) erp.JobResult {
+	return erp.JobResult{
+		ID:             r.ID,
+		Status:         erp.JobStatus(r.Status),
+		OrderNumber:    r.OrderNumber,
+		DocumentNumber: r.DocumentNumber,
+		Entity:         r.Entity,
+		Err:            r.Err,
+		Errors:         r.Errors,
+		VATRate:        r.VATRate,
+		VATExempt:      r.VATExempt,
+	}
+}
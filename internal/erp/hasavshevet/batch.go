@@ -0,0 +1,349 @@
+package hasavshevet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"erp-connector/internal/db"
+)
+
+// defaultBatchMaxOrders caps a batch when hasavshevet.batchMaxOrders is not
+// set.
+const defaultBatchMaxOrders = 20
+
+// batchOrder is one order of an importer run.
+type batchOrder struct {
+	req      OrderRequest
+	orderNum int64
+}
+
+// batchOutcome is one order's result from processBatch.
+type batchOutcome struct {
+	result *OrderResult
+	err    error
+}
+
+// batchDocument is an order that was built and is part of the importer run.
+type batchDocument struct {
+	index      int // position in the batch
+	orderNum   int64
+	req        OrderRequest
+	prepared   preparedOrder
+	historyDir string
+	files      []string
+}
+
+// batchWindow returns how long the queue gathers orders into one importer
+// run; zero disables batching.
+func (s *Sender) batchWindow() time.Duration {
+	if s == nil || s.cfg.Hasavshevet.BatchWindowSeconds <= 0 {
+		return 0
+	}
+	return time.Duration(s.cfg.Hasavshevet.BatchWindowSeconds) * time.Second
+}
+
+// batchMaxOrders returns the most orders one importer run takes.
+func (s *Sender) batchMaxOrders() int {
+	if s == nil || s.cfg.Hasavshevet.BatchMaxOrders <= 0 {
+		return defaultBatchMaxOrders
+	}
+	return s.cfg.Hasavshevet.BatchMaxOrders
+}
+
+// processBatch executes the send-order flow for orders with one IMOVEIN
+// pair and one importer run. Each order is its own document (line3/line8
+// carry its order number) and keeps its own history copy; orders that fail
+// validation or lookups are left out of the run. The outcomes are in the
+// order of orders.
+func (s *Sender) processBatch(ctx context.Context, orders []batchOrder) []batchOutcome {
+	out := make([]batchOutcome, len(orders))
+	failAll := func(docs []batchDocument, err error) []batchOutcome {
+		for _, d := range docs {
+			out[d.index].err = err
+		}
+		return out
+	}
+
+	if strings.TrimSpace(s.cfg.SendOrderDir) == "" {
+		return failAll(allDocuments(orders), errors.New("sendOrderDir is not configured"))
+	}
+	// DB name always comes from the connector config.
+	dbName := strings.TrimSpace(s.cfg.DB.Database)
+	if dbName == "" {
+		return failAll(allDocuments(orders), errors.New("database is not configured (set db.database in config)"))
+	}
+
+	// 1-7. Validate, number, look up and build every order, and write its
+	// history copy.
+	var docs []batchDocument
+	for i, o := range orders {
+		doc, err := s.prepareBatchDocument(ctx, dbName, o)
+		if err != nil {
+			out[i].err = err
+			continue
+		}
+		doc.index = i
+		docs = append(docs, doc)
+	}
+	if len(docs) == 0 {
+		return out
+	}
+
+	// 8. Write the active import files (read by has.exe): the records of all
+	// documents, one after another. The single-worker queue prevents
+	// collisions.
+	dir := s.cfg.SendOrderDir
+	var docBytes bytes.Buffer
+	for _, d := range docs {
+		b, _ := generateDOC(d.prepared.hdr, d.prepared.moves)
+		docBytes.Write(b)
+	}
+	active := []string{filepath.Join(dir, "IMOVEIN.doc"), filepath.Join(dir, "IMOVEIN.prm")}
+	for i, data := range [][]byte{docBytes.Bytes(), generatePRM()} {
+		if err := os.WriteFile(active[i], data, 0o644); err != nil {
+			return failAll(docs, fmt.Errorf("write %s: %w", active[i], err))
+		}
+	}
+
+	label := batchLabel(docs)
+	if len(docs) > 1 {
+		s.log.Info(fmt.Sprintf("importing batch %s records=%d", label, bytes.Count(docBytes.Bytes(), []byte("\n"))))
+	}
+
+	// 9. Execute Hasavshevet importer once for the whole batch.
+	ran, exitCode, output, runStart := s.runImport(ctx, dir, label)
+
+	// 10. Collect the records the importer rejected from its error files and
+	// output, mapped back to orders, order lines and IMOVEIN fields.
+	var (
+		perOrder [][]ImportIssue
+		shared   []ImportIssue
+	)
+	if ran && importerSupported {
+		combined := OrderRequest{}
+		historyDirs := make([]string, len(docs))
+		lineCounts := make([]int, len(docs))
+		for i, d := range docs {
+			combined.Details = append(combined.Details, d.req.Details...)
+			historyDirs[i] = d.historyDir
+			lineCounts[i] = len(d.req.Details)
+		}
+		issues := s.collectImportErrors(combined, output, historyDirs, runStart)
+		perOrder, shared = splitBatchIssues(issues, lineCounts)
+		for i, d := range docs {
+			for _, issue := range perOrder[i] {
+				s.log.Warn(fmt.Sprintf("importer error orderNumber=%d %s", d.orderNum, issue))
+			}
+		}
+		for _, issue := range shared {
+			s.log.Warn(fmt.Sprintf("importer error %s %s", label, issue))
+		}
+	} else {
+		perOrder = make([][]ImportIssue, len(docs))
+	}
+
+	// 11. Verify the importer created each document (Stock.Asmahta2 =
+	// orderNum). The orders share one window.
+	window := s.importVerifyWindow()
+	verify := ran && importerSupported && window > 0
+	deadline := time.Now().Add(window)
+	for i, d := range docs {
+		// Errors not tied to a record (header or file problems) go to every
+		// order they may have affected.
+		issues := perOrder[i]
+		var found importedDocument
+		if verify {
+			// The importer has run: when the document cannot be looked up
+			// the order is unverified, not failed.
+			conn := s.db.DB()
+			if conn == nil {
+				out[d.index].err = &UnverifiedImportError{OrderNumber: d.orderNum, ExitCode: exitCode, Output: trimOutput(output), Issues: append(issues, shared...), Err: db.ErrUnavailable}
+				continue
+			}
+			doc, ok, err := waitForDocument(ctx, func(ctx context.Context) (importedDocument, bool, error) {
+				return findImportedDocument(ctx, conn, d.orderNum)
+			}, max(time.Until(deadline), 0), importVerifyInterval)
+			if err != nil {
+				out[d.index].err = &UnverifiedImportError{OrderNumber: d.orderNum, ExitCode: exitCode, Output: trimOutput(output), Issues: append(issues, shared...), Err: err}
+				continue
+			}
+			if !ok {
+				out[d.index].err = &ImportError{OrderNumber: d.orderNum, ExitCode: exitCode, Output: trimOutput(output), Window: window, Issues: append(issues, shared...)}
+				continue
+			}
+			found = doc
+			s.log.Info(fmt.Sprintf("import verified orderNumber=%d stockId=%d docNumber=%s", d.orderNum, doc.ID, doc.Number))
+		} else if issues = append(issues, shared...); len(issues) > 0 || exitCode != 0 {
+			// Without verification the reported errors and the exit code
+			// are the only evidence the import failed.
+			out[d.index].err = &ImportError{OrderNumber: d.orderNum, ExitCode: exitCode, Output: trimOutput(output), Issues: issues}
+			continue
+		}
+
+		writtenFiles := append(append([]string{}, active...), d.files...)
+		s.log.Success(fmt.Sprintf("order complete orderNumber=%d files=%v", d.orderNum, writtenFiles))
+		out[d.index].result = orderResult(d, writtenFiles, found)
+	}
+	return out
+}
+
+// prepareBatchDocument runs the per-order steps before the importer:
+// validation, order number, lookups, the IMOVEIN records and the history
+// copy of them.
+func (s *Sender) prepareBatchDocument(ctx context.Context, dbName string, o batchOrder) (batchDocument, error) {
+	// 1. Pre-flight validation (business rules + Hasavshevet mandatory field spec)
+	if err := validateOrderRequest(o.req); err != nil {
+		return batchDocument{}, err
+	}
+
+	// 2. Concurrency-safe order number (reserved at queue submit when possible).
+	orderNum := o.orderNum
+	if orderNum == 0 {
+		var err error
+		if orderNum, err = s.nextOrderNumber(); err != nil {
+			return batchDocument{}, err
+		}
+	}
+
+	s.log.Info(fmt.Sprintf("processing order orderNumber=%d historyId=%s userExtId=%s dbName=%s",
+		orderNum, o.req.HistoryID, o.req.UserExtID, dbName))
+
+	// 3-6. Account, currency rate, VAT and the IMOVEIN records
+	prepared, err := s.prepareOrder(ctx, dbName, o.req, orderNum)
+	if err != nil {
+		return batchDocument{}, err
+	}
+	docBytes, err := generateDOC(prepared.hdr, prepared.moves)
+	if err != nil {
+		return batchDocument{}, fmt.Errorf("generate DOC: %w", err)
+	}
+
+	// 7. History copies: permanent audit trail per order, holding only this
+	// order's records so it can be inspected or re-imported on its own.
+	historyDir := filepath.Join(s.cfg.SendOrderDir, "history", fmt.Sprintf("%d", orderNum))
+	if err := os.MkdirAll(historyDir, 0o755); err != nil {
+		return batchDocument{}, fmt.Errorf("create history dir: %w", err)
+	}
+	files := []struct {
+		path string
+		data []byte
+	}{
+		{filepath.Join(historyDir, fmt.Sprintf("IMOVEIN_%d.doc", orderNum)), docBytes},
+		{filepath.Join(historyDir, fmt.Sprintf("IMOVEIN_%d.prm", orderNum)), generatePRM()},
+	}
+	doc := batchDocument{orderNum: orderNum, req: o.req, prepared: prepared, historyDir: historyDir}
+	for _, f := range files {
+		if err := os.WriteFile(f.path, f.data, 0o644); err != nil {
+			return batchDocument{}, fmt.Errorf("write %s: %w", f.path, err)
+		}
+		doc.files = append(doc.files, f.path)
+	}
+	return doc, nil
+}
+
+// runImport executes the Hasavshevet importer (Windows only; no-op on other
+// platforms). HasBatFile (Masofon-generated BAT launcher) takes precedence
+// over HasExePath. label names the order(s) in the log.
+func (s *Sender) runImport(ctx context.Context, dir, label string) (ran bool, exitCode int, output string, start time.Time) {
+	start = time.Now()
+	switch {
+	case strings.TrimSpace(s.cfg.HasBatFile) != "":
+		var execErr error
+		exitCode, output, execErr = runBatFile(ctx, s.cfg.HasBatFile)
+		ran = true
+		s.log.Info(fmt.Sprintf("digi.bat exit=%d durationMs=%d output=%q %s",
+			exitCode, time.Since(start).Milliseconds(), output, label))
+		if execErr != nil || exitCode != 0 {
+			s.log.Error(fmt.Sprintf("digi.bat failed %s exit=%d", label, exitCode), execErr)
+		}
+	case strings.TrimSpace(s.cfg.HasExePath) != "":
+		var execErr error
+		exitCode, output, execErr = runImporter(ctx, s.cfg.HasExePath, s.cfg.HasParamFile, dir)
+		ran = true
+		s.log.Info(fmt.Sprintf("has.exe exit=%d durationMs=%d output=%q %s",
+			exitCode, time.Since(start).Milliseconds(), output, label))
+		if execErr != nil {
+			// Logged here; the import verification decides whether the
+			// orders failed.
+			s.log.Error(fmt.Sprintf("has.exe failed %s", label), execErr)
+		}
+	}
+	return ran, exitCode, output, start
+}
+
+// splitBatchIssues assigns importer issues to the orders of a batch. Record
+// numbers count across the combined IMOVEIN.doc, in which order i has
+// lineCounts[i] records; they are rewritten to the order's own line
+// numbers. Issues without a record are returned as shared.
+func splitBatchIssues(issues []ImportIssue, lineCounts []int) (perOrder [][]ImportIssue, shared []ImportIssue) {
+	perOrder = make([][]ImportIssue, len(lineCounts))
+	for _, issue := range issues {
+		line := issue.Line
+		assigned := false
+		for i, n := range lineCounts {
+			if line >= 1 && line <= n {
+				issue.Line = line
+				perOrder[i] = append(perOrder[i], issue)
+				assigned = true
+				break
+			}
+			line -= n
+		}
+		if !assigned {
+			shared = append(shared, issue)
+		}
+	}
+	return perOrder, shared
+}
+
+// batchLabel names the orders of a run for log lines.
+func batchLabel(docs []batchDocument) string {
+	if len(docs) == 1 {
+		return fmt.Sprintf("orderNumber=%d", docs[0].orderNum)
+	}
+	nums := make([]string, len(docs))
+	for i, d := range docs {
+		nums[i] = fmt.Sprintf("%d", d.orderNum)
+	}
+	return "orderNumbers=" + strings.Join(nums, ",")
+}
+
+// allDocuments indexes every order of a batch, for failures before any of
+// them is built.
+func allDocuments(orders []batchOrder) []batchDocument {
+	docs := make([]batchDocument, len(orders))
+	for i := range orders {
+		docs[i].index = i
+	}
+	return docs
+}
+
+// orderResult is the OrderResult of an imported document.
+func orderResult(d batchDocument, writtenFiles []string, found importedDocument) *OrderResult {
+	account, vat := d.prepared.account, d.prepared.vat
+	vatRate := vat.Rate
+	result := &OrderResult{
+		OrderNumber:  d.orderNum,
+		WrittenFiles: writtenFiles,
+		VATRate:      &vatRate,
+		VATExempt:    vat.Exempt,
+		Account: AccountInfo{
+			AccountKey: account.AccountKey,
+			FullName:   account.FullName,
+			Address:    account.Address,
+			City:       account.City,
+			Phone:      account.Phone,
+		},
+	}
+	if found.ID != 0 {
+		result.DocumentNumber = found.Number
+		result.Entity = fmt.Sprintf("Stock(%d)", found.ID)
+	}
+	return result
+}
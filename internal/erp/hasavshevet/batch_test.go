@@ -0,0 +1,113 @@
+package hasavshevet
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"erp-connector/internal/config"
+	"erp-connector/internal/db"
+	"erp-connector/internal/logger"
+)
+
+func TestSplitBatchIssues(t *testing.T) {
+	issues := []ImportIssue{
+		{Line: 2, SKU: "A-2", Message: "first order, line 2"},
+		{Line: 3, SKU: "B-1", Message: "second order, line 1"},
+		{Line: 6, SKU: "C-1", Message: "third order, line 1"},
+		{Message: "header error"},
+		{Line: 9, Message: "past the file"},
+	}
+	perOrder, shared := splitBatchIssues(issues, []int{2, 3, 1})
+	if len(perOrder[0]) != 1 || perOrder[0][0].Line != 2 {
+		t.Errorf("order 1 = %+v", perOrder[0])
+	}
+	if len(perOrder[1]) != 1 || perOrder[1][0].Line != 1 || perOrder[1][0].SKU != "B-1" {
+		t.Errorf("order 2 = %+v", perOrder[1])
+	}
+	if len(perOrder[2]) != 1 || perOrder[2][0].Line != 1 || perOrder[2][0].SKU != "C-1" {
+		t.Errorf("order 3 = %+v", perOrder[2])
+	}
+	if len(shared) != 2 {
+		t.Errorf("shared = %+v", shared)
+	}
+}
+
+// TestProcessBatch_PerOrderFailures checks that each order of a batch gets
+// its own outcome and that nothing is imported when no order could be built.
+func TestProcessBatch_PerOrderFailures(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.Config{SendOrderDir: dir}
+	cfg.DB.Database = "DEMO"
+	s := NewSender(nil, cfg, nil, logger.NewStderr())
+
+	invalid := mappingOrder()
+	invalid.Details[0].Unit = "carton"
+	out := s.processBatch(context.Background(), []batchOrder{
+		{req: invalid, orderNum: 1},
+		{req: mappingOrder(), orderNum: 2},
+	})
+	if len(out) != 2 {
+		t.Fatalf("outcomes = %d, want 2", len(out))
+	}
+	if out[0].err == nil || !strings.Contains(out[0].err.Error(), "unit") {
+		t.Errorf("order 1 err = %v, want the validation error", out[0].err)
+	}
+	if !errors.Is(out[1].err, db.ErrUnavailable) {
+		t.Errorf("order 2 err = %v, want its own account lookup error", out[1].err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "IMOVEIN.doc")); !os.IsNotExist(err) {
+		t.Errorf("IMOVEIN.doc written without any built order: %v", err)
+	}
+}
+
+func TestOrderQueueGather(t *testing.T) {
+	newQueue := func(window int) *OrderQueue {
+		cfg := config.Config{}
+		cfg.Hasavshevet.BatchWindowSeconds = window
+		cfg.Hasavshevet.BatchMaxOrders = 3
+		return NewOrderQueue(NewSender(nil, cfg, nil, logger.NewStderr()), logger.NewStderr())
+	}
+	ids := func(jobs []*orderJob) []string {
+		var out []string
+		for _, j := range jobs {
+			out = append(out, j.id)
+		}
+		return out
+	}
+	ctx := context.Background()
+
+	// The batch is full before the window ends.
+	q := newQueue(60)
+	for _, id := range []string{"2", "3", "4"} {
+		q.waiting.push(&orderJob{id: id, lane: laneNormal})
+	}
+	if got := ids(q.gather(ctx, &orderJob{id: "1", lane: laneNormal})); !slices.Equal(got, []string{"1", "2", "3"}) {
+		t.Errorf("gather = %v", got)
+	}
+
+	// A stopped queue ends the window.
+	q.Stop()
+	if got := ids(q.gather(ctx, &orderJob{id: "5", lane: laneNormal})); !slices.Equal(got, []string{"5", "4"}) {
+		t.Errorf("gather after Stop = %v", got)
+	}
+
+	// A waiting urgent order ends the window and goes first.
+	q = newQueue(60)
+	q.waiting.push(&orderJob{id: "2", lane: laneBulk})
+	q.waiting.push(&orderJob{id: "3", lane: laneUrgent})
+	if got := ids(q.gather(ctx, &orderJob{id: "1", lane: laneNormal})); !slices.Equal(got, []string{"1", "3", "2"}) {
+		t.Errorf("gather with urgent order = %v", got)
+	}
+
+	// Without a window each job runs alone.
+	q = newQueue(0)
+	q.waiting.push(&orderJob{id: "2", lane: laneNormal})
+	if got := ids(q.gather(ctx, &orderJob{id: "1", lane: laneNormal})); len(got) != 1 {
+		t.Errorf("gather without window = %v", got)
+	}
+}
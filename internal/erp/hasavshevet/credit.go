@@ -0,0 +1,123 @@
+package hasavshevet
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"erp-connector/internal/config"
+	"erp-connector/internal/erp"
+)
+
+// transMoveDebit is TransMoves.DebitCredit of a debit (חובה) movement.
+const transMoveDebit = 1
+
+// CustomerCredit reads the customer's MaxCredit and Locked flag from Accounts,
+// its open ledger balance from the journal (TransMoves) and values its open
+// orders and delivery notes from Stock / StockMoves. The balance is debits
+// minus credits, so invoiced amounts the customer has not paid count once.
+// Lines are valued before VAT and the document discount: orders at
+// SupplyQuantity, delivery notes at their full quantity.
+func CustomerCredit(ctx context.Context, dbConn *sql.DB, cfg config.Config, extID string) (erp.CreditStatus, error) {
+	var (
+		key       string
+		maxCredit sql.NullFloat64
+		locked    sql.NullInt64
+	)
+	err := dbConn.QueryRowContext(ctx, `
+		SELECT A.AccountKey, A.MaxCredit, A.Locked
+		FROM dbo.Accounts AS A WITH (NOLOCK)
+		WHERE A.AccountKey = @key;`,
+		sql.Named("key", strings.TrimSpace(extID)),
+	).Scan(&key, &maxCredit, &locked)
+	if errors.Is(err, sql.ErrNoRows) {
+		return erp.CreditStatus{}, erp.ErrNotFound
+	}
+	if err != nil {
+		return erp.CreditStatus{}, err
+	}
+
+	s := erp.CreditStatus{ExtID: strings.TrimSpace(key), Blocked: locked.Int64 != 0}
+	if maxCredit.Valid && maxCredit.Float64 != 0 {
+		limit := maxCredit.Float64
+		s.CreditLimit = &limit
+	}
+
+	var balance sql.NullFloat64
+	err = dbConn.QueryRowContext(ctx, `
+		SELECT SUM(CASE WHEN M.DebitCredit = @debit THEN M.SuF ELSE -M.SuF END) AS Balance
+		FROM dbo.TransMoves AS M WITH (NOLOCK)
+		WHERE M.AccountKey = @key;`,
+		sql.Named("debit", transMoveDebit),
+		sql.Named("key", s.ExtID),
+	).Scan(&balance)
+	if err != nil {
+		return erp.CreditStatus{}, err
+	}
+	s.Balance = &balance.Float64
+
+	kinds := documentKinds(cfg.Hasavshevet)
+	where, args := openDocumentFilter(s.ExtID, kinds)
+	if where == "" {
+		return s, nil
+	}
+	rows, err := dbConn.QueryContext(ctx, fmt.Sprintf(`
+		SELECT S.DocumentID,
+			SUM(M.SupplyQuantity * M.Price * (1 - ISNULL(M.DiscountPrc, 0) / 100)) AS OpenValue,
+			SUM(M.Quantity * M.Price * (1 - ISNULL(M.DiscountPrc, 0) / 100)) AS FullValue
+		FROM dbo.Stock AS S WITH (NOLOCK)
+		INNER JOIN dbo.StockMoves AS M WITH (NOLOCK) ON M.StockId = S.ID
+		WHERE %s
+		GROUP BY S.DocumentID;`, where), args...)
+	if err != nil {
+		return erp.CreditStatus{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			documentID           int
+			openValue, fullValue sql.NullFloat64
+		)
+		if err := rows.Scan(&documentID, &openValue, &fullValue); err != nil {
+			return erp.CreditStatus{}, err
+		}
+		switch kinds[documentID] {
+		case erp.DocOrder:
+			s.OpenOrders += openValue.Float64
+		case erp.DocDeliveryNote:
+			s.OpenDeliveries += fullValue.Float64
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return erp.CreditStatus{}, err
+	}
+	return s, nil
+}
+
+// openDocumentFilter selects the account's open order and delivery note
+// lines. It returns "" when neither kind has a configured DocumentID.
+func openDocumentFilter(extID string, kinds map[int]string) (string, []any) {
+	var ids []string
+	var args []any
+	for _, id := range slices.Sorted(maps.Keys(kinds)) {
+		if kinds[id] != erp.DocOrder && kinds[id] != erp.DocDeliveryNote {
+			continue
+		}
+		name := fmt.Sprintf("doc%d", len(ids))
+		ids = append(ids, "@"+name)
+		args = append(args, sql.Named(name, id))
+	}
+	if len(ids) == 0 {
+		return "", nil
+	}
+	args = append(args,
+		sql.Named("key", extID),
+		sql.Named("open", stockStatusOpen),
+		sql.Named("closed", stockMoveStatusClosed))
+	return "S.AccountKey = @key AND S.DocumentID IN (" + strings.Join(ids, ", ") + ")" +
+		" AND S.Status = @open AND ISNULL(M.Status, 0) <> @closed", args
+}
@@ -0,0 +1,50 @@
+package hasavshevet
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"erp-connector/internal/config"
+	"erp-connector/internal/erp"
+)
+
+// creditDB answers the CustomerCredit queries for C001: a 1000 limit, the
+// given journal balance and one open delivery note (DocumentID 3) of 50.
+func creditDB(t *testing.T, balance float64) func(string, map[string]any) ([]string, [][]driver.Value, error) {
+	t.Helper()
+	return func(query string, args map[string]any) ([]string, [][]driver.Value, error) {
+		switch {
+		case strings.Contains(query, "FROM dbo.Accounts"):
+			return []string{"AccountKey", "MaxCredit", "Locked"}, [][]driver.Value{{"C001", 1000.0, int64(0)}}, nil
+		case strings.Contains(query, "FROM dbo.TransMoves"):
+			if args["debit"] != int64(transMoveDebit) {
+				t.Errorf("debit = %v", args["debit"])
+			}
+			return []string{"Balance"}, [][]driver.Value{{balance}}, nil
+		default:
+			return []string{"DocumentID", "OpenValue", "FullValue"}, [][]driver.Value{{int64(3), 0.0, 50.0}}, nil
+		}
+	}
+}
+
+// TestCustomerCredit_Balance verifies the unpaid ledger balance counts toward
+// the exposure, so a customer whose debt alone is over MaxCredit fails the
+// check.
+func TestCustomerCredit_Balance(t *testing.T) {
+	dbConn := openFakeDB(t, creditDB(t, 1200))
+	s, err := CustomerCredit(context.Background(), dbConn, config.Config{}, "C001")
+	if err != nil {
+		t.Fatalf("CustomerCredit: %v", err)
+	}
+	if s.Balance == nil || *s.Balance != 1200 {
+		t.Fatalf("balance = %v, want 1200", s.Balance)
+	}
+	if s.OpenDeliveries != 50 {
+		t.Errorf("open deliveries = %v, want the default delivery note counted", s.OpenDeliveries)
+	}
+	if c := erp.CheckCredit(s, 1); !c.Exceeded || c.Exposure != 1251 {
+		t.Errorf("check = %+v, want exposure 1251 over the 1000 limit", c)
+	}
+}
@@ -0,0 +1,119 @@
+package hasavshevet
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"erp-connector/internal/db"
+	"erp-connector/internal/erp"
+)
+
+// customerColumns are the Accounts columns read for a customer. HProtect is
+// the VAT registration number (IMOVEIN line60); Accounts keeps no running
+// balance, so Balance stays empty.
+const customerColumns = `
+	A.AccountKey, A.FullName, A.Address, A.City, A.Zip,
+	A.Phone, A.Phone2, A.EMail, A.Agent, A.HProtect,
+	A.PriceList, A.CreditTermsCode, A.MaxCredit, A.Locked`
+
+func queryCustomers(ctx context.Context, dbConn *sql.DB, where string, args []any, q erp.CustomerSearch) ([]erp.Customer, int, error) {
+	query := fmt.Sprintf(`
+		SELECT %s,
+			COUNT(*) OVER () AS TotalRows
+		FROM dbo.Accounts AS A WITH (NOLOCK)
+		WHERE %s
+		ORDER BY A.AccountKey
+		OFFSET @offset ROWS FETCH NEXT @limit ROWS ONLY;`, customerColumns, where)
+	args = append(args, sql.Named("offset", q.Offset()), sql.Named("limit", q.PageSize))
+
+	rows, err := dbConn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	customers := []erp.Customer{}
+	total := 0
+	for rows.Next() {
+		var (
+			key                                string
+			name, address, city, zip           sql.NullString
+			phone, phone2, email, agent, vatID sql.NullString
+			priceList, terms                   sql.NullString
+			maxCredit                          sql.NullFloat64
+			locked                             sql.NullInt64
+		)
+		if err := rows.Scan(&key, &name, &address, &city, &zip,
+			&phone, &phone2, &email, &agent, &vatID,
+			&priceList, &terms, &maxCredit, &locked, &total); err != nil {
+			return nil, 0, err
+		}
+		c := erp.Customer{
+			ExtID:        strings.TrimSpace(key),
+			Name:         strings.TrimSpace(name.String),
+			Phones:       erp.AppendNonEmpty(nil, phone.String, phone2.String),
+			Email:        strings.TrimSpace(email.String),
+			TaxID:        strings.TrimSpace(vatID.String),
+			Agent:        strings.TrimSpace(agent.String),
+			PriceList:    strings.TrimSpace(priceList.String),
+			PaymentTerms: strings.TrimSpace(terms.String),
+			Blocked:      locked.Int64 != 0,
+		}
+		if address.String != "" || city.String != "" || zip.String != "" {
+			c.Addresses = []erp.CustomerAddress{{
+				Type:    erp.AddressBilling,
+				Street:  strings.TrimSpace(address.String),
+				City:    strings.TrimSpace(city.String),
+				ZipCode: strings.TrimSpace(zip.String),
+			}}
+		}
+		if maxCredit.Valid && maxCredit.Float64 != 0 {
+			limit := maxCredit.Float64
+			c.CreditLimit = &limit
+		}
+		customers = append(customers, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return customers, total, nil
+}
+
+// GetCustomer reads one account by AccountKey.
+func GetCustomer(ctx context.Context, dbConn *sql.DB, extID string) (erp.Customer, error) {
+	customers, _, err := queryCustomers(ctx, dbConn, "A.AccountKey = @key",
+		[]any{sql.Named("key", strings.TrimSpace(extID))},
+		erp.CustomerSearch{Page: 1, PageSize: 1})
+	if err != nil {
+		return erp.Customer{}, err
+	}
+	if len(customers) == 0 {
+		return erp.Customer{}, erp.ErrNotFound
+	}
+	return customers[0], nil
+}
+
+// SearchCustomers pages through Accounts matching q.Query on the key prefix,
+// name or phone numbers. Accounts also holds non-customer ledger accounts;
+// they match too when searched for.
+func SearchCustomers(ctx context.Context, dbConn *sql.DB, q erp.CustomerSearch) (erp.CustomerPage, error) {
+	where, args := customerSearchFilter(q.Query)
+	customers, total, err := queryCustomers(ctx, dbConn, where, args, q)
+	if err != nil {
+		return erp.CustomerPage{}, err
+	}
+	return erp.CustomerPage{Customers: customers, Page: q.Page, PageSize: q.PageSize, Total: total}, nil
+}
+
+func customerSearchFilter(text string) (string, []any) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "1 = 1", nil
+	}
+	like := db.EscapeLike(text)
+	return `(A.AccountKey LIKE @prefix OR A.FullName LIKE @contains
+			OR A.Phone LIKE @contains OR A.Phone2 LIKE @contains)`,
+		[]any{sql.Named("prefix", like+"%"), sql.Named("contains", "%"+like+"%")}
+}
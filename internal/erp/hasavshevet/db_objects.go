@@ -0,0 +1,12 @@
+package hasavshevet
+
+import "erp-connector/internal/db/migrate"
+
+// DBObjects are the stored procedures the connector installs in the company
+// database for price and stock lookups.
+func DBObjects() []migrate.Object {
+	return []migrate.Object{
+		{Name: gpriceBulkProcName, Version: gpriceBulkVersion, Script: gpriceBulkProcedureSQL},
+		{Name: onHandStockProcName, Version: onHandStockVersion, Script: onHandStockProcedureSQL},
+	}
+}
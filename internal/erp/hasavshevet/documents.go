@@ -0,0 +1,223 @@
+package hasavshevet
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"erp-connector/internal/config"
+	"erp-connector/internal/erp"
+)
+
+// stockStatusOpen is Stock.Status of documents not yet fully supplied.
+const stockStatusOpen = 1
+
+// stockMoveStatusClosed is StockMoves.Status of fully supplied lines.
+const stockMoveStatusClosed = 2
+
+// documentKinds maps Stock.DocumentID codes to document kinds for the
+// configured installation (see HasavshevetConfig.DocumentIDs for the
+// defaults).
+func documentKinds(h config.HasavshevetConfig) map[int]string {
+	ids := h.DocumentIDs()
+	out := make(map[int]string)
+	for _, id := range ids.Orders {
+		out[id] = erp.DocOrder
+	}
+	for _, id := range ids.DeliveryNotes {
+		out[id] = erp.DocDeliveryNote
+	}
+	for _, id := range ids.Invoices {
+		out[id] = erp.DocInvoice
+	}
+	return out
+}
+
+// CustomerDocuments reads the customer's Stock documents of the selected
+// kinds with their StockMoves lines. Documents imported by sendOrder carry
+// the connector order number in Asmahta2; it is reported as OrderNumber when
+// sendOrderDir holds that order's history.
+func CustomerDocuments(ctx context.Context, dbConn *sql.DB, cfg config.Config, q erp.DocumentSearch) (erp.DocumentPage, error) {
+	page := erp.DocumentPage{Documents: []erp.Document{}, Page: q.Page, PageSize: q.PageSize}
+
+	kinds := documentKinds(cfg.Hasavshevet)
+	where, args := documentFilter(q, kinds)
+	if where == "" {
+		return page, nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT S.ID, S.DocumentID, S.DocNumber, S.ValueDate, S.Asmahta2, S.Status,
+			COUNT(*) OVER () AS TotalRows
+		FROM dbo.Stock AS S WITH (NOLOCK)
+		WHERE %s
+		ORDER BY S.ValueDate DESC, S.ID DESC
+		OFFSET @offset ROWS FETCH NEXT @limit ROWS ONLY;`, where)
+	args = append(args, sql.Named("offset", q.Offset()), sql.Named("limit", q.PageSize))
+
+	rows, err := dbConn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return erp.DocumentPage{}, err
+	}
+	defer rows.Close()
+
+	var stockIDs []int64
+	for rows.Next() {
+		var (
+			id         int64
+			documentID int
+			docNumber  sql.NullString
+			valueDate  sql.NullTime
+			asmahta2   sql.NullString
+			status     sql.NullInt64
+		)
+		if err := rows.Scan(&id, &documentID, &docNumber, &valueDate, &asmahta2, &status, &page.Total); err != nil {
+			return erp.DocumentPage{}, err
+		}
+		doc := erp.Document{
+			Kind:        kinds[documentID],
+			Number:      strings.TrimSpace(docNumber.String),
+			Status:      erp.DocStatusClosed,
+			Reference:   strings.TrimSpace(asmahta2.String),
+			OrderNumber: connectorOrderNumber(cfg.SendOrderDir, asmahta2.String),
+			Lines:       []erp.DocumentLine{},
+			Details:     map[string]any{"documentId": documentID, "stockId": id},
+		}
+		if status.Int64 == stockStatusOpen {
+			doc.Status = erp.DocStatusOpen
+		}
+		if valueDate.Valid {
+			t := valueDate.Time
+			doc.Date = &t
+		}
+		page.Documents = append(page.Documents, doc)
+		stockIDs = append(stockIDs, id)
+	}
+	if err := rows.Err(); err != nil {
+		return erp.DocumentPage{}, err
+	}
+	if len(stockIDs) == 0 {
+		return page, nil
+	}
+
+	lines, err := fetchStockMoves(ctx, dbConn, stockIDs)
+	if err != nil {
+		return erp.DocumentPage{}, err
+	}
+	for i, id := range stockIDs {
+		doc := &page.Documents[i]
+		for _, l := range lines[id] {
+			doc.Lines = append(doc.Lines, l)
+			doc.NetTotal += l.Total
+		}
+	}
+	return page, nil
+}
+
+// documentFilter builds the Stock WHERE clause. It returns "" when none of
+// the requested kinds has a configured DocumentID.
+func documentFilter(q erp.DocumentSearch, kinds map[int]string) (string, []any) {
+	var ids []string
+	var args []any
+	for _, id := range slices.Sorted(maps.Keys(kinds)) {
+		if !q.WantsKind(kinds[id]) {
+			continue
+		}
+		name := fmt.Sprintf("doc%d", len(ids))
+		ids = append(ids, "@"+name)
+		args = append(args, sql.Named(name, id))
+	}
+	if len(ids) == 0 {
+		return "", nil
+	}
+
+	conds := []string{"S.AccountKey = @key", "S.DocumentID IN (" + strings.Join(ids, ", ") + ")"}
+	args = append(args, sql.Named("key", strings.TrimSpace(q.ExtID)))
+	if q.OpenOnly {
+		conds = append(conds, "S.Status = @open")
+		args = append(args, sql.Named("open", stockStatusOpen))
+	}
+	if !q.From.IsZero() {
+		conds = append(conds, "S.ValueDate >= @from")
+		args = append(args, sql.Named("from", q.From))
+	}
+	if !q.To.IsZero() {
+		conds = append(conds, "S.ValueDate < @to")
+		args = append(args, sql.Named("to", q.To.AddDate(0, 0, 1)))
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+func fetchStockMoves(ctx context.Context, dbConn *sql.DB, stockIDs []int64) (map[int64][]erp.DocumentLine, error) {
+	names := make([]string, 0, len(stockIDs))
+	args := make([]any, 0, len(stockIDs))
+	for i, id := range stockIDs {
+		name := fmt.Sprintf("s%d", i)
+		names = append(names, "@"+name)
+		args = append(args, sql.Named(name, id))
+	}
+	query := `
+		SELECT M.StockId, M.ItemKey, M.ItemName, M.Quantity, M.SupplyQuantity,
+			M.Price, M.DiscountPrc, M.Status
+		FROM dbo.StockMoves AS M WITH (NOLOCK)
+		WHERE M.StockId IN (` + strings.Join(names, ", ") + `)
+		ORDER BY M.StockId, M.ID;`
+
+	rows, err := dbConn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[int64][]erp.DocumentLine, len(stockIDs))
+	for rows.Next() {
+		var (
+			stockID                     int64
+			itemKey, itemName           sql.NullString
+			qty, supplyQty, price, disc sql.NullFloat64
+			status                      sql.NullInt64
+		)
+		if err := rows.Scan(&stockID, &itemKey, &itemName, &qty, &supplyQty, &price, &disc, &status); err != nil {
+			return nil, err
+		}
+		line := erp.DocumentLine{
+			LineNum:      len(out[stockID]) + 1,
+			SKU:          strings.TrimSpace(itemKey.String),
+			Name:         strings.TrimSpace(itemName.String),
+			Quantity:     qty.Float64,
+			OpenQuantity: supplyQty.Float64,
+			Price:        price.Float64,
+			DiscountPct:  disc.Float64,
+			Total:        erp.LineTotal(qty.Float64, price.Float64, disc.Float64),
+			Status:       erp.DocStatusOpen,
+		}
+		if status.Int64 == stockMoveStatusClosed {
+			line.Status = erp.DocStatusClosed
+		}
+		out[stockID] = append(out[stockID], line)
+	}
+	return out, rows.Err()
+}
+
+// connectorOrderNumber returns asmahta2 when it is an order number this
+// connector imported, i.e. sendOrderDir/history/<n> exists.
+func connectorOrderNumber(sendOrderDir, asmahta2 string) string {
+	asmahta2 = strings.TrimSpace(asmahta2)
+	if sendOrderDir == "" || asmahta2 == "" {
+		return ""
+	}
+	if _, err := strconv.ParseInt(asmahta2, 10, 64); err != nil {
+		return ""
+	}
+	if fi, err := os.Stat(filepath.Join(sendOrderDir, "history", asmahta2)); err != nil || !fi.IsDir() {
+		return ""
+	}
+	return asmahta2
+}
+
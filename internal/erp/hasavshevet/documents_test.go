@@ -0,0 +1,99 @@
+package hasavshevet
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"erp-connector/internal/config"
+	"erp-connector/internal/erp"
+)
+
+// TestDocumentFilter verifies only configured DocumentIDs of the requested kinds are queried.
+func TestDocumentFilter(t *testing.T) {
+	kinds := documentKinds(config.HasavshevetConfig{Documents: config.HasavshevetDocumentIDs{Invoices: []int{1, 3}}})
+
+	where, args := documentFilter(erp.DocumentSearch{ExtID: "C001", OpenOnly: true}, kinds)
+	if !strings.Contains(where, "S.DocumentID IN (@doc0, @doc1, @doc2, @doc3, @doc4)") || !strings.Contains(where, "S.Status = @open") {
+		t.Errorf("where = %s", where)
+	}
+	ids := map[string]any{}
+	for _, a := range args {
+		n := a.(sql.NamedArg)
+		ids[n.Name] = n.Value
+	}
+	if ids["doc0"] != 1 || ids["doc1"] != 3 || ids["doc2"] != 11 || ids["doc3"] != 30 || ids["doc4"] != 32 || ids["key"] != "C001" {
+		t.Errorf("args = %v", ids)
+	}
+
+	// The default delivery note code 3 is configured as an invoice, so no
+	// delivery notes are left to query.
+	if where, _ := documentFilter(erp.DocumentSearch{Kinds: []string{erp.DocDeliveryNote}}, kinds); where != "" {
+		t.Errorf("where = %q, want empty", where)
+	}
+}
+
+// TestConnectorOrderNumber verifies Asmahta2 links only to orders with a history dir.
+func TestConnectorOrderNumber(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "history", "1000295"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]string{"1000295": "1000295", " 1000295 ": "1000295", "1000296": "", "PO-77": "", "": ""}
+	for asmahta2, want := range cases {
+		if got := connectorOrderNumber(dir, asmahta2); got != want {
+			t.Errorf("connectorOrderNumber(%q) = %q, want %q", asmahta2, got, want)
+		}
+	}
+	if got := connectorOrderNumber("", "1000295"); got != "" {
+		t.Errorf("without sendOrderDir = %q, want empty", got)
+	}
+}
+
+// TestCustomerDocuments_DefaultKinds verifies orders, delivery notes and
+// invoices are all reported without hasavshevet.documents configured.
+func TestCustomerDocuments_DefaultKinds(t *testing.T) {
+	stock := [][]driver.Value{
+		{int64(1), int64(11), "5001", time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC), "", int64(stockStatusOpen)},
+		{int64(2), int64(3), "7001", time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), "", int64(stockStatusOpen)},
+		{int64(3), int64(1), "9001", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), "", int64(0)},
+		{int64(4), int64(99), "1", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), "", int64(0)},
+	}
+	dbConn := openFakeDB(t, func(query string, args map[string]any) ([]string, [][]driver.Value, error) {
+		if strings.Contains(query, "FROM dbo.StockMoves") {
+			return []string{"StockId", "ItemKey", "ItemName", "Quantity", "SupplyQuantity", "Price", "DiscountPrc", "Status"}, nil, nil
+		}
+		// Stock rows whose DocumentID is one of the @docN arguments.
+		var rows [][]driver.Value
+		for _, r := range stock {
+			for name, v := range args {
+				if strings.HasPrefix(name, "doc") && v == r[1] {
+					rows = append(rows, append(r, int64(0)))
+				}
+			}
+		}
+		for _, r := range rows {
+			r[6] = int64(len(rows))
+		}
+		return []string{"ID", "DocumentID", "DocNumber", "ValueDate", "Asmahta2", "Status", "TotalRows"}, rows, nil
+	})
+
+	page, err := CustomerDocuments(context.Background(), dbConn, config.Config{}, erp.DocumentSearch{ExtID: "C001", Page: 1, PageSize: 50})
+	if err != nil {
+		t.Fatalf("CustomerDocuments: %v", err)
+	}
+	var kinds []string
+	for _, d := range page.Documents {
+		kinds = append(kinds, d.Kind)
+	}
+	want := []string{erp.DocOrder, erp.DocDeliveryNote, erp.DocInvoice}
+	if strings.Join(kinds, ",") != strings.Join(want, ",") || page.Total != 3 {
+		t.Errorf("kinds = %v (total %d), want %v", kinds, page.Total, want)
+	}
+}
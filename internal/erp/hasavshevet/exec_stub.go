@@ -4,6 +4,10 @@ package hasavshevet
 
 import "context"
 
+// importerSupported is false: the importer is skipped, so there is no import
+// to verify.
+const importerSupported = false
+
 // runImporter is a no-op on non-Windows platforms.
 // Hasavshevet (has.exe) only runs on Windows; on Linux/macOS the files are
 // still written for testing or cross-compiled builds.
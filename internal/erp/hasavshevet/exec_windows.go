@@ -9,6 +9,10 @@ import (
 	"strings"
 )
 
+// importerSupported reports that has.exe / digi.bat really run, so their
+// imports can be verified.
+const importerSupported = true
+
 // runImporter executes has.exe with the given parameter file in workDir.
 // Returns the process exit code, combined stdout+stderr output, and any exec error.
 // The single-worker OrderQueue guarantees only one invocation runs at a time.
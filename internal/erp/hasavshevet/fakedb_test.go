@@ -0,0 +1,90 @@
+package hasavshevet
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+)
+
+// fakeQuery answers one query with column names and rows. args holds the
+// named arguments by name.
+type fakeQuery func(query string, args map[string]any) ([]string, [][]driver.Value, error)
+
+var (
+	fakeDBsMu sync.Mutex
+	fakeDBs   = map[string]fakeQuery{}
+)
+
+func init() {
+	sql.Register("hasavshevet-fake", fakeDriver{})
+}
+
+// openFakeDB returns a database whose queries are answered by answer.
+func openFakeDB(t *testing.T, answer fakeQuery) *sql.DB {
+	t.Helper()
+	fakeDBsMu.Lock()
+	fakeDBs[t.Name()] = answer
+	fakeDBsMu.Unlock()
+	dbConn, err := sql.Open("hasavshevet-fake", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = dbConn.Close()
+		fakeDBsMu.Lock()
+		delete(fakeDBs, t.Name())
+		fakeDBsMu.Unlock()
+	})
+	return dbConn
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeDBsMu.Lock()
+	defer fakeDBsMu.Unlock()
+	answer, ok := fakeDBs[name]
+	if !ok {
+		return nil, errors.New("unknown fake database " + name)
+	}
+	return fakeConn{answer: answer}, nil
+}
+
+type fakeConn struct{ answer fakeQuery }
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c fakeConn) QueryContext(_ context.Context, query string, nv []driver.NamedValue) (driver.Rows, error) {
+	args := make(map[string]any, len(nv))
+	for _, v := range nv {
+		args[v.Name] = v.Value
+	}
+	cols, rows, err := c.answer(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{cols: cols, rows: rows}, nil
+}
+
+type fakeRows struct {
+	cols []string
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.cols }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
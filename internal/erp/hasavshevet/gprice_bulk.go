@@ -1,10 +1,9 @@
 package hasavshevet
 
-import (
-	"context"
-	"database/sql"
-	"errors"
-)
+// gpriceBulkVersion is the installed version of GPRICE_Bulk; bump it with
+// every script change. Version 2 prices each SKU at its own quantity
+// (@QuantitiesJson).
+const gpriceBulkVersion = 2
 
 const gpriceBulkProcName = "dbo.GPRICE_Bulk"
 
@@ -17,7 +16,8 @@ CREATE OR ALTER PROCEDURE dbo.GPRICE_Bulk
 	@DatF         datetime = NULL,
 	@Quantity     float = 1,
 	@Assign       varchar(15) = '1',
-	@Split        tinyint = 0
+	@Split        tinyint = 0,
+	@QuantitiesJson nvarchar(max) = NULL
 )
 AS
 BEGIN
@@ -62,9 +62,11 @@ BEGIN
 		)
 		SELECT
 			@StationId, @CustomerId, s.Sku,
-			@Quantity, @DatF, @DocumentID,
+			COALESCE(TRY_CAST(q.[value] AS float), @Quantity), @DatF, @DocumentID,
 			0, 0, 0
-		FROM #SkuList s;
+		FROM #SkuList s
+		LEFT JOIN OPENJSON(@QuantitiesJson) q
+		  ON q.[key] COLLATE DATABASE_DEFAULT = s.Sku;
 
 		EXEC dbo.GPRICE
 			@STATIONID = @StationId,
@@ -122,54 +124,3 @@ BEGIN
 	END CATCH
 END
 `
-
-func EnsureGPriceBulkProcedure(ctx context.Context, dbConn *sql.DB) (bool, error) {
-	if dbConn == nil {
-		return false, errors.New("db connection is required")
-	}
-	if ctx == nil {
-		ctx = context.Background()
-	}
-
-	exists, err := procedureExists(ctx, dbConn, gpriceBulkProcName)
-	if err != nil {
-		return false, err
-	}
-	if exists {
-		return false, nil
-	}
-
-	if _, err := dbConn.ExecContext(ctx, gpriceBulkProcedureSQL); err != nil {
-		return false, err
-	}
-	return true, nil
-}
-
-func procedureExists(ctx context.Context, dbConn *sql.DB, name string) (bool, error) {
-	if dbConn == nil {
-		return false, errors.New("db connection is required")
-	}
-	if ctx == nil {
-		ctx = context.Background()
-	}
-
-	const query = `
-		SELECT 1
-		WHERE EXISTS (
-			SELECT 1
-			FROM sys.objects
-			WHERE object_id = OBJECT_ID(@procName)
-			  AND type IN (N'P', N'PC')
-		);
-	`
-
-	var found int
-	err := dbConn.QueryRowContext(ctx, query, sql.Named("procName", name)).Scan(&found)
-	if errors.Is(err, sql.ErrNoRows) {
-		return false, nil
-	}
-	if err != nil {
-		return false, err
-	}
-	return true, nil
-}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"golang.org/x/text/encoding/charmap"
 	"golang.org/x/text/transform"
@@ -241,6 +242,20 @@ type stockMove struct {
 	Price       string // originalPrice (line24)
 	DiscountPrc string
 	Unit        string
+	VatExempt   bool   // line34 / פטור ממע"מ
+	Batch       string // line41 / אצווה
+	Serial      string // line44 / מספר טבוע
+	Remarks1    string // line56 / הערה נוספת בתנועה 1
+	Remarks2    string // line57 / הערה נוספת בתנועה 2
+	ExtraSum1   string // line58
+	ExtraSum2   string // line59
+	DueDate     string // line70 / תאריך ערך בתנועה
+	Details     string // line71 / פרטים בתנועה
+	WareHouse   string // line72 / מחסן בתנועה; blank uses the header's
+	ExtraDate1  string // line83
+	ExtraDate2  string // line84
+	ExtraNum1   string // line85
+	ExtraNum2   string // line86
 }
 
 // generateDOC generates the IMOVEIN.doc binary content (Windows-1255 encoded).
@@ -259,99 +274,92 @@ func generateDOC(hdr stockHeader, moves []stockMove) ([]byte, error) {
 // buildDOCRecord builds a single fixed-length Windows-1255 record.
 // Field order and widths match the canonical imoveInFieldLengths mapping.
 func buildDOCRecord(h stockHeader, m stockMove) []byte {
-	f := imoveInFieldLengths
-	w := padW1255
-
+	values := docRecordValues(h, m)
 	var buf bytes.Buffer
-	buf.Write(w(h.AccountKey, f["line2"]))
-	buf.Write(w(fmt.Sprintf("%d", h.MyID), f["line3"]))
-	buf.Write(w(fmt.Sprintf("%d", h.DocumentID), f["line4"]))
-	buf.Write(w(h.AccountName, f["line5"]))
-	buf.Write(w(h.Address, f["line6"]))
-	buf.Write(w(h.City, f["line7"]))
-	buf.Write(w(h.Asmahta2, f["line8"]))
-	buf.Write(w(h.ShortDate, f["line9"]))
-	buf.Write(w(h.ShortDate, f["line10"]))
-	buf.Write(w(h.Agent, f["line11"]))
-	buf.Write(w(fmt.Sprintf("%d", h.WareHouse), f["line12"]))
-	buf.Write(w("", f["line13"]))
-	buf.Write(w("", f["line14"]))
-	buf.Write(w("", f["line15"]))
-	buf.Write(w("", f["line16"]))
-	buf.Write(w(h.DiscountPrcR, f["line17"]))
-	buf.Write(w(h.VatPrc, f["line18"]))
-	buf.Write(w(h.Copies, f["line19"]))
-	buf.Write(w(h.Currency, f["line20"]))
-	buf.Write(w(h.Rate, f["line21"]))
-	buf.Write(w(m.ItemKey, f["line22"]))
-	buf.Write(w(m.Quantity, f["line23"]))
-	buf.Write(w(m.Price, f["line24"]))
-	buf.Write(w(h.Currency, f["line25"]))
-	buf.Write(w(m.DiscountPrc, f["line26"]))
-	buf.Write(w(h.Rate, f["line27"]))
-	buf.Write(w(m.ItemName, f["line28"]))
-	buf.Write(w(m.Unit, f["line29"]))
-	buf.Write(w("", f["line30"]))
-	buf.Write(w("", f["line31"]))
-	buf.Write(w("", f["line32"]))
-	buf.Write(w(m.Packs, f["line33"])) // אריזות / packs
-	buf.Write(w("0", f["line34"]))
-	buf.Write(w(h.Phone, f["line35"]))
-	buf.Write(w(h.ShortDate, f["line36"]))
-	buf.Write(w(h.Remarks, f["line37"]))
-	buf.Write(w("", f["line38"]))
-	buf.Write(w("", f["line39"]))
-	buf.Write(w("", f["line40"]))
-	buf.Write(w("", f["line41"]))
-	buf.Write(w("", f["line42"]))
-	buf.Write(w("", f["line43"]))
-	buf.Write(w("", f["line44"]))
-	buf.Write(w(h.ExtraText1, f["line45"]))
-	buf.Write(w(h.ExtraText2, f["line46"]))
-	buf.Write(w(h.ExtraText3, f["line47"]))
-	buf.Write(w(h.ExtraText4, f["line48"]))
-	buf.Write(w(h.ExtraText5, f["line49"]))
-	buf.Write(w(h.ExtraSum1, f["line50"]))
-	buf.Write(w(h.ExtraSum2, f["line51"]))
-	buf.Write(w(h.ExtraSum3, f["line52"]))
-	buf.Write(w("", f["line53"]))
-	buf.Write(w("", f["line54"]))
-	buf.Write(w("", f["line55"]))
-	buf.Write(w("", f["line56"]))
-	buf.Write(w("", f["line57"]))
-	buf.Write(w("", f["line58"]))
-	buf.Write(w("", f["line59"]))
-	buf.Write(w(h.HProtect, f["line60"]))
-	buf.Write(w("", f["line61"]))
-	buf.Write(w("", f["line62"]))
-	// line63: length 0, omitted from record
-	buf.Write(w("", f["line64"]))
-	buf.Write(w("", f["line65"]))
-	buf.Write(w("", f["line66"]))
-	buf.Write(w("", f["line67"]))
-	buf.Write(w("", f["line68"]))
-	buf.Write(w("", f["line69"]))
-	buf.Write(w("", f["line70"]))
-	buf.Write(w("", f["line71"]))
-	buf.Write(w("", f["line72"]))
-	buf.Write(w("", f["line73"]))
-	buf.Write(w("", f["line74"]))
-	buf.Write(w("", f["line75"]))
-	buf.Write(w("", f["line76"]))
-	buf.Write(w("", f["line77"]))
-	buf.Write(w("", f["line78"]))
-	buf.Write(w(h.ExtraDate1, f["line79"]))
-	buf.Write(w(h.ExtraDate2, f["line80"]))
-	buf.Write(w(h.ExtraNum1, f["line81"]))
-	buf.Write(w(h.ExtraNum2, f["line82"]))
-	buf.Write(w("", f["line83"]))
-	buf.Write(w("", f["line84"]))
-	buf.Write(w("", f["line85"]))
-	buf.Write(w("", f["line86"]))
-	buf.Write(w("", f["line87"]))
+	for i := 2; i <= 87; i++ {
+		key := fmt.Sprintf("line%d", i)
+		// line63 has length 0 and is omitted from the record.
+		buf.Write(padW1255(values[key], imoveInFieldLengths[key]))
+	}
 	return buf.Bytes()
 }
 
+// docRecordValues returns the value of every non-blank field of a record,
+// before padW1255 pads or truncates it to the field's width.
+func docRecordValues(h stockHeader, m stockMove) map[string]string {
+	return map[string]string{
+		"line2":  h.AccountKey,
+		"line3":  fmt.Sprintf("%d", h.MyID),
+		"line4":  fmt.Sprintf("%d", h.DocumentID),
+		"line5":  h.AccountName,
+		"line6":  h.Address,
+		"line7":  h.City,
+		"line8":  h.Asmahta2,
+		"line9":  h.ShortDate,
+		"line10": h.ShortDate,
+		"line11": h.Agent,
+		"line12": fmt.Sprintf("%d", h.WareHouse),
+		"line17": h.DiscountPrcR,
+		"line18": h.VatPrc,
+		"line19": h.Copies,
+		"line20": h.Currency,
+		"line21": h.Rate,
+		"line22": m.ItemKey,
+		"line23": m.Quantity,
+		"line24": m.Price,
+		"line25": h.Currency,
+		"line26": m.DiscountPrc,
+		"line27": h.Rate,
+		"line28": m.ItemName,
+		"line29": m.Unit,
+		"line33": m.Packs,
+		"line34": vatExemptFlag(m.VatExempt),
+		"line35": h.Phone,
+		"line36": h.ShortDate,
+		"line37": h.Remarks,
+		"line41": m.Batch,
+		"line44": m.Serial,
+		"line45": h.ExtraText1,
+		"line46": h.ExtraText2,
+		"line47": h.ExtraText3,
+		"line48": h.ExtraText4,
+		"line49": h.ExtraText5,
+		"line50": h.ExtraSum1,
+		"line51": h.ExtraSum2,
+		"line52": h.ExtraSum3,
+		"line56": m.Remarks1,
+		"line57": m.Remarks2,
+		"line58": m.ExtraSum1,
+		"line59": m.ExtraSum2,
+		"line60": h.HProtect,
+		"line70": m.DueDate,
+		"line71": m.Details,
+		"line72": m.WareHouse,
+		"line79": h.ExtraDate1,
+		"line80": h.ExtraDate2,
+		"line81": h.ExtraNum1,
+		"line82": h.ExtraNum2,
+		"line83": m.ExtraDate1,
+		"line84": m.ExtraDate2,
+		"line85": m.ExtraNum1,
+		"line86": m.ExtraNum2,
+	}
+}
+
+// docTruncations lists the fields of a record that padW1255 would cut.
+func docTruncations(h stockHeader, m stockMove) []string {
+	values := docRecordValues(h, m)
+	var out []string
+	for i := 2; i <= 87; i++ {
+		key := fmt.Sprintf("line%d", i)
+		if n := utf8.RuneCountInString(values[key]); n > imoveInFieldLengths[key] {
+			out = append(out, fmt.Sprintf("%s (%s) is %d characters, cut to %d: %q",
+				key, imoveInFieldTitles[key], n, imoveInFieldLengths[key], values[key]))
+		}
+	}
+	return out
+}
+
 // generatePRM generates the IMOVEIN.prm binary content (Windows-1255 encoded).
 // Format: line1 = total record length; lines 2..87 = "start end ;title".
 // Zero-length fields (line63) emit "0 0 ;title".
@@ -418,3 +426,11 @@ func encodeWindows1255(s string) []byte {
 	}
 	return result
 }
+
+// vatExemptFlag is the line34 value: 1 for VAT-exempt lines.
+func vatExemptFlag(exempt bool) string {
+	if exempt {
+		return "1"
+	}
+	return "0"
+}
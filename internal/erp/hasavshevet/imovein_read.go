@@ -0,0 +1,260 @@
+package hasavshevet
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IMOVEINField is one field of an IMOVEIN record as a PRM file describes it.
+type IMOVEINField struct {
+	Key   string // PRM key, line2..line87
+	Title string
+	Start int // 1-based first byte in the record; 0 for unused fields
+	Width int
+}
+
+// IMOVEINLayout is the record layout an IMOVEIN.doc is read with.
+type IMOVEINLayout struct {
+	RecordLength int
+	Fields       []IMOVEINField
+}
+
+// DefaultIMOVEINLayout is the layout generatePRM writes.
+func DefaultIMOVEINLayout() IMOVEINLayout {
+	l := IMOVEINLayout{RecordLength: prmTotalRecordLength}
+	pos := 1
+	for i := 2; i <= 87; i++ {
+		key := fmt.Sprintf("line%d", i)
+		f := IMOVEINField{Key: key, Title: imoveInFieldTitles[key], Width: imoveInFieldLengths[key]}
+		if f.Width > 0 {
+			f.Start = pos
+			pos += f.Width
+		}
+		l.Fields = append(l.Fields, f)
+	}
+	return l
+}
+
+var prmLineRe = regexp.MustCompile(`^\s*(\d+)(?:\s+(\d+))?\s*;(.*)$`)
+
+// ParsePRM reads an IMOVEIN.prm: the record length on the first line, then
+// one "start end ;title" line per field, in order from line2.
+func ParsePRM(data []byte) (IMOVEINLayout, error) {
+	var l IMOVEINLayout
+	lines := strings.Split(strings.ReplaceAll(decodeImportText(data), "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		m := prmLineRe.FindStringSubmatch(line)
+		if m == nil {
+			return IMOVEINLayout{}, fmt.Errorf("prm line %d: %q is not \"start end ;title\"", i+1, line)
+		}
+		start, _ := strconv.Atoi(m[1])
+		if i == 0 {
+			l.RecordLength = start
+			continue
+		}
+		end, _ := strconv.Atoi(m[2])
+		f := IMOVEINField{Key: fmt.Sprintf("line%d", len(l.Fields)+2), Title: strings.TrimSpace(m[3])}
+		if start > 0 {
+			if end < start {
+				return IMOVEINLayout{}, fmt.Errorf("prm line %d: end %d before start %d", i+1, end, start)
+			}
+			f.Start, f.Width = start, end-start+1
+		}
+		l.Fields = append(l.Fields, f)
+	}
+	if l.RecordLength == 0 || len(l.Fields) == 0 {
+		return IMOVEINLayout{}, fmt.Errorf("prm has no fields")
+	}
+	return l, nil
+}
+
+// Differences lists the fields whose position or width differ from other,
+// e.g. a history PRM compared with DefaultIMOVEINLayout.
+func (l IMOVEINLayout) Differences(other IMOVEINLayout) []string {
+	var out []string
+	if l.RecordLength != other.RecordLength {
+		out = append(out, fmt.Sprintf("record length %d, expected %d", l.RecordLength, other.RecordLength))
+	}
+	for i := 0; i < max(len(l.Fields), len(other.Fields)); i++ {
+		switch {
+		case i >= len(l.Fields):
+			out = append(out, fmt.Sprintf("%s missing", other.Fields[i].Key))
+		case i >= len(other.Fields):
+			out = append(out, fmt.Sprintf("%s is not expected", l.Fields[i].Key))
+		case l.Fields[i].Start != other.Fields[i].Start || l.Fields[i].Width != other.Fields[i].Width:
+			a, b := l.Fields[i], other.Fields[i]
+			out = append(out, fmt.Sprintf("%s at %d width %d, expected %d width %d", a.Key, a.Start, a.Width, b.Start, b.Width))
+		}
+	}
+	return out
+}
+
+// DOCValue is one field read from a DOC record.
+type DOCValue struct {
+	IMOVEINField
+	Value string // decoded, padding trimmed
+	// Full is set for text that fills the whole field: padW1255 cuts longer
+	// values, so it was probably truncated. Numbers and dates are not flagged.
+	Full bool
+	// Missing is set when the record ends before the field.
+	Missing bool
+}
+
+// DOCRecord is one IMOVEIN.doc line cut into fields.
+type DOCRecord struct {
+	Number int // 1-based record (order line) number
+	Length int // bytes, without the line ending
+	Values []DOCValue
+}
+
+// Overflow is the number of bytes past the layout's record length; negative
+// when the record is short.
+func (r DOCRecord) Overflow(l IMOVEINLayout) int {
+	return r.Length - l.RecordLength
+}
+
+// Value returns the field with key, or a zero DOCValue.
+func (r DOCRecord) Value(key string) DOCValue {
+	for _, v := range r.Values {
+		if v.Key == key {
+			return v
+		}
+	}
+	return DOCValue{}
+}
+
+// Warnings lists the record's overflowed, short and probably truncated fields.
+func (r DOCRecord) Warnings(l IMOVEINLayout) []string {
+	var out []string
+	switch n := r.Overflow(l); {
+	case n > 0:
+		out = append(out, fmt.Sprintf("record %d: %d bytes past the %d-byte record", r.Number, n, l.RecordLength))
+	case n < 0:
+		out = append(out, fmt.Sprintf("record %d: %d bytes short of the %d-byte record", r.Number, -n, l.RecordLength))
+	}
+	for _, v := range r.Values {
+		switch {
+		case v.Missing:
+			out = append(out, fmt.Sprintf("record %d: %s (%s) is missing", r.Number, v.Key, v.Title))
+		case v.Full:
+			out = append(out, fmt.Sprintf("record %d: %s (%s) fills all %d characters; it may be truncated", r.Number, v.Key, v.Title, v.Width))
+		}
+	}
+	return out
+}
+
+// ParseDOC cuts an IMOVEIN.doc into records using l. Records are separated
+// by LF (CRLF is accepted); the Windows-1255 bytes are decoded per field.
+func (l IMOVEINLayout) ParseDOC(data []byte) []DOCRecord {
+	var records []DOCRecord
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		if len(line) == 0 {
+			continue
+		}
+		r := DOCRecord{Number: i + 1, Length: len(line)}
+		for _, f := range l.Fields {
+			v := DOCValue{IMOVEINField: f}
+			switch {
+			case f.Width == 0:
+			case f.Start-1+f.Width > len(line):
+				v.Missing = true
+				if f.Start-1 < len(line) {
+					v.Value = strings.TrimRight(decodeImportText(line[f.Start-1:]), " ")
+				}
+			default:
+				raw := line[f.Start-1 : f.Start-1+f.Width]
+				v.Value = strings.TrimRight(decodeImportText(raw), " ")
+				v.Full = f.Width > 1 && raw[len(raw)-1] != ' ' && !numericOrDate(v.Value)
+			}
+			r.Values = append(r.Values, v)
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+// numericOrDate reports whether s is a number or a dd/mm/yyyy date, which
+// fill their fields exactly without being truncated.
+func numericOrDate(s string) bool {
+	if _, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+		return true
+	}
+	_, err := time.Parse("02/01/2006", s)
+	return err == nil
+}
+
+// DOCDiff is one field that differs between two DOC files.
+type DOCDiff struct {
+	Record int
+	Key    string // "" when the whole record exists on one side only
+	Title  string
+	A, B   string
+}
+
+// DiffDOC compares two parsed DOC files record by record and field by field.
+func DiffDOC(a, b []DOCRecord) []DOCDiff {
+	var out []DOCDiff
+	for i := 0; i < max(len(a), len(b)); i++ {
+		switch {
+		case i >= len(a):
+			out = append(out, DOCDiff{Record: b[i].Number, A: "(no record)", B: fmt.Sprintf("%d bytes", b[i].Length)})
+			continue
+		case i >= len(b):
+			out = append(out, DOCDiff{Record: a[i].Number, A: fmt.Sprintf("%d bytes", a[i].Length), B: "(no record)"})
+			continue
+		}
+		for _, va := range a[i].Values {
+			vb := b[i].Value(va.Key)
+			if va.Value != vb.Value {
+				out = append(out, DOCDiff{Record: a[i].Number, Key: va.Key, Title: va.Title, A: va.Value, B: vb.Value})
+			}
+		}
+	}
+	return out
+}
+
+// IMOVEINFile is a DOC file read with the PRM beside it.
+type IMOVEINFile struct {
+	Path    string
+	PRMPath string // "" when there is no PRM and the default layout is used
+	Layout  IMOVEINLayout
+	Records []DOCRecord
+	// LayoutNotes lists where the PRM differs from DefaultIMOVEINLayout.
+	LayoutNotes []string
+}
+
+// HistoryDOCPath is where processOrderWithNumber keeps order orderNum's DOC.
+func HistoryDOCPath(sendOrderDir string, orderNum int64) string {
+	return filepath.Join(sendOrderDir, "history", fmt.Sprintf("%d", orderNum), fmt.Sprintf("IMOVEIN_%d.doc", orderNum))
+}
+
+// ReadIMOVEINFile parses the DOC at path with the same-named .prm, falling
+// back to DefaultIMOVEINLayout when there is none.
+func ReadIMOVEINFile(path string) (IMOVEINFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return IMOVEINFile{}, err
+	}
+	f := IMOVEINFile{Path: path, Layout: DefaultIMOVEINLayout()}
+	prmPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".prm"
+	if prm, err := os.ReadFile(prmPath); err == nil {
+		layout, err := ParsePRM(prm)
+		if err != nil {
+			return IMOVEINFile{}, fmt.Errorf("%s: %w", prmPath, err)
+		}
+		f.PRMPath, f.Layout = prmPath, layout
+		f.LayoutNotes = layout.Differences(DefaultIMOVEINLayout())
+	}
+	f.Records = f.Layout.ParseDOC(data)
+	return f, nil
+}
@@ -0,0 +1,121 @@
+package hasavshevet
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"erp-connector/internal/config"
+)
+
+func TestParsePRM_MatchesDefaultLayout(t *testing.T) {
+	layout, err := ParsePRM(generatePRM())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notes := layout.Differences(DefaultIMOVEINLayout()); len(notes) > 0 {
+		t.Errorf("generated PRM differs from the default layout: %v", notes)
+	}
+	if f := layout.Fields[20]; f.Key != "line22" || f.Title != imoveInFieldTitles["line22"] || f.Width != 20 {
+		t.Errorf("line22 = %+v", f)
+	}
+}
+
+// TestParseDOC_RoundTrip decodes what buildIMOVEIN and generateDOC wrote.
+func TestParseDOC_RoundTrip(t *testing.T) {
+	req := mappingOrder()
+	req.Details = append(req.Details, OrderLineItem{SKU: "SKU-002", Title: "שולחן", Quantity: 3, Batch: "LOT-42"})
+	hdr, moves := buildIMOVEIN(config.HasavshevetConfig{}, 1000295, accountInfo{AccountKey: "CUST001"}, req, 1, vatDecision{Rate: 18})
+	doc, _ := generateDOC(hdr, moves)
+
+	layout := DefaultIMOVEINLayout()
+	records := layout.ParseDOC(doc)
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+	r := records[1]
+	for key, want := range map[string]string{"line2": "CUST001", "line22": "SKU-002", "line28": "שולחן", "line41": "LOT-42", "line37": "leave at gate"} {
+		if got := r.Value(key).Value; got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if w := r.Warnings(layout); len(w) > 0 {
+		t.Errorf("unexpected warnings: %v", w)
+	}
+}
+
+func TestParseDOC_FlagsOverflowAndTruncation(t *testing.T) {
+	hdr := stockHeader{AccountKey: "CUST001", Remarks: strings.Repeat("ר", 300)}
+	rec := buildDOCRecord(hdr, stockMove{ItemKey: "SKU-001", Quantity: "1234567890"})
+	layout := DefaultIMOVEINLayout()
+
+	long := append(append([]byte{}, rec...), "XY"...)
+	short := rec[:100]
+	records := layout.ParseDOC(bytes.Join([][]byte{rec, long, short}, []byte("\r\n")))
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want 3", len(records))
+	}
+
+	if !records[0].Value("line37").Full {
+		t.Error("remarks filling line37 should be flagged")
+	}
+	if records[0].Value("line23").Full {
+		t.Error("a number filling its field should not be flagged")
+	}
+	if n := records[1].Overflow(layout); n != 2 {
+		t.Errorf("overflow = %d, want 2", n)
+	}
+	if !records[2].Value("line22").Missing {
+		t.Error("fields past a short record should be missing")
+	}
+	if w := records[2].Warnings(layout); len(w) == 0 || !strings.Contains(w[0], "short") {
+		t.Errorf("warnings = %v", w)
+	}
+}
+
+func TestDiffDOC(t *testing.T) {
+	layout := DefaultIMOVEINLayout()
+	a := layout.ParseDOC(buildDOCRecord(stockHeader{AccountKey: "CUST001"}, stockMove{ItemKey: "SKU-001", Quantity: "1.00"}))
+	b := layout.ParseDOC(append(append(buildDOCRecord(stockHeader{AccountKey: "CUST001"}, stockMove{ItemKey: "SKU-001", Quantity: "2.00"}), '\n'),
+		buildDOCRecord(stockHeader{}, stockMove{})...))
+
+	diffs := DiffDOC(a, b)
+	if len(diffs) != 2 {
+		t.Fatalf("diffs = %+v", diffs)
+	}
+	if d := diffs[0]; d.Record != 1 || d.Key != "line23" || d.A != "1.00" || d.B != "2.00" {
+		t.Errorf("diffs[0] = %+v", d)
+	}
+	if d := diffs[1]; d.Record != 2 || d.Key != "" || d.A != "(no record)" {
+		t.Errorf("diffs[1] = %+v", d)
+	}
+}
+
+func TestReadIMOVEINFile_History(t *testing.T) {
+	dir := t.TempDir()
+	path := HistoryDOCPath(dir, 1000295)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	doc, _ := generateDOC(stockHeader{AccountKey: "CUST001"}, []stockMove{{ItemKey: "SKU-001"}})
+	prm := bytes.Replace(generatePRM(), []byte("2891 ;"), []byte("2890 ;"), 1)
+	if err := os.WriteFile(path, doc, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(strings.TrimSuffix(path, ".doc")+".prm", prm, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := ReadIMOVEINFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.PRMPath == "" || len(f.LayoutNotes) != 1 || len(f.Records) != 1 {
+		t.Errorf("file = %+v", f)
+	}
+	if n := f.Records[0].Overflow(f.Layout); n != 1 {
+		t.Errorf("overflow against the history PRM = %d, want 1", n)
+	}
+}
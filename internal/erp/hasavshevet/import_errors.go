@@ -0,0 +1,229 @@
+package hasavshevet
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+
+	"erp-connector/internal/erp"
+)
+
+// defaultImportErrorFiles are the file patterns searched for has.exe error
+// output when hasavshevet.importErrorFiles is not set. A .log file is a run
+// log, not an error file: only its lines that read like errors are kept.
+var defaultImportErrorFiles = []string{"*.err", "*error*.txt", "*.log"}
+
+// maxImportErrorFile bounds how much of one error file is read.
+const maxImportErrorFile = 1 << 20
+
+// ImportIssue is one rejected record or field reported by the importer.
+type ImportIssue = erp.JobError
+
+var (
+	// importRecordRe matches the record (DOC line) an error refers to:
+	// "record 3", "רשומה 3", "שורה: 3", "line 3", "row #3".
+	importRecordRe = regexp.MustCompile(`(?i)(?:record|rec\.|row|line|רשומה|שורה)\s*(?:no\.?|#|מס['׳]?)?\s*[:=]?\s*(\d+)`)
+	// importFieldRe matches a PRM field number: "field 22", "שדה 22" or the
+	// PRM key itself, "line22".
+	importFieldRe = regexp.MustCompile(`(?i)(?:(?:field|שדה)\s*(?:no\.?|#|מס['׳]?)?\s*[:=]?\s*|\bline)(\d+)\b`)
+	// importColumnRe matches a 1-based character position in the record.
+	importColumnRe = regexp.MustCompile(`(?i)(?:column|col\.|position|pos\.|offset|עמודה|מיקום)\s*[:=]?\s*(\d+)`)
+	// importErrorWordRe selects error lines from the importer's console
+	// output and .log files; every other line of an error file is an error.
+	importErrorWordRe = regexp.MustCompile(`(?i)error|rejected|invalid|not found|unknown|failed|שגיאה|שגוי|נדחה|לא קיים|לא נמצא`)
+)
+
+// collectImportErrors parses the error files the importer wrote since start
+// and its console output, and copies the files into each of historyDirs.
+func (s *Sender) collectImportErrors(req OrderRequest, output string, historyDirs []string, start time.Time) []ImportIssue {
+	var issues []ImportIssue
+	for _, path := range s.importErrorFiles(start) {
+		data, err := readImportErrorFile(path)
+		if err != nil {
+			s.log.Warn(fmt.Sprintf("read importer error file %s: %v", path, err))
+			continue
+		}
+		for _, historyDir := range historyDirs {
+			if err := os.WriteFile(filepath.Join(historyDir, filepath.Base(path)), data, 0o644); err != nil {
+				s.log.Warn(fmt.Sprintf("copy importer error file %s: %v", path, err))
+			}
+		}
+		isLog := strings.EqualFold(filepath.Ext(path), ".log")
+		issues = append(issues, parseImportErrors(decodeImportText(data), req, isLog)...)
+	}
+	// has.exe often echoes its error file to the console.
+	for _, issue := range parseImportErrors(output, req, true) {
+		if !slices.Contains(issues, issue) {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// importErrorFiles lists the files matching hasavshevet.importErrorFiles,
+// in SendOrderDir and the importer's own directory, modified since start.
+// Relative patterns are resolved against both directories.
+func (s *Sender) importErrorFiles(start time.Time) []string {
+	patterns := s.cfg.Hasavshevet.ImportErrorFiles
+	if len(patterns) == 0 {
+		patterns = defaultImportErrorFiles
+	}
+	dirs := []string{s.cfg.SendOrderDir}
+	for _, p := range []string{s.cfg.HasBatFile, s.cfg.HasExePath} {
+		if strings.TrimSpace(p) != "" {
+			dirs = append(dirs, filepath.Dir(p))
+		}
+	}
+
+	// File systems with coarse timestamps may stamp a file written right
+	// after start a little earlier.
+	since := start.Add(-2 * time.Second)
+	var out []string
+	for _, pattern := range patterns {
+		var candidates []string
+		if filepath.IsAbs(pattern) {
+			candidates = globFold(filepath.Dir(pattern), filepath.Base(pattern))
+		} else {
+			for _, dir := range dirs {
+				candidates = append(candidates, globFold(dir, pattern)...)
+			}
+		}
+		for _, path := range candidates {
+			info, err := os.Stat(path)
+			if err != nil || info.IsDir() || info.ModTime().Before(since) || slices.Contains(out, path) {
+				continue
+			}
+			out = append(out, path)
+		}
+	}
+	return out
+}
+
+// globFold lists the files in dir whose name matches pattern, ignoring case
+// as Windows does.
+func globFold(dir, pattern string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	pattern = strings.ToLower(pattern)
+	var out []string
+	for _, e := range entries {
+		if ok, _ := filepath.Match(pattern, strings.ToLower(e.Name())); ok {
+			out = append(out, filepath.Join(dir, e.Name()))
+		}
+	}
+	return out
+}
+
+func readImportErrorFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(f, maxImportErrorFile))
+}
+
+// decodeImportText returns importer output as UTF-8. has.exe writes
+// Windows-1255 like the IMOVEIN files it reads.
+func decodeImportText(data []byte) string {
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	out, err := charmap.Windows1255.NewDecoder().Bytes(data)
+	if err != nil {
+		return string(data)
+	}
+	return string(out)
+}
+
+// parseImportErrors turns importer error text into issues. Record numbers are
+// DOC lines, one per order line, so record N is req.Details[N-1]; the field
+// comes from a field number, a column in the record, or a field title in the
+// message. With errorLinesOnly set (console output and logs) only lines that
+// read like errors are kept.
+func parseImportErrors(text string, req OrderRequest, errorLinesOnly bool) []ImportIssue {
+	var issues []ImportIssue
+	for _, raw := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" || errorLinesOnly && !importErrorWordRe.MatchString(line) {
+			continue
+		}
+		issue := ImportIssue{Message: line}
+
+		// Strip field keys first so "line22" is never read as record 22.
+		rest := line
+		if m := importFieldRe.FindStringSubmatchIndex(rest); m != nil {
+			if n, err := strconv.Atoi(rest[m[2]:m[3]]); err == nil {
+				if key := fmt.Sprintf("line%d", n); imoveInFieldTitles[key] != "" {
+					issue.Field = key
+				}
+			}
+			rest = rest[:m[0]] + " " + rest[m[1]:]
+		}
+		if m := importColumnRe.FindStringSubmatchIndex(rest); m != nil {
+			if col, err := strconv.Atoi(rest[m[2]:m[3]]); err == nil && issue.Field == "" {
+				issue.Field = fieldAtColumn(col)
+			}
+			rest = rest[:m[0]] + " " + rest[m[1]:]
+		}
+		if m := importRecordRe.FindStringSubmatch(rest); m != nil {
+			if n, err := strconv.Atoi(m[1]); err == nil && n >= 1 && n <= len(req.Details) {
+				issue.Line = n
+				issue.SKU = req.Details[n-1].SKU
+				issue.Message = strings.Trim(strings.Replace(line, m[0], " ", 1), " \t:-–,;")
+			}
+		}
+		if issue.Field == "" {
+			issue.Field = fieldByTitle(line)
+		}
+		if issue.Field != "" {
+			issue.FieldTitle = imoveInFieldTitles[issue.Field]
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// fieldAtColumn maps a 1-based character position in a DOC record to the
+// PRM field covering it (see generatePRM), or "" past the record end.
+func fieldAtColumn(col int) string {
+	pos := 1
+	for i := 2; i <= 87; i++ {
+		key := fmt.Sprintf("line%d", i)
+		length := imoveInFieldLengths[key]
+		if length > 0 && col >= pos && col < pos+length {
+			return key
+		}
+		pos += length
+	}
+	return ""
+}
+
+// importTitleDigitsRe strips the field number and punctuation from a title,
+// leaving the name has.exe uses in its messages.
+var importTitleDigitsRe = regexp.MustCompile(`[\d%\-\t]+`)
+
+// fieldByTitle finds the field whose title appears in msg. The longest title
+// wins, so "מפתח פריט" beats "פריט"; ties go to the lowest field number.
+func fieldByTitle(msg string) string {
+	best, bestLen := "", 0
+	for i := 2; i <= 87; i++ {
+		key := fmt.Sprintf("line%d", i)
+		name := strings.Join(strings.Fields(importTitleDigitsRe.ReplaceAllString(imoveInFieldTitles[key], " ")), " ")
+		if n := utf8.RuneCountInString(name); n > bestLen && n >= 3 && strings.Contains(msg, name) {
+			best, bestLen = key, n
+		}
+	}
+	return best
+}
@@ -0,0 +1,136 @@
+package hasavshevet
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+
+	"erp-connector/internal/config"
+	"erp-connector/internal/logger"
+)
+
+func importErrorsOrder() OrderRequest {
+	return OrderRequest{Details: []OrderLineItem{{SKU: "SKU-001"}, {SKU: "SKU-002"}, {SKU: "SKU-003"}}}
+}
+
+func TestParseImportErrors(t *testing.T) {
+	tests := []struct {
+		name, text       string
+		line             int
+		sku, field, want string
+	}{
+		{"record and field number", "Record 3: field 22 - unknown SKU", 3, "SKU-003", "line22", "field 22 - unknown SKU"},
+		{"hebrew record and title", "שורה 2: מפתח פריט לא קיים", 2, "SKU-002", "line22", "מפתח פריט לא קיים"},
+		{"prm key", "line23 invalid quantity, line 1", 1, "SKU-001", "line23", "line23 invalid quantity"},
+		{"column offset", "record 2 column 3 invalid", 2, "SKU-002", "line2", "column 3 invalid"},
+		{"header error", "סוג מסמך 99 לא קיים", 0, "", "line4", "סוג מסמך 99 לא קיים"},
+		{"record past the order", "record 9: unknown SKU", 0, "", "", "record 9: unknown SKU"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := parseImportErrors(tt.text, importErrorsOrder(), false)
+			if len(issues) != 1 {
+				t.Fatalf("issues = %+v, want 1", issues)
+			}
+			got := issues[0]
+			if got.Line != tt.line || got.SKU != tt.sku || got.Field != tt.field || got.Message != tt.want {
+				t.Errorf("issue = %+v, want line=%d sku=%s field=%s message=%q", got, tt.line, tt.sku, tt.field, tt.want)
+			}
+			if tt.field != "" && got.FieldTitle != imoveInFieldTitles[tt.field] {
+				t.Errorf("fieldTitle = %q", got.FieldTitle)
+			}
+		})
+	}
+}
+
+func TestParseImportErrors_ConsoleKeepsErrorLines(t *testing.T) {
+	out := "Hashavshevet import v12\r\nreading IMOVEIN.doc\r\nrecord 3: unknown SKU\r\n1 records imported\r\n"
+	issues := parseImportErrors(out, importErrorsOrder(), true)
+	if len(issues) != 1 || issues[0].String() != "line 3 (SKU-003): unknown SKU" {
+		t.Fatalf("issues = %+v", issues)
+	}
+}
+
+func TestFieldAtColumn(t *testing.T) {
+	tests := []struct {
+		col  int
+		want string
+	}{
+		{1, "line2"}, {15, "line2"}, {16, "line3"}, {prmTotalRecordLength, "line87"}, {prmTotalRecordLength + 1, ""},
+	}
+	for _, tt := range tests {
+		if got := fieldAtColumn(tt.col); got != tt.want {
+			t.Errorf("fieldAtColumn(%d) = %q, want %q", tt.col, got, tt.want)
+		}
+	}
+}
+
+func TestCollectImportErrors(t *testing.T) {
+	dir := t.TempDir()
+	historyDir := filepath.Join(dir, "history", "1000295")
+	if err := os.MkdirAll(historyDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+
+	errText, _ := charmap.Windows1255.NewEncoder().String("רשומה 3: מפתח פריט לא קיים\r\n")
+	if err := os.WriteFile(filepath.Join(dir, "IMOVEIN.ERR"), []byte(errText), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stale := filepath.Join(dir, "old.log")
+	if err := os.WriteFile(stale, []byte("record 1: error from yesterday"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := start.Add(-time.Hour)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Sender{cfg: config.Config{SendOrderDir: dir}, log: logger.NewStderr()}
+	issues := s.collectImportErrors(importErrorsOrder(), "", []string{historyDir}, start)
+	if len(issues) != 1 {
+		t.Fatalf("issues = %+v, want the fresh IMOVEIN.ERR record only", issues)
+	}
+	if got := issues[0]; got.Line != 3 || got.Field != "line22" || !strings.Contains(got.Message, "לא קיים") {
+		t.Errorf("issue = %+v", got)
+	}
+	if _, err := os.Stat(filepath.Join(historyDir, "IMOVEIN.ERR")); err != nil {
+		t.Errorf("error file not copied to history: %v", err)
+	}
+}
+
+// TestCollectImportErrors_LogKeepsErrorLines checks that a run log is not
+// read as an error file: its informational lines yield no issues.
+func TestCollectImportErrors_LogKeepsErrorLines(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Now().Add(-time.Second)
+	s := &Sender{cfg: config.Config{SendOrderDir: dir}, log: logger.NewStderr()}
+	logPath := filepath.Join(dir, "has.log")
+
+	info := "Hashavshevet import v12\r\nreading IMOVEIN.doc\r\n3 records imported\r\n"
+	if err := os.WriteFile(logPath, []byte(info), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if issues := s.collectImportErrors(importErrorsOrder(), "", nil, start); len(issues) != 0 {
+		t.Errorf("informational log: issues = %+v, want none", issues)
+	}
+
+	if err := os.WriteFile(logPath, []byte(info+"record 2: invalid price\r\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	issues := s.collectImportErrors(importErrorsOrder(), "", nil, start)
+	if len(issues) != 1 || issues[0].String() != "line 2 (SKU-002): invalid price" {
+		t.Errorf("log with an error: issues = %+v", issues)
+	}
+}
+
+func TestImportError_ListsIssues(t *testing.T) {
+	err := &ImportError{OrderNumber: 1000295, ExitCode: 1, Issues: []ImportIssue{{Line: 3, SKU: "SKU-003", Message: "unknown SKU"}}}
+	if got := err.Error(); !strings.Contains(got, "import failed for order 1000295: line 3 (SKU-003): unknown SKU") {
+		t.Errorf("error = %q", got)
+	}
+}
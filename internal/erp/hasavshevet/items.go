@@ -0,0 +1,125 @@
+package hasavshevet
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"erp-connector/internal/db"
+	"erp-connector/internal/erp"
+)
+
+// itemColumns are the Items columns read for an item. SortGroup is the item
+// group; Items keeps a single unit of measure and no change timestamp or
+// manufacturer, so those stay empty.
+const itemColumns = `
+	I.ItemKey, I.ItemName, I.BarCode, I.SortGroup,
+	I.Unit, I.Packing, I.VatExampt, I.Frozen`
+
+func queryItems(ctx context.Context, dbConn *sql.DB, where string, args []any, pageSize, offset int) ([]erp.Item, int, error) {
+	query := fmt.Sprintf(`
+		SELECT %s,
+			COUNT(*) OVER () AS TotalRows
+		FROM dbo.Items AS I WITH (NOLOCK)
+		WHERE %s
+		ORDER BY I.ItemKey
+		OFFSET @offset ROWS FETCH NEXT @limit ROWS ONLY;`, itemColumns, where)
+	args = append(args, sql.Named("offset", offset), sql.Named("limit", pageSize))
+
+	rows, err := dbConn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	items := []erp.Item{}
+	total := 0
+	for rows.Next() {
+		var (
+			key                        string
+			name, barcode, group, unit sql.NullString
+			packing                    sql.NullFloat64
+			vatExempt, frozen          sql.NullInt64
+		)
+		if err := rows.Scan(&key, &name, &barcode, &group,
+			&unit, &packing, &vatExempt, &frozen, &total); err != nil {
+			return nil, 0, err
+		}
+		it := erp.Item{
+			SKU:           strings.TrimSpace(key),
+			Name:          strings.TrimSpace(name.String),
+			Barcodes:      erp.AppendNonEmpty(nil, barcode.String),
+			Group:         strings.TrimSpace(group.String),
+			SalesUnit:     strings.TrimSpace(unit.String),
+			InventoryUnit: strings.TrimSpace(unit.String),
+			PackSize:      1,
+			VATClass:      "standard",
+			Active:        frozen.Int64 == 0,
+		}
+		if packing.Valid && packing.Float64 > 0 {
+			it.PackSize = packing.Float64
+		}
+		if vatExempt.Int64 != 0 {
+			it.VATClass = "exempt"
+		}
+		items = append(items, it)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+// GetItem reads one item by ItemKey.
+func GetItem(ctx context.Context, dbConn *sql.DB, sku string) (erp.Item, error) {
+	items, _, err := queryItems(ctx, dbConn, "I.ItemKey = @sku",
+		[]any{sql.Named("sku", strings.TrimSpace(sku))}, 1, 0)
+	if err != nil {
+		return erp.Item{}, err
+	}
+	if len(items) == 0 {
+		return erp.Item{}, erp.ErrNotFound
+	}
+	return items[0], nil
+}
+
+// SearchItems pages through Items with the filters of q. Manufacturer and
+// modifiedSince have no Items column and are rejected.
+func SearchItems(ctx context.Context, dbConn *sql.DB, q erp.ItemSearch) (erp.ItemPage, error) {
+	where, args, err := itemSearchFilter(q)
+	if err != nil {
+		return erp.ItemPage{}, err
+	}
+	items, total, err := queryItems(ctx, dbConn, where, args, q.PageSize, q.Offset())
+	if err != nil {
+		return erp.ItemPage{}, err
+	}
+	return erp.ItemPage{Items: items, Page: q.Page, PageSize: q.PageSize, Total: total}, nil
+}
+
+func itemSearchFilter(q erp.ItemSearch) (string, []any, error) {
+	if strings.TrimSpace(q.Manufacturer) != "" {
+		return "", nil, fmt.Errorf("%w: manufacturer filter is not available for Hasavshevet", erp.ErrInvalidRequest)
+	}
+	if !q.ModifiedSince.IsZero() {
+		return "", nil, fmt.Errorf("%w: modifiedSince filter is not available for Hasavshevet", erp.ErrInvalidRequest)
+	}
+
+	conds := []string{"1 = 1"}
+	var args []any
+	if text := strings.TrimSpace(q.Query); text != "" {
+		like := db.EscapeLike(text)
+		conds = append(conds, "(I.ItemKey LIKE @prefix OR I.ItemName LIKE @contains)")
+		args = append(args, sql.Named("prefix", like+"%"), sql.Named("contains", "%"+like+"%"))
+	}
+	if bc := strings.TrimSpace(q.Barcode); bc != "" {
+		conds = append(conds, "I.BarCode = @barcode")
+		args = append(args, sql.Named("barcode", bc))
+	}
+	if g := strings.TrimSpace(q.Group); g != "" {
+		conds = append(conds, "I.SortGroup = @group")
+		args = append(args, sql.Named("group", g))
+	}
+	return strings.Join(conds, " AND "), args, nil
+}
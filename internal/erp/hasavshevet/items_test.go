@@ -0,0 +1,30 @@
+package hasavshevet
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"erp-connector/internal/erp"
+)
+
+// TestItemSearchFilter_Unsupported verifies filters without an Items column are rejected.
+func TestItemSearchFilter_Unsupported(t *testing.T) {
+	for _, q := range []erp.ItemSearch{
+		{Manufacturer: "Acme"},
+		{ModifiedSince: time.Now()},
+	} {
+		if _, _, err := itemSearchFilter(q); !errors.Is(err, erp.ErrInvalidRequest) {
+			t.Errorf("itemSearchFilter(%+v) error = %v, want ErrInvalidRequest", q, err)
+		}
+	}
+
+	where, args, err := itemSearchFilter(erp.ItemSearch{Query: "wid", Barcode: "729", Group: "100"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(where, "I.BarCode = @barcode") || !strings.Contains(where, "I.SortGroup = @group") || len(args) != 4 {
+		t.Errorf("where = %s, args = %v", where, args)
+	}
+}
@@ -0,0 +1,119 @@
+package hasavshevet
+
+import (
+	"slices"
+
+	"erp-connector/internal/erp"
+)
+
+// defaultPriorityMaxSkips applies when hasavshevet.priorityMaxSkips is not
+// set.
+const defaultPriorityMaxSkips = 4
+
+// Queue lanes, highest priority first.
+const (
+	laneUrgent = iota
+	laneNormal
+	laneBulk
+	laneCount
+)
+
+// laneOf maps an OrderRequest.Priority to its lane; empty is normal.
+func laneOf(priority string) int {
+	switch priority {
+	case erp.PriorityUrgent:
+		return laneUrgent
+	case erp.PriorityBulk:
+		return laneBulk
+	}
+	return laneNormal
+}
+
+// priorityMaxSkips returns how many jobs may be taken ahead of a waiting
+// lower priority job.
+func (s *Sender) priorityMaxSkips() int {
+	if s == nil || s.cfg.Hasavshevet.PriorityMaxSkips <= 0 {
+		return defaultPriorityMaxSkips
+	}
+	return s.cfg.Hasavshevet.PriorityMaxSkips
+}
+
+// lanes holds the waiting jobs of each priority in submission order (seq).
+// skips[l] counts the jobs taken from higher lanes while lane l had jobs
+// waiting.
+type lanes struct {
+	jobs  [laneCount][]*orderJob
+	skips [laneCount]int
+}
+
+// push adds job to its lane behind the jobs submitted before it, so a job
+// moved to another lane keeps its place by submission time.
+func (l *lanes) push(job *orderJob) {
+	jobs := l.jobs[job.lane]
+	i := len(jobs)
+	for i > 0 && jobs[i-1].seq > job.seq {
+		i--
+	}
+	l.jobs[job.lane] = slices.Insert(jobs, i, job)
+}
+
+func (l *lanes) len() int {
+	n := 0
+	for _, jobs := range l.jobs {
+		n += len(jobs)
+	}
+	return n
+}
+
+// remove takes job out of its lane and reports whether it was waiting there.
+func (l *lanes) remove(job *orderJob) bool {
+	jobs := l.jobs[job.lane]
+	for i, j := range jobs {
+		if j == job {
+			l.jobs[job.lane] = append(jobs[:i:i], jobs[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// take removes and returns the next job, or nil when every lane is empty.
+// That is the oldest job of the highest non-empty lane, unless a lower lane
+// has been passed over maxSkips times; then the highest such lane goes
+// first, so bulk orders cannot starve.
+func (l *lanes) take(maxSkips int) *orderJob {
+	next := -1
+	for i := range l.jobs {
+		if len(l.jobs[i]) == 0 {
+			l.skips[i] = 0
+			continue
+		}
+		if next < 0 {
+			next = i
+		} else if l.skips[i] >= maxSkips {
+			next = i
+			break
+		}
+	}
+	if next < 0 {
+		return nil
+	}
+	for i := next + 1; i < laneCount; i++ {
+		if len(l.jobs[i]) > 0 {
+			l.skips[i]++
+		}
+	}
+	l.skips[next] = 0
+	job := l.jobs[next][0]
+	l.jobs[next] = l.jobs[next][1:]
+	return job
+}
+
+// order returns the waiting jobs in the order take would return them.
+func (l lanes) order(maxSkips int) []*orderJob {
+	var out []*orderJob
+	for job := l.take(maxSkips); job != nil; job = l.take(maxSkips) {
+		out = append(out, job)
+	}
+	return out
+}
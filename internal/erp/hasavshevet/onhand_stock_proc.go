@@ -1,10 +1,7 @@
 package hasavshevet
 
-import (
-	"context"
-	"database/sql"
-	"errors"
-)
+// onHandStockVersion is the installed version of GetOnHandStockForSkus.
+const onHandStockVersion = 1
 
 const onHandStockProcName = "dbo.GetOnHandStockForSkus"
 
@@ -89,25 +86,3 @@ BEGIN
 	RETURN 0;
 END
 `
-
-func EnsureOnHandStockForSkusProcedure(ctx context.Context, dbConn *sql.DB) (bool, error) {
-	if dbConn == nil {
-		return false, errors.New("db connection is required")
-	}
-	if ctx == nil {
-		ctx = context.Background()
-	}
-
-	exists, err := procedureExists(ctx, dbConn, onHandStockProcName)
-	if err != nil {
-		return false, err
-	}
-	if exists {
-		return false, nil
-	}
-
-	if _, err := dbConn.ExecContext(ctx, onHandStockProcedureSQL); err != nil {
-		return false, err
-	}
-	return true, nil
-}
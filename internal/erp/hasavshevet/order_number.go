@@ -24,6 +24,20 @@ func NewOrderNumberStore(path string) *OrderNumberStore {
 	return &OrderNumberStore{path: path}
 }
 
+// Peek returns the number Next would return, without reserving it.
+func (s *OrderNumberStore) Peek() (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var data orderNumberFile
+	if b, err := os.ReadFile(s.path); err == nil {
+		_ = json.Unmarshal(b, &data)
+	} else if !os.IsNotExist(err) {
+		return 0, err
+	}
+	return data.LastOrderNumber + 1, nil
+}
+
 // Next atomically increments the order number, persists it, and returns the new value.
 // It is safe for concurrent use from multiple goroutines; the backing mutex
 // serialises all reads and writes.
@@ -108,3 +108,20 @@ func TestOrderNumberStore_Concurrent(t *testing.T) {
 		seen[n] = true
 	}
 }
+
+// TestOrderNumberStore_Peek verifies Peek reports the next number without
+// reserving it.
+func TestOrderNumberStore_Peek(t *testing.T) {
+	store := NewOrderNumberStore(filepath.Join(t.TempDir(), "lastOrderNumber.json"))
+	for range 2 {
+		if n, err := store.Peek(); err != nil || n != 1 {
+			t.Fatalf("Peek() = %d, %v; want 1", n, err)
+		}
+	}
+	if n, _ := store.Next(); n != 1 {
+		t.Fatalf("Next() after Peek = %d, want 1", n)
+	}
+	if n, _ := store.Peek(); n != 2 {
+		t.Errorf("Peek() after Next = %d, want 2", n)
+	}
+}
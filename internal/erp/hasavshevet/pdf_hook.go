@@ -43,6 +43,10 @@ func NewPDFPostOrderHook(cfg config.Config, pdfGen *pdf.Generator, emailSend *em
 // non-fatal — the order itself was already written to the ERP successfully.
 func (h *PDFPostOrderHook) AfterOrder(ctx context.Context, req OrderRequest, result *OrderResult) error {
 	orderNum := fmt.Sprintf("%d", result.OrderNumber)
+	if result.OrderNumber == 0 && result.DocumentNumber != "" {
+		// Priority numbers its documents itself (e.g. SO2600123).
+		orderNum = result.DocumentNumber
+	}
 
 	h.log.Info(fmt.Sprintf(
 		"AfterOrder invoked: order=%s documentType=%q UseRemoteTemplate=%v PrintAfterOrder=%v EmailAfterOrder=%v hasCustomerEmail=%v tokenCount=%d",
@@ -88,7 +92,8 @@ func (h *PDFPostOrderHook) AfterOrder(ctx context.Context, req OrderRequest, res
 // the existing chromedp HTML→PDF pipeline. The token never appears in errors.
 //
 // documentNumber is the user-visible order number (e.g. "1000011" — the
-// externalOrderId / Hasavshevet order number from result.OrderNumber). The
+// externalOrderId / Hasavshevet order number from result.OrderNumber, or
+// Priority's document number when the ERP assigns its own). The
 // backend resolves this via History.orderExtId. Passing req.HistoryID (an
 // internal UUID) here would 404 because the backend has no row keyed by that.
 func (h *PDFPostOrderHook) fetchRemoteHTMLAndRenderPDF(ctx context.Context, token string, req OrderRequest, documentNumber string) ([]byte, error) {
@@ -0,0 +1,84 @@
+package hasavshevet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"erp-connector/internal/erp"
+)
+
+// PreviewOrder runs sendOrder's validation, account, currency rate and VAT
+// lookups and builds the IMOVEIN records, then returns them decoded field by
+// field. It writes no files, reserves no order number and does not run
+// has.exe; OrderNumber is the number the order would get if sent now.
+func (s *Sender) PreviewOrder(ctx context.Context, req OrderRequest) (erp.OrderPreview, error) {
+	dbName := strings.TrimSpace(s.cfg.DB.Database)
+	if dbName == "" {
+		return erp.OrderPreview{}, errors.New("database is not configured (set db.database in config)")
+	}
+	if err := validateOrderRequest(req); err != nil {
+		return erp.OrderPreview{}, fmt.Errorf("%w: %v", erp.ErrInvalidRequest, err)
+	}
+
+	var orderNum int64
+	if s.numberStore != nil {
+		n, err := s.numberStore.Peek()
+		if err != nil {
+			return erp.OrderPreview{}, fmt.Errorf("read order number: %w", err)
+		}
+		orderNum = n
+	}
+
+	prepared, err := s.prepareOrder(ctx, dbName, req, orderNum)
+	if err != nil {
+		return erp.OrderPreview{}, err
+	}
+	return previewIMOVEIN(orderNum, prepared), nil
+}
+
+// previewIMOVEIN decodes the records generateDOC would write for prepared
+// and lists the fields padW1255 cuts.
+func previewIMOVEIN(orderNum int64, prepared preparedOrder) erp.OrderPreview {
+	vatRate := prepared.vat.Rate
+	a := prepared.account
+	p := erp.OrderPreview{
+		OrderNumber: orderNum,
+		Account: AccountInfo{
+			AccountKey: a.AccountKey,
+			FullName:   a.FullName,
+			Address:    a.Address,
+			City:       a.City,
+			Phone:      a.Phone,
+		},
+		CurrencyRate: prepared.rate,
+		VATRate:      &vatRate,
+		VATExempt:    prepared.vat.Exempt,
+	}
+
+	layout := DefaultIMOVEINLayout()
+	for i, m := range prepared.moves {
+		rec := layout.ParseDOC(buildDOCRecord(prepared.hdr, m))[0]
+		values := docRecordValues(prepared.hdr, m)
+		pr := erp.PreviewRecord{Line: i + 1}
+		for _, v := range rec.Values {
+			if v.Value == "" {
+				continue
+			}
+			pr.Fields = append(pr.Fields, erp.PreviewField{
+				Key:       v.Key,
+				Title:     v.Title,
+				Start:     v.Start,
+				Width:     v.Width,
+				Value:     v.Value,
+				Truncated: len([]rune(values[v.Key])) > v.Width,
+			})
+		}
+		p.Records = append(p.Records, pr)
+		for _, t := range docTruncations(prepared.hdr, m) {
+			p.Warnings = append(p.Warnings, fmt.Sprintf("line %d: %s", i+1, t))
+		}
+	}
+	return p
+}
@@ -0,0 +1,46 @@
+package hasavshevet
+
+import (
+	"strings"
+	"testing"
+
+	"erp-connector/internal/config"
+)
+
+func TestPreviewIMOVEIN(t *testing.T) {
+	req := mappingOrder()
+	req.Comment = strings.Repeat("א", 300)
+	account := accountInfo{AccountKey: "CUST001", FullName: "Acme"}
+	hdr, moves := buildIMOVEIN(config.HasavshevetConfig{}, 1000295, account, req, 3.7, vatDecision{Rate: 18})
+
+	p := previewIMOVEIN(1000295, preparedOrder{account: account, rate: 3.7, vat: vatDecision{Rate: 18}, hdr: hdr, moves: moves})
+	if p.OrderNumber != 1000295 || p.Account.FullName != "Acme" || p.CurrencyRate != 3.7 || *p.VATRate != 18 {
+		t.Errorf("preview = %+v", p)
+	}
+	if len(p.Records) != 1 {
+		t.Fatalf("records = %d, want 1", len(p.Records))
+	}
+	fields := map[string]string{}
+	for _, f := range p.Records[0].Fields {
+		fields[f.Key] = f.Value
+	}
+	if fields["line22"] != "SKU-001" || fields["line4"] != "32" || fields["line18"] != "18.00" {
+		t.Errorf("fields = %v", fields)
+	}
+	// sanitizeComment cuts remarks to 250 before the record, so nothing is
+	// truncated by padding; an over-long title is.
+	if len(p.Warnings) != 0 {
+		t.Errorf("warnings = %v", p.Warnings)
+	}
+
+	moves[0].ItemName = strings.Repeat("x", 120)
+	p = previewIMOVEIN(1, preparedOrder{hdr: hdr, moves: moves})
+	if len(p.Warnings) != 1 || !strings.Contains(p.Warnings[0], "line28") {
+		t.Errorf("warnings = %v", p.Warnings)
+	}
+	for _, f := range p.Records[0].Fields {
+		if f.Key == "line28" && (!f.Truncated || len(f.Value) != 100) {
+			t.Errorf("line28 = %+v", f)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
@@ -41,7 +42,44 @@ type onHandStockRow struct {
 	TotalStock      sql.NullFloat64
 }
 
-const hasavshevetDefaultDocumentID = 1
+// defaultPriceDocumentID is the GPRICE document used when the request names
+// no documentType.
+const defaultPriceDocumentID = 1
+
+// priceDocumentID returns the DocumentID GPRICE should price with: the one
+// sendOrder writes to the IMOVEIN header for the same documentType, so the
+// quoted price is the one the imported document gets.
+func priceDocumentID(h config.HasavshevetConfig, documentType string) (int, error) {
+	documentType = strings.TrimSpace(documentType)
+	if documentType == "" {
+		return defaultPriceDocumentID, nil
+	}
+	_, docID := headerDocument(h, documentType, h.LocalCurrencyCode())
+	if docID == 0 {
+		return 0, fmt.Errorf("%w: invalid documentType %q; allowed: ORDER, QUOATE, RETURN", erp.ErrInvalidRequest, documentType)
+	}
+	return docID, nil
+}
+
+// quantitiesJSON encodes the SKU → quantity map GPRICE_Bulk joins on. It is
+// empty when every SKU is priced at 1, which keeps procedures installed
+// before @QuantitiesJson existed working for plain lookups.
+func quantitiesJSON(skus []string, quantities map[string]float64) (string, error) {
+	out := make(map[string]float64, len(skus))
+	for _, sku := range skus {
+		if q := erp.QuantityFor(quantities, sku); q != 1 {
+			out[sku] = q
+		}
+	}
+	if len(out) == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal(out)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
 
 func FetchPriceAndStock(ctx context.Context, dbConn *sql.DB, cfg config.Config, req erp.PriceStockRequest) (erp.PriceStockResult, error) {
 	if dbConn == nil {
@@ -56,14 +94,17 @@ func FetchPriceAndStock(ctx context.Context, dbConn *sql.DB, cfg config.Config,
 		return erp.PriceStockResult{Items: []erp.PriceStockItem{}}, nil
 	}
 
-	documentID := hasavshevetDefaultDocumentID
+	documentID, err := priceDocumentID(cfg.Hasavshevet, req.DocumentType)
+	if err != nil {
+		return erp.PriceStockResult{}, err
+	}
 	var datF any
 	if strings.TrimSpace(req.Date) != "" {
 		datF = strings.TrimSpace(req.Date)
 	}
 
 	customerID := strings.TrimSpace(req.UserExtID)
-	priceBySKU, err := fetchGPriceBulk(ctx, dbConn, customerID, skus, documentID, datF)
+	priceBySKU, err := fetchGPriceBulk(ctx, dbConn, customerID, skus, req.Quantities, documentID, datF)
 	if err != nil {
 		return erp.PriceStockResult{}, err
 	}
@@ -85,7 +126,7 @@ func FetchPriceAndStock(ctx context.Context, dbConn *sql.DB, cfg config.Config,
 			prices = nil
 		}
 
-		details := map[string]any{}
+		details := map[string]any{"quantity": erp.QuantityFor(req.Quantities, sku)}
 		if ok {
 			if rec.Currency != nil {
 				details["currency"] = *rec.Currency
@@ -106,10 +147,6 @@ func FetchPriceAndStock(ctx context.Context, dbConn *sql.DB, cfg config.Config,
 				details["documentId"] = *rec.DocumentID
 			}
 		}
-		if len(details) == 0 {
-			details = nil
-		}
-
 		items = append(items, erp.PriceStockItem{
 			SKU:              sku,
 			Prices:           prices,
@@ -121,7 +158,7 @@ func FetchPriceAndStock(ctx context.Context, dbConn *sql.DB, cfg config.Config,
 	return erp.PriceStockResult{Items: items}, nil
 }
 
-func fetchGPriceBulk(ctx context.Context, dbConn *sql.DB, customerID string, skus []string, documentID int, datF any) (map[string]gpriceRecord, error) {
+func fetchGPriceBulk(ctx context.Context, dbConn *sql.DB, customerID string, skus []string, quantities map[string]float64, documentID int, datF any) (map[string]gpriceRecord, error) {
 	if len(skus) == 0 {
 		return map[string]gpriceRecord{}, nil
 	}
@@ -130,6 +167,10 @@ func fetchGPriceBulk(ctx context.Context, dbConn *sql.DB, customerID string, sku
 	if err != nil {
 		return nil, err
 	}
+	qtyJSON, err := quantitiesJSON(skus, quantities)
+	if err != nil {
+		return nil, err
+	}
 
 	query := `
 		EXEC dbo.GPRICE_Bulk
@@ -138,13 +179,25 @@ func fetchGPriceBulk(ctx context.Context, dbConn *sql.DB, customerID string, sku
 			@DocumentID = @DocumentID,
 			@DatF = @DatF;
 	`
-
-	rows, err := dbConn.QueryContext(ctx, query,
+	args := []any{
 		sql.Named("CustomerId", customerID),
 		sql.Named("SkusJson", string(skusJSON)),
 		sql.Named("DocumentID", documentID),
 		sql.Named("DatF", datF),
-	)
+	}
+	if qtyJSON != "" {
+		query = `
+		EXEC dbo.GPRICE_Bulk
+			@CustomerId = @CustomerId,
+			@SkusJson = @SkusJson,
+			@DocumentID = @DocumentID,
+			@DatF = @DatF,
+			@QuantitiesJson = @QuantitiesJson;
+	`
+		args = append(args, sql.Named("QuantitiesJson", qtyJSON))
+	}
+
+	rows, err := dbConn.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
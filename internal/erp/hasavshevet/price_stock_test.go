@@ -0,0 +1,43 @@
+package hasavshevet
+
+import (
+	"errors"
+	"testing"
+
+	"erp-connector/internal/config"
+	"erp-connector/internal/erp"
+)
+
+// TestPriceDocumentID verifies prices use the DocumentID sendOrder imports with.
+func TestPriceDocumentID(t *testing.T) {
+	cases := map[string]int{"": 1, "ORDER": 30, "QUOATE": 40, "RETURN": 74}
+	for docType, want := range cases {
+		got, err := priceDocumentID(config.HasavshevetConfig{}, docType)
+		if err != nil {
+			t.Fatalf("priceDocumentID(%q) error: %v", docType, err)
+		}
+		if got != want {
+			t.Errorf("priceDocumentID(%q) = %d, want %d", docType, got, want)
+		}
+	}
+
+	if _, err := priceDocumentID(config.HasavshevetConfig{}, "INVOICE"); !errors.Is(err, erp.ErrInvalidRequest) {
+		t.Errorf("priceDocumentID(INVOICE) error = %v, want ErrInvalidRequest", err)
+	}
+}
+
+// TestQuantitiesJSON verifies only SKUs priced above/below 1 are sent.
+func TestQuantitiesJSON(t *testing.T) {
+	got, err := quantitiesJSON([]string{"A", "B"}, nil)
+	if err != nil || got != "" {
+		t.Fatalf("no quantities = %q, %v; want empty", got, err)
+	}
+
+	got, err = quantitiesJSON([]string{"A", "B", "C"}, map[string]float64{"A": 12, "B": 1, "C": -2, "X": 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"A":12}`; got != want {
+		t.Errorf("quantitiesJSON = %s, want %s", got, want)
+	}
+}
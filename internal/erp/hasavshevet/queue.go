@@ -4,10 +4,13 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"strconv"
 	"sync"
+	"time"
 
+	"erp-connector/internal/erp"
 	"erp-connector/internal/logger"
 )
 
@@ -17,58 +20,88 @@ const defaultQueueSize = 64
 type JobStatus string
 
 const (
-	JobStatusQueued  JobStatus = "queued"
-	JobStatusRunning JobStatus = "running"
-	JobStatusDone    JobStatus = "done"
-	JobStatusFailed  JobStatus = "failed"
+	JobStatusQueued    JobStatus = "queued"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusDone      JobStatus = "done"
+	JobStatusFailed    JobStatus = "failed"
+	JobStatusCancelled JobStatus = "cancelled"
+	// JobStatusUnverified: the importer ran but the document could not be
+	// looked up. The order may be in the ERP; it must not be resubmitted.
+	JobStatusUnverified JobStatus = "unverified"
 )
 
-// JobResult holds the outcome of a processed order job.
+// JobResult holds the outcome of a processed order job. DocumentNumber and
+// Entity identify the verified Stock document and VATRate / VATExempt the VAT
+// it was imported with; ImporterOutput and Errors
+// (the importer's rejected records) are kept when the import failed or
+// could not be verified.
 type JobResult struct {
-	ID           string
-	Status       JobStatus
-	OrderNumber  int64
-	WrittenFiles []string
-	Err          error
+	ID             string
+	Status         JobStatus
+	OrderNumber    int64
+	DocumentNumber string
+	Entity         string
+	WrittenFiles   []string
+	ImporterOutput string
+	Err            error
+	Errors         []ImportIssue
+	VATRate        *float64
+	VATExempt      bool
 }
 
+// orderJob is a submitted order. seq orders jobs by submission. req, lane
+// and cancelled are guarded by the queue's mu until the worker claims the job.
 type orderJob struct {
 	id          string
 	orderNumber int64
+	seq         uint64
 	req         OrderRequest
+	lane        int
+	cancelled   bool
 }
 
 // PostOrderHook is called after order processing succeeds.
 // Implementations run inside the single-worker goroutine.
 // Errors are logged but never fail the order.
-type PostOrderHook interface {
-	AfterOrder(ctx context.Context, req OrderRequest, result *OrderResult) error
-}
+type PostOrderHook = erp.PostOrderHook
 
 // OrderQueue is a single-worker async queue for Hasavshevet send-order jobs.
 //
 // Using a single worker guarantees that only one goroutine writes to
 // IMOVEIN.doc/.prm and executes has.exe at a time, preventing file collisions.
-// Jobs are processed in FIFO order.
+// Jobs wait in urgent, normal and bulk lanes (OrderRequest.Priority) and are
+// taken in submission order within a lane; a lower lane goes next once
+// hasavshevet.priorityMaxSkips jobs have overtaken it. With
+// hasavshevet.batchWindowSeconds set, the jobs queued within the window share
+// one IMOVEIN file and importer run. Jobs can be cancelled or edited until
+// the worker starts them.
 type OrderQueue struct {
-	ch        chan orderJob
+	wake      chan struct{} // signalled when jobs are queued or the queue stops
 	sender    *Sender
 	log       logger.LoggerService
 	postHooks []PostOrderHook
 
-	mu   sync.RWMutex
-	jobs map[string]*JobResult
+	mu        sync.RWMutex
+	jobs      map[string]*JobResult
+	queued    map[string]*orderJob // submitted and not yet claimed
+	waiting   lanes                // queued jobs the worker has not taken
+	seq       uint64               // last orderJob.seq handed out
+	closed    bool
+	perOrder  time.Duration // running average of an order's processing time
+	importing []*orderJob   // jobs in the current importer run
+	runStart  time.Time
 }
 
 // NewOrderQueue creates a new queue. Call Start to begin processing.
 // Optional PostOrderHook instances are called after each successful order.
 func NewOrderQueue(sender *Sender, log logger.LoggerService, hooks ...PostOrderHook) *OrderQueue {
 	return &OrderQueue{
-		ch:        make(chan orderJob, defaultQueueSize),
+		wake:      make(chan struct{}, 1),
 		sender:    sender,
 		log:       log,
 		postHooks: hooks,
 		jobs:      make(map[string]*JobResult),
+		queued:    make(map[string]*orderJob),
 	}
 }
 
@@ -80,62 +113,285 @@ func (q *OrderQueue) Start(ctx context.Context) {
 
 func (q *OrderQueue) run(ctx context.Context) {
 	for {
+		job, ok := q.next(ctx)
+		if !ok {
+			return
+		}
+		q.process(ctx, q.gather(ctx, job))
+	}
+}
+
+// next waits for a job and takes it from its lane. It reports false when ctx
+// is cancelled or the queue was stopped and has no jobs left.
+func (q *OrderQueue) next(ctx context.Context) (*orderJob, bool) {
+	for {
+		q.mu.Lock()
+		job := q.waiting.take(q.sender.priorityMaxSkips())
+		closed := q.closed
+		q.mu.Unlock()
+		if job != nil {
+			return job, true
+		}
+		if closed {
+			return nil, false
+		}
 		select {
 		case <-ctx.Done():
-			return
-		case job, ok := <-q.ch:
-			if !ok {
-				return
-			}
-			q.setStatus(job.id, JobStatusRunning, job.orderNumber, nil, nil)
-			result, err := q.sender.processOrderWithNumber(ctx, job.req, job.orderNumber)
-			if err != nil {
-				q.log.Error(fmt.Sprintf("order job %s failed", job.id), err)
-				q.setStatus(job.id, JobStatusFailed, job.orderNumber, nil, err)
-			} else {
-				q.log.Success(fmt.Sprintf("order job %s done orderNumber=%d files=%v", job.id, result.OrderNumber, result.WrittenFiles))
-				q.setStatus(job.id, JobStatusDone, result.OrderNumber, result.WrittenFiles, nil)
-
-				// Post-order hooks (PDF generation, printing, email).
-				// Errors are logged but never fail the order.
-				for _, hook := range q.postHooks {
-					if hookErr := hook.AfterOrder(ctx, job.req, result); hookErr != nil {
-						q.log.Warn(fmt.Sprintf("post-order hook failed for job %s: %v", job.id, hookErr))
-					}
-				}
-			}
+			return nil, false
+		case <-q.wake:
+		}
+	}
+}
+
+// gather adds the jobs queued within the sender's batch window of job, up to
+// its batch size, taken by priority. It stops waiting early when the batch
+// is full, an urgent order is waiting or the queue is stopped. With batching
+// off it returns job alone.
+func (q *OrderQueue) gather(ctx context.Context, job *orderJob) []*orderJob {
+	jobs := []*orderJob{job}
+	window := q.sender.batchWindow()
+	if window <= 0 {
+		return jobs
+	}
+	limit := q.sender.batchMaxOrders()
+	timer := time.NewTimer(window)
+	defer timer.Stop()
+wait:
+	for !q.batchReady(job, limit) {
+		select {
+		case <-ctx.Done():
+			break wait
+		case <-timer.C:
+			break wait
+		case <-q.wake:
+		}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(jobs) < limit {
+		next := q.waiting.take(q.sender.priorityMaxSkips())
+		if next == nil {
+			break
+		}
+		jobs = append(jobs, next)
+	}
+	return jobs
+}
+
+// batchReady reports whether gather can stop waiting for more jobs after
+// first.
+func (q *OrderQueue) batchReady(first *orderJob, limit int) bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.closed || first.lane == laneUrgent || len(q.waiting.jobs[laneUrgent]) > 0 ||
+		1+q.waiting.len() >= limit
+}
+
+// process runs jobs through the sender as one importer run and records each
+// job's own outcome. Jobs cancelled while queued are skipped.
+func (q *OrderQueue) process(ctx context.Context, jobs []*orderJob) {
+	var (
+		claimed []*orderJob
+		orders  []batchOrder
+	)
+	for _, job := range jobs {
+		req, ok := q.claim(job)
+		if !ok {
+			continue
+		}
+		claimed = append(claimed, job)
+		orders = append(orders, batchOrder{req: req, orderNum: job.orderNumber})
+	}
+	if len(orders) == 0 {
+		return
+	}
+
+	start := time.Now()
+	q.mu.Lock()
+	q.importing, q.runStart = claimed, start
+	q.mu.Unlock()
+	outcomes := q.sender.processBatch(ctx, orders)
+	q.timeRun(len(orders), time.Since(start))
+
+	for i, o := range outcomes {
+		q.finish(ctx, claimed[i], orders[i].req, o.result, o.err)
+	}
+}
+
+// timeRun folds an importer run of n orders that took d into the average
+// processing time per order.
+func (q *OrderQueue) timeRun(n int, d time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	perOrder := d / time.Duration(n)
+	if q.perOrder == 0 {
+		q.perOrder = perOrder
+	} else {
+		q.perOrder = (4*q.perOrder + perOrder) / 5
+	}
+	q.importing = nil
+}
+
+// claim marks job running and returns its current order, or false when it
+// was cancelled.
+func (q *OrderQueue) claim(job *orderJob) (OrderRequest, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if job.cancelled {
+		return OrderRequest{}, false
+	}
+	delete(q.queued, job.id)
+	q.jobs[job.id] = &JobResult{ID: job.id, Status: JobStatusRunning, OrderNumber: job.orderNumber}
+	return job.req, true
+}
+
+// finish records a job's outcome and runs the post-order hooks for
+// successful orders. An import that could not be verified is recorded as
+// unverified, without the hooks.
+func (q *OrderQueue) finish(ctx context.Context, job *orderJob, req OrderRequest, result *OrderResult, err error) {
+	var unverified *UnverifiedImportError
+	if errors.As(err, &unverified) {
+		q.log.Warn(fmt.Sprintf("order job %s unverified: %v", job.id, err))
+		q.set(&JobResult{
+			ID:             job.id,
+			Status:         JobStatusUnverified,
+			OrderNumber:    job.orderNumber,
+			Err:            err,
+			ImporterOutput: unverified.Output,
+			Errors:         unverified.Issues,
+		})
+		return
+	}
+	if err != nil {
+		q.log.Error(fmt.Sprintf("order job %s failed", job.id), err)
+		failed := &JobResult{ID: job.id, Status: JobStatusFailed, OrderNumber: job.orderNumber, Err: err}
+		var importErr *ImportError
+		if errors.As(err, &importErr) {
+			failed.ImporterOutput = importErr.Output
+			failed.Errors = importErr.Issues
+		}
+		q.set(failed)
+		return
+	}
+
+	q.log.Success(fmt.Sprintf("order job %s done orderNumber=%d document=%s files=%v",
+		job.id, result.OrderNumber, result.DocumentNumber, result.WrittenFiles))
+	q.set(&JobResult{
+		ID:             job.id,
+		Status:         JobStatusDone,
+		OrderNumber:    result.OrderNumber,
+		DocumentNumber: result.DocumentNumber,
+		Entity:         result.Entity,
+		WrittenFiles:   result.WrittenFiles,
+		VATRate:        result.VATRate,
+		VATExempt:      result.VATExempt,
+	})
+
+	// Post-order hooks (PDF generation, printing, email).
+	// Errors are logged but never fail the order.
+	for _, hook := range q.postHooks {
+		if hookErr := hook.AfterOrder(ctx, req, result); hookErr != nil {
+			q.log.Warn(fmt.Sprintf("post-order hook failed for job %s: %v", job.id, hookErr))
 		}
 	}
 }
 
 // Submit enqueues an order request and returns a job ID.
 // In normal runtime this ID is the reserved lastOrderNumber as a decimal string.
-// Returns an error if the queue is full.
+// Returns an error if the queue is full or stopped; the reserved number is
+// then recorded as voided.
 func (q *OrderQueue) Submit(req OrderRequest) (string, error) {
 	jobID, orderNumber, err := q.reserveJobIdentity()
 	if err != nil {
 		return "", err
 	}
 
-	job := orderJob{id: jobID, orderNumber: orderNumber, req: req}
+	job := &orderJob{id: jobID, orderNumber: orderNumber, req: req, lane: laneOf(req.Priority)}
 
 	q.mu.Lock()
+	var rejected error
+	switch {
+	case q.closed:
+		rejected = errors.New("order queue stopped")
+	case len(q.queued) >= defaultQueueSize:
+		rejected = fmt.Errorf("order queue full (capacity %d)", defaultQueueSize)
+	}
+	if rejected != nil {
+		q.mu.Unlock()
+		if err := q.sender.voidOrderNumber(job, rejected.Error()); err != nil {
+			q.log.Error(fmt.Sprintf("record voided order number %d", orderNumber), err)
+		}
+		return "", rejected
+	}
+	q.seq++
+	job.seq = q.seq
 	q.jobs[jobID] = &JobResult{
 		ID:          jobID,
 		Status:      JobStatusQueued,
 		OrderNumber: orderNumber,
 	}
+	q.queued[jobID] = job
+	q.waiting.push(job)
 	q.mu.Unlock()
 
-	select {
-	case q.ch <- job:
-		return jobID, nil
-	default:
-		q.mu.Lock()
-		delete(q.jobs, jobID)
-		q.mu.Unlock()
-		return "", fmt.Errorf("order queue full (capacity %d)", defaultQueueSize)
+	q.notify()
+	return jobID, nil
+}
+
+// Position reports where a waiting job stands: its place in the order the
+// worker will take the queued jobs and, once an order has been timed, a
+// rough wait (the rest of the current run plus the average time per order
+// for each job ahead). It returns false for jobs not waiting in a lane.
+func (q *OrderQueue) Position(jobID string) (erp.QueuePosition, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	for i, job := range q.waiting.order(q.sender.priorityMaxSkips()) {
+		if job.id != jobID {
+			continue
+		}
+		p := erp.QueuePosition{Position: i + 1}
+		if q.perOrder > 0 {
+			wait := time.Duration(i) * q.perOrder
+			if n := len(q.importing); n > 0 {
+				wait += max(time.Duration(n)*q.perOrder-time.Since(q.runStart), 0)
+			}
+			p.EstimatedWait, p.Estimated = wait, true
+		}
+		return p, true
+	}
+	return erp.QueuePosition{}, false
+}
+
+// PendingTotal is the total of extID's orders that are queued or in the
+// current importer run, i.e. accepted but not yet in Stock.
+func (q *OrderQueue) PendingTotal(extID string) float64 {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	var total float64
+	for _, job := range q.queued {
+		if erp.SameExtID(job.req.UserExtID, extID) {
+			total += job.req.Total
+		}
+	}
+	for _, job := range q.importing {
+		if erp.SameExtID(job.req.UserExtID, extID) {
+			total += job.req.Total
+		}
+	}
+	return total
+}
+
+// PendingJobTotal returns the total of job jobID while it is queued for
+// extID.
+func (q *OrderQueue) PendingJobTotal(jobID, extID string) (float64, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	job, ok := q.queued[jobID]
+	if !ok || !erp.SameExtID(job.req.UserExtID, extID) {
+		return 0, false
 	}
+	return job.req.Total, true
 }
 
 // reserveJobIdentity reserves the next order number when available and uses it
@@ -157,6 +413,62 @@ func (q *OrderQueue) reserveJobIdentity() (string, int64, error) {
 	return strconv.FormatInt(orderNumber, 10), orderNumber, nil
 }
 
+// Cancel cancels a job that is still queued and records its order number as
+// voided in history/<N>/. It returns erp.ErrNotFound for an unknown job and
+// erp.ErrJobNotQueued once the worker has started it.
+func (q *OrderQueue) Cancel(jobID string) (*JobResult, error) {
+	q.mu.Lock()
+	job, err := q.queuedJob(jobID)
+	if err != nil {
+		q.mu.Unlock()
+		return nil, err
+	}
+	job.cancelled = true
+	delete(q.queued, jobID)
+	q.waiting.remove(job)
+	r := &JobResult{ID: jobID, Status: JobStatusCancelled, OrderNumber: job.orderNumber}
+	q.jobs[jobID] = r
+	q.mu.Unlock()
+
+	// The job is out of the queue, so its request no longer changes.
+	if err := q.sender.voidOrderNumber(job, "cancelled before import"); err != nil {
+		q.log.Error(fmt.Sprintf("record voided order number %d", job.orderNumber), err)
+	}
+	q.log.Info(fmt.Sprintf("order job %s cancelled orderNumber=%d historyId=%s",
+		jobID, job.orderNumber, job.req.HistoryID))
+	return r, nil
+}
+
+// Update replaces the order of a job that is still queued. The job keeps its
+// order number and its place by submission time; a new priority moves it to
+// that lane, ahead of the jobs submitted after it. Errors are as for Cancel.
+func (q *OrderQueue) Update(jobID string, req OrderRequest) (*JobResult, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, err := q.queuedJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+	job.req = req
+	if lane := laneOf(req.Priority); lane != job.lane && q.waiting.remove(job) {
+		job.lane = lane
+		q.waiting.push(job)
+	}
+	q.log.Info(fmt.Sprintf("order job %s updated orderNumber=%d historyId=%s", jobID, job.orderNumber, req.HistoryID))
+	return q.jobs[jobID], nil
+}
+
+// queuedJob returns the queued job jobID. The caller holds mu.
+func (q *OrderQueue) queuedJob(jobID string) (*orderJob, error) {
+	if job, ok := q.queued[jobID]; ok {
+		return job, nil
+	}
+	if r, ok := q.jobs[jobID]; ok {
+		return nil, fmt.Errorf("job %s is %s: %w", jobID, r.Status, erp.ErrJobNotQueued)
+	}
+	return nil, fmt.Errorf("job %s: %w", jobID, erp.ErrNotFound)
+}
+
 // Status returns the current result for a job ID, or false if not found.
 func (q *OrderQueue) Status(jobID string) (*JobResult, bool) {
 	q.mu.RLock()
@@ -165,21 +477,26 @@ func (q *OrderQueue) Status(jobID string) (*JobResult, bool) {
 	return r, ok
 }
 
-// Stop closes the job channel, causing the worker to exit after the current job.
+// Stop rejects new jobs; the worker exits once the queued jobs are done.
 func (q *OrderQueue) Stop() {
-	close(q.ch)
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	q.notify()
 }
 
-func (q *OrderQueue) setStatus(id string, status JobStatus, orderNumber int64, files []string, err error) {
+// notify wakes the worker without blocking.
+func (q *OrderQueue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *OrderQueue) set(r *JobResult) {
 	q.mu.Lock()
 	defer q.mu.Unlock()
-	q.jobs[id] = &JobResult{
-		ID:           id,
-		Status:       status,
-		OrderNumber:  orderNumber,
-		WrittenFiles: files,
-		Err:          err,
-	}
+	q.jobs[r.ID] = r
 }
 
 func newJobID() string {
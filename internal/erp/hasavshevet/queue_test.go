@@ -0,0 +1,273 @@
+package hasavshevet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"erp-connector/internal/config"
+	"erp-connector/internal/db"
+	"erp-connector/internal/erp"
+	"erp-connector/internal/logger"
+)
+
+func newEditableQueue(t *testing.T) (*OrderQueue, string) {
+	t.Helper()
+	dir := t.TempDir()
+	store := NewOrderNumberStore(filepath.Join(dir, "lastOrderNumber.json"))
+	sender := NewSender(nil, config.Config{SendOrderDir: dir}, store, logger.NewStderr())
+	return NewOrderQueue(sender, logger.NewStderr()), dir
+}
+
+func TestOrderQueue_Cancel(t *testing.T) {
+	q, dir := newEditableQueue(t)
+	first, _ := q.Submit(OrderRequest{HistoryID: "H-1"})
+	second, _ := q.Submit(OrderRequest{HistoryID: "H-2"})
+
+	r, err := q.Cancel(first)
+	if err != nil {
+		t.Fatalf("Cancel(%s): %v", first, err)
+	}
+	if r.Status != JobStatusCancelled || r.OrderNumber != 1 {
+		t.Errorf("Cancel(%s) = %+v, want cancelled order 1", first, r)
+	}
+	b, err := os.ReadFile(VoidedPath(dir, 1))
+	if err != nil {
+		t.Fatalf("voided record: %v", err)
+	}
+	var voided voidedOrder
+	if err := json.Unmarshal(b, &voided); err != nil || voided.OrderNumber != 1 || voided.HistoryID != "H-1" {
+		t.Errorf("voided record = %s (%v)", b, err)
+	}
+
+	// The last number is voided too, never handed to the next order: the
+	// cancelled job ID must keep naming the cancelled job.
+	if _, err := q.Cancel(second); err != nil {
+		t.Fatalf("Cancel(%s): %v", second, err)
+	}
+	if _, err := os.Stat(VoidedPath(dir, 2)); err != nil {
+		t.Errorf("last number not recorded as voided: %v", err)
+	}
+	third, _ := q.Submit(OrderRequest{HistoryID: "H-3"})
+	if third != "3" {
+		t.Errorf("next job = %s, want 3", third)
+	}
+	if st, _ := q.Status(second); st.Status != JobStatusCancelled {
+		t.Errorf("Status(%s) = %s, want cancelled", second, st.Status)
+	}
+
+	// Cancelled jobs leave their lane.
+	job := q.waiting.take(defaultPriorityMaxSkips)
+	if job == nil || job.id != third {
+		t.Fatalf("take = %+v, want the new job", job)
+	}
+	if next := q.waiting.take(defaultPriorityMaxSkips); next != nil {
+		t.Errorf("take = %+v, want no cancelled job", next)
+	}
+
+	// A job the worker took but has not started can still be cancelled.
+	if _, err := q.Cancel(job.id); err != nil {
+		t.Fatalf("Cancel(taken job): %v", err)
+	}
+	if _, ok := q.claim(job); ok {
+		t.Error("claimed the cancelled job")
+	}
+
+	if _, err := q.Cancel(first); !errors.Is(err, erp.ErrJobNotQueued) {
+		t.Errorf("Cancel(cancelled) error = %v, want ErrJobNotQueued", err)
+	}
+	if _, err := q.Cancel("999"); !errors.Is(err, erp.ErrNotFound) {
+		t.Errorf("Cancel(unknown) error = %v, want ErrNotFound", err)
+	}
+}
+
+// TestOrderQueue_SubmitFullVoidsNumber verifies an order turned away by a
+// full queue leaves its reserved number recorded as voided.
+func TestOrderQueue_SubmitFullVoidsNumber(t *testing.T) {
+	q, dir := newEditableQueue(t)
+	for range defaultQueueSize {
+		if _, err := q.Submit(OrderRequest{}); err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+	}
+	if _, err := q.Submit(OrderRequest{HistoryID: "H-full"}); err == nil {
+		t.Fatal("Submit to a full queue succeeded")
+	}
+	num := int64(defaultQueueSize + 1)
+	b, err := os.ReadFile(VoidedPath(dir, num))
+	if err != nil {
+		t.Fatalf("voided record: %v", err)
+	}
+	var voided voidedOrder
+	if err := json.Unmarshal(b, &voided); err != nil || voided.OrderNumber != num || voided.HistoryID != "H-full" {
+		t.Errorf("voided record = %s (%v)", b, err)
+	}
+}
+
+func TestOrderQueue_Update(t *testing.T) {
+	q, _ := newEditableQueue(t)
+	id, _ := q.Submit(OrderRequest{HistoryID: "H-1", Total: 10})
+
+	r, err := q.Update(id, OrderRequest{HistoryID: "H-1", Total: 20})
+	if err != nil || r.Status != JobStatusQueued || r.OrderNumber != 1 {
+		t.Fatalf("Update = %+v, %v", r, err)
+	}
+	req, ok := q.claim(q.waiting.take(defaultPriorityMaxSkips))
+	if !ok || req.Total != 20 {
+		t.Fatalf("claim = %+v, %v; want the updated order", req, ok)
+	}
+	if st, _ := q.Status(id); st.Status != JobStatusRunning {
+		t.Errorf("status after claim = %s, want running", st.Status)
+	}
+
+	if _, err := q.Update(id, OrderRequest{Total: 30}); !errors.Is(err, erp.ErrJobNotQueued) {
+		t.Errorf("Update(running) error = %v, want ErrJobNotQueued", err)
+	}
+	if _, err := q.Cancel(id); !errors.Is(err, erp.ErrJobNotQueued) {
+		t.Errorf("Cancel(running) error = %v, want ErrJobNotQueued", err)
+	}
+}
+
+// TestOrderQueue_FinishUnverified checks that an import whose document could
+// not be looked up is reported as unverified with the importer output, not as
+// failed.
+func TestOrderQueue_FinishUnverified(t *testing.T) {
+	q, _ := newEditableQueue(t)
+	id, _ := q.Submit(OrderRequest{HistoryID: "H-1"})
+	job := q.waiting.take(defaultPriorityMaxSkips)
+	req, _ := q.claim(job)
+
+	q.finish(context.Background(), job, req, nil, &UnverifiedImportError{
+		OrderNumber: 1,
+		Output:      "imported 1 document",
+		Issues:      []ImportIssue{{Line: 2, Message: "price rounded"}},
+		Err:         fmt.Errorf("query Stock: %w", db.ErrUnavailable),
+	})
+	st, _ := q.Status(id)
+	if st.Status != JobStatusUnverified {
+		t.Errorf("status = %s, want unverified", st.Status)
+	}
+	if st.ImporterOutput != "imported 1 document" || len(st.Errors) != 1 {
+		t.Errorf("result = %+v, want the importer output and issues", st)
+	}
+	if !errors.Is(st.Err, db.ErrUnavailable) {
+		t.Errorf("err = %v, want the lookup error", st.Err)
+	}
+}
+
+// TestOrderQueue_PendingTotal verifies the credit check sees queued orders
+// and the orders of the current importer run, but not cancelled ones.
+func TestOrderQueue_PendingTotal(t *testing.T) {
+	q, _ := newEditableQueue(t)
+	first, _ := q.Submit(OrderRequest{UserExtID: "C1", Total: 100})
+	second, _ := q.Submit(OrderRequest{UserExtID: " c1 ", Total: 50})
+	_, _ = q.Submit(OrderRequest{UserExtID: "C2", Total: 30})
+
+	job := q.waiting.take(defaultPriorityMaxSkips)
+	if _, ok := q.claim(job); !ok || job.id != first {
+		t.Fatalf("claim = %+v, want %s", job, first)
+	}
+	q.importing = []*orderJob{job}
+	if got := q.PendingTotal("C1"); got != 150 {
+		t.Errorf("PendingTotal(C1) = %v, want 150", got)
+	}
+	if _, ok := q.PendingJobTotal(first, "C1"); ok {
+		t.Error("PendingJobTotal of a running job reported")
+	}
+	if got, ok := q.PendingJobTotal(second, "C1"); !ok || got != 50 {
+		t.Errorf("PendingJobTotal(%s, C1) = %v, %v; want 50", second, got, ok)
+	}
+	if _, ok := q.PendingJobTotal(second, "C2"); ok {
+		t.Error("PendingJobTotal matched another customer")
+	}
+
+	if _, err := q.Cancel(second); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	q.timeRun(1, time.Second)
+	if got := q.PendingTotal("C1"); got != 0 {
+		t.Errorf("PendingTotal(C1) after the run = %v, want 0", got)
+	}
+	if got := q.PendingTotal("C2"); got != 30 {
+		t.Errorf("PendingTotal(C2) = %v, want 30", got)
+	}
+}
+
+func TestLanesTake(t *testing.T) {
+	var l lanes
+	for _, id := range []string{"u1", "u2", "u3"} {
+		l.push(&orderJob{id: id, lane: laneUrgent})
+	}
+	for _, id := range []string{"n1", "n2", "n3"} {
+		l.push(&orderJob{id: id, lane: laneNormal})
+	}
+	l.push(&orderJob{id: "b1", lane: laneBulk})
+
+	// With maxSkips 2 a lower lane goes next once two jobs overtook it.
+	want := []string{"u1", "u2", "n1", "b1", "u3", "n2", "n3"}
+	var got []string
+	for _, job := range l.order(2) {
+		got = append(got, job.id)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+	if l.len() != 7 {
+		t.Errorf("order changed the lanes: %d jobs left", l.len())
+	}
+	for i, id := range want {
+		if job := l.take(2); job == nil || job.id != id {
+			t.Fatalf("take %d = %+v, want %s", i+1, job, id)
+		}
+	}
+	if job := l.take(2); job != nil {
+		t.Errorf("take from empty lanes = %+v", job)
+	}
+}
+
+func TestOrderQueue_Position(t *testing.T) {
+	q, _ := newEditableQueue(t)
+	bulk, _ := q.Submit(OrderRequest{Priority: erp.PriorityBulk})
+	normal, _ := q.Submit(OrderRequest{})
+	urgent, _ := q.Submit(OrderRequest{Priority: erp.PriorityUrgent})
+
+	for id, want := range map[string]int{urgent: 1, normal: 2, bulk: 3} {
+		p, ok := q.Position(id)
+		if !ok || p.Position != want || p.Estimated {
+			t.Errorf("Position(%s) = %+v, %v; want %d, no estimate yet", id, p, ok, want)
+		}
+	}
+
+	// Raising the bulk order's priority moves it into the urgent lane ahead
+	// of the urgent order submitted after it.
+	if _, err := q.Update(bulk, OrderRequest{Priority: erp.PriorityUrgent}); err != nil {
+		t.Fatal(err)
+	}
+	for id, want := range map[string]int{bulk: 1, urgent: 2, normal: 3} {
+		if p, _ := q.Position(id); p.Position != want {
+			t.Errorf("Position(%s) after Update = %d, want %d", id, p.Position, want)
+		}
+	}
+	// Lowering it to normal puts it ahead of the normal order submitted
+	// after it.
+	if _, err := q.Update(bulk, OrderRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := q.Position(bulk); p.Position != 2 {
+		t.Errorf("Position(%s) after lowering = %d, want 2 (ahead of the later normal order)", bulk, p.Position)
+	}
+
+	q.timeRun(2, 20*time.Second)
+	if p, _ := q.Position(normal); !p.Estimated || p.EstimatedWait != 20*time.Second {
+		t.Errorf("Position(normal) = %+v, want 20s wait behind two orders", p)
+	}
+	if _, ok := q.Position("999"); ok {
+		t.Error("Position(unknown) reported a position")
+	}
+}
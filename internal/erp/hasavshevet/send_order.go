@@ -5,60 +5,32 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"erp-connector/internal/config"
+	"erp-connector/internal/db"
+	"erp-connector/internal/erp"
 	"erp-connector/internal/logger"
 )
 
-// OrderRequest is the internal representation of a send-order request,
-// translated from the API DTO before enqueueing.
+// OrderRequest, OrderLineItem and OrderLineExtra are the ERP-neutral order
+// types; the aliases keep the Hasavshevet pipeline reading naturally.
 // DBName is not part of the request; the Sender resolves it from config.
-type OrderRequest struct {
-	DocumentType  string
-	UserExtID     string
-	DueDate       string
-	CreatedDate   string
-	Comment       string
-	Discount      float64
-	HistoryID     string
-	Total         float64
-	Currency      string
-	CustomerEmail string // optional; used for PDF email delivery
-	Details       []OrderLineItem
-}
-
-// OrderLineItem is one line item in an order.
-type OrderLineItem struct {
-	Title         string
-	SKU           string
-	Quantity      float64
-	Packs         float64 // number of packages → IMOVEIN line33 (אריזות)
-	OriginalPrice float64
-	SinglePrice   float64
-	TotalPrice    float64
-	Discount      float64
-}
-
-// OrderResult is returned by ProcessOrder on success.
-type OrderResult struct {
-	OrderNumber  int64
-	WrittenFiles []string
-	Account      AccountInfo
-}
+type (
+	OrderRequest   = erp.OrderRequest
+	OrderLineItem  = erp.OrderLineItem
+	OrderLineExtra = erp.OrderLineExtra
+)
 
-// AccountInfo holds customer data needed for PDF generation.
-// Exported mirror of the internal accountInfo queried from the DB.
-type AccountInfo struct {
-	AccountKey string
-	FullName   string
-	Address    string
-	City       string
-	Phone      string
-}
+// OrderResult and AccountInfo are the ERP-neutral results shared with
+// post-order hooks.
+type (
+	OrderResult = erp.OrderResult
+	AccountInfo = erp.AccountInfo
+)
 
 // accountInfo holds the DB columns needed from the Accounts table.
 type accountInfo struct {
@@ -78,15 +50,17 @@ type accountInfo struct {
 // (guaranteed by OrderQueue's single-worker model) because IMOVEIN.doc/.prm
 // are shared files in SendOrderDir that cannot be written concurrently.
 type Sender struct {
-	db          *sql.DB
+	db          *db.Handle
 	cfg         config.Config
 	numberStore *OrderNumberStore
 	log         logger.LoggerService
 }
 
-// NewSender creates a Sender. db and numberStore must be non-nil.
-func NewSender(db *sql.DB, cfg config.Config, numberStore *OrderNumberStore, log logger.LoggerService) *Sender {
-	return &Sender{db: db, cfg: cfg, numberStore: numberStore, log: log}
+// NewSender creates a Sender. dbHandle and numberStore must be non-nil.
+// The connection is resolved per order, so a Sender built while the database
+// is still down starts working as soon as the handle reconnects.
+func NewSender(dbHandle *db.Handle, cfg config.Config, numberStore *OrderNumberStore, log logger.LoggerService) *Sender {
+	return &Sender{db: dbHandle, cfg: cfg, numberStore: numberStore, log: log}
 }
 
 // ProcessOrder executes the full send-order pipeline for one order.
@@ -114,37 +88,28 @@ func (s *Sender) nextOrderNumber() (int64, error) {
 // processOrderWithNumber executes the full send-order flow using a pre-reserved
 // order number. If orderNum is zero, it reserves one before continuing.
 func (s *Sender) processOrderWithNumber(ctx context.Context, req OrderRequest, orderNum int64) (*OrderResult, error) {
-	if strings.TrimSpace(s.cfg.SendOrderDir) == "" {
-		return nil, errors.New("sendOrderDir is not configured")
-	}
-
-	// DB name always comes from the connector config.
-	dbName := strings.TrimSpace(s.cfg.DB.Database)
-	if dbName == "" {
-		return nil, errors.New("database is not configured (set db.database in config)")
-	}
-
-	// 1. Pre-flight validation (business rules + Hasavshevet mandatory field spec)
-	if err := validateOrderRequest(req); err != nil {
-		return nil, err
-	}
-
-	// 2. Concurrency-safe order number (reserved at queue submit when possible).
-	if orderNum == 0 {
-		var err error
-		orderNum, err = s.nextOrderNumber()
-		if err != nil {
-			return nil, err
-		}
-	}
+	o := s.processBatch(ctx, []batchOrder{{req: req, orderNum: orderNum}})[0]
+	return o.result, o.err
+}
 
-	s.log.Info(fmt.Sprintf("processing order orderNumber=%d historyId=%s userExtId=%s dbName=%s",
-		orderNum, req.HistoryID, req.UserExtID, dbName))
+// preparedOrder is an order's IMOVEIN content and the lookups it was built
+// from.
+type preparedOrder struct {
+	account accountInfo
+	rate    float64
+	vat     vatDecision
+	hdr     stockHeader
+	moves   []stockMove
+}
 
+// prepareOrder looks up the account, currency rate and VAT for a validated
+// order and builds its IMOVEIN records. It reads the database only, so
+// PreviewOrder can run it without side effects.
+func (s *Sender) prepareOrder(ctx context.Context, dbName string, req OrderRequest, orderNum int64) (preparedOrder, error) {
 	// 3. Account lookup
 	account, err := s.queryAccount(ctx, dbName, req.UserExtID)
 	if err != nil {
-		return nil, fmt.Errorf("query account %q: %w", req.UserExtID, err)
+		return preparedOrder{}, fmt.Errorf("query account %q: %w", req.UserExtID, err)
 	}
 
 	// 4. Currency rate (non-fatal; default 1.0 matches legacy behaviour)
@@ -154,83 +119,27 @@ func (s *Sender) processOrderWithNumber(ctx context.Context, req OrderRequest, o
 		rate = 1.0
 	}
 
-	// 5. Build DOC and PRM content
-	hdr, moves := buildIMOVEIN(orderNum, account, req, rate)
-	docBytes, err := generateDOC(hdr, moves)
+	// 5. VAT by document date; exempt customers are imported at 0%.
+	dbConn := s.db.DB()
+	if dbConn == nil {
+		return preparedOrder{}, fmt.Errorf("resolve VAT: %w", db.ErrUnavailable)
+	}
+	vat, err := resolveVAT(ctx, dbConn, s.cfg.Hasavshevet.VAT, dbName, account.AccountKey,
+		parseTimeOrNow(req.CreatedDate, time.Now()))
 	if err != nil {
-		return nil, fmt.Errorf("generate DOC: %w", err)
-	}
-	prmBytes := generatePRM()
-
-	// 6. Ensure output directories exist
-	dir := s.cfg.SendOrderDir
-	historyDir := filepath.Join(dir, "history", fmt.Sprintf("%d", orderNum))
-	if err := os.MkdirAll(historyDir, 0o755); err != nil {
-		return nil, fmt.Errorf("create history dir: %w", err)
-	}
-
-	// 7. Write files
-	// Active import files (read by has.exe): single-worker queue prevents collision.
-	// History copies: permanent audit trail per order.
-	toWrite := []struct {
-		path string
-		data []byte
-	}{
-		{filepath.Join(dir, "IMOVEIN.doc"), docBytes},
-		{filepath.Join(dir, "IMOVEIN.prm"), prmBytes},
-		{filepath.Join(historyDir, fmt.Sprintf("IMOVEIN_%d.doc", orderNum)), docBytes},
-		{filepath.Join(historyDir, fmt.Sprintf("IMOVEIN_%d.prm", orderNum)), prmBytes},
-	}
-
-	var writtenFiles []string
-	for _, f := range toWrite {
-		if err := os.WriteFile(f.path, f.data, 0o644); err != nil {
-			return nil, fmt.Errorf("write %s: %w", f.path, err)
-		}
-		writtenFiles = append(writtenFiles, f.path)
-	}
-
-	// 8. Execute Hasavshevet importer (Windows only; no-op on other platforms).
-	// HasBatFile (Masofon-generated BAT launcher) takes precedence over HasExePath.
-	// The single-worker queue guarantees the previous import is finished before
-	// the next order's files are written and the importer is invoked again.
-	switch {
-	case strings.TrimSpace(s.cfg.HasBatFile) != "":
-		start := time.Now()
-		exitCode, output, execErr := runBatFile(ctx, s.cfg.HasBatFile)
-		s.log.Info(fmt.Sprintf("digi.bat exit=%d durationMs=%d output=%q orderNumber=%d",
-			exitCode, time.Since(start).Milliseconds(), output, orderNum))
-		if execErr != nil || exitCode != 0 {
-			s.log.Error(fmt.Sprintf("digi.bat failed orderNumber=%d exit=%d", orderNum, exitCode), execErr)
-		}
-	case strings.TrimSpace(s.cfg.HasExePath) != "":
-		start := time.Now()
-		exitCode, output, execErr := runImporter(ctx, s.cfg.HasExePath, s.cfg.HasParamFile, dir)
-		s.log.Info(fmt.Sprintf("has.exe exit=%d durationMs=%d output=%q orderNumber=%d",
-			exitCode, time.Since(start).Milliseconds(), output, orderNum))
-		if execErr != nil {
-			// Log but do not fail: files are written; import can be retried.
-			s.log.Error(fmt.Sprintf("has.exe failed orderNumber=%d", orderNum), execErr)
-		}
+		return preparedOrder{}, err
 	}
 
-	s.log.Success(fmt.Sprintf("order complete orderNumber=%d files=%v", orderNum, writtenFiles))
-	return &OrderResult{
-		OrderNumber:  orderNum,
-		WrittenFiles: writtenFiles,
-		Account: AccountInfo{
-			AccountKey: account.AccountKey,
-			FullName:   account.FullName,
-			Address:    account.Address,
-			City:       account.City,
-			Phone:      account.Phone,
-		},
-	}, nil
+	// 6. Build DOC content
+	hdr, moves := buildIMOVEIN(s.cfg.Hasavshevet, orderNum, account, req, rate, vat)
+	return preparedOrder{account: account, rate: rate, vat: vat, hdr: hdr, moves: moves}, nil
 }
 
 // buildIMOVEIN maps a validated OrderRequest to the stockHeader + []stockMove
-// needed by generateDOC.
-func buildIMOVEIN(orderNum int64, account accountInfo, req OrderRequest, rate float64) (stockHeader, []stockMove) {
+// needed by generateDOC. Document codes, warehouse, copies, unit, agent and
+// remarks come from the documentType's mapping in h; vat is the resolved
+// rate and exemption.
+func buildIMOVEIN(h config.HasavshevetConfig, orderNum int64, account accountInfo, req OrderRequest, rate float64, vat vatDecision) (stockHeader, []stockMove) {
 	now := time.Now()
 	createdDate := parseTimeOrNow(req.CreatedDate, now)
 	dueDate := parseTimeOrNow(req.DueDate, now)
@@ -243,22 +152,10 @@ func buildIMOVEIN(orderNum int64, account accountInfo, req OrderRequest, rate fl
 		discount = 99.99
 	}
 
-	// Document type → Hasavshevet header DocumentID code
-	var headerDocID, wareHouse int
-	switch req.DocumentType {
-	case "ORDER":
-		wareHouse = 1
-		if req.Currency == `ש"ח` {
-			headerDocID = 30
-		} else {
-			headerDocID = 32
-		}
-	case "QUOATE":
-		headerDocID = 40
-		wareHouse = 1
-	case "RETURN":
-		headerDocID = 74
-		wareHouse = 1
+	mapping, headerDocID := headerDocument(h, req.DocumentType, req.Currency)
+	agent := account.Agent
+	if mapping.Agent != "" {
+		agent = mapping.Agent
 	}
 
 	hdr := stockHeader{
@@ -271,19 +168,17 @@ func buildIMOVEIN(orderNum int64, account accountInfo, req OrderRequest, rate fl
 		Phone:        sanitizeField(account.Phone),
 		Asmahta2:     fmt.Sprintf("%d", orderNum),
 		ShortDate:    shortDate,
-		Agent:        account.Agent,
-		WareHouse:    wareHouse,
+		Agent:        agent,
+		WareHouse:    mapping.Warehouse,
 		DiscountPrcR: fmt.Sprintf("%.2f", discount),
-		VatPrc:       "18.00",
-		Copies:       "1",
+		VatPrc:       fmt.Sprintf("%.2f", vat.Rate),
+		Copies:       fmt.Sprintf("%d", mapping.Copies),
 		Currency:     req.Currency,
 		Rate:         fmt.Sprintf("%.4f", rate),
-		Remarks:      sanitizeComment(req.Comment, 250),
+		Remarks:      sanitizeComment(renderRemarks(mapping.RemarksTemplate, orderNum, req, createdDate, dueDate), 250),
 		HProtect:     account.HProtect,
 	}
 
-	_ = dueDate // available for future per-line DueDate fields
-
 	moves := make([]stockMove, 0, len(req.Details))
 	for _, d := range req.Details {
 		// Packs (line33/אריזות): only emit when known (>0) so legacy callers that
@@ -292,6 +187,18 @@ func buildIMOVEIN(orderNum int64, account accountInfo, req OrderRequest, rate fl
 		if d.Packs > 0 {
 			packs = fmt.Sprintf("%.2f", d.Packs)
 		}
+		unit := mapping.Unit
+		if d.Unit != "" {
+			unit = d.Unit
+		}
+		warehouse := ""
+		if d.Warehouse > 0 {
+			warehouse = fmt.Sprintf("%d", d.Warehouse)
+		}
+		lineDue := d.DueDate
+		if lineDue == "" {
+			lineDue = req.DueDate
+		}
 		moves = append(moves, stockMove{
 			ItemKey:     d.SKU,
 			ItemName:    sanitizeField(d.Title),
@@ -299,13 +206,51 @@ func buildIMOVEIN(orderNum int64, account accountInfo, req OrderRequest, rate fl
 			Packs:       packs,
 			Price:       fmt.Sprintf("%.2f", d.OriginalPrice),
 			DiscountPrc: fmt.Sprintf("%.2f", d.Discount),
-			Unit:        "יח'",
+			Unit:        unit,
+			VatExempt:   vat.Exempt,
+			Batch:       d.Batch,
+			Serial:      d.Serial,
+			Remarks1:    sanitizeField(d.Extra.Text1),
+			Remarks2:    sanitizeField(d.Extra.Text2),
+			ExtraSum1:   formatOptionalAmount(d.Extra.Sum1),
+			ExtraSum2:   formatOptionalAmount(d.Extra.Sum2),
+			DueDate:     formatOptionalDate(lineDue),
+			Details:     sanitizeField(d.Remarks),
+			WareHouse:   warehouse,
+			ExtraDate1:  formatOptionalDate(d.Extra.Date1),
+			ExtraDate2:  formatOptionalDate(d.Extra.Date2),
+			ExtraNum1:   formatOptionalNumber(d.Extra.Num1),
+			ExtraNum2:   formatOptionalNumber(d.Extra.Num2),
 		})
 	}
 
 	return hdr, moves
 }
 
+// headerDocument returns the documentType's mapping and the header
+// DocumentID for an order in currency. Unknown types return a zero mapping;
+// validateOrderRequest rejects them first.
+func headerDocument(h config.HasavshevetConfig, documentType, currency string) (config.HasavshevetDocumentMapping, int) {
+	m, ok := h.DocumentMapping(documentType)
+	if !ok {
+		return config.HasavshevetDocumentMapping{}, 0
+	}
+	return m, m.DocumentIDFor(currency, h.LocalCurrencyCode())
+}
+
+// renderRemarks fills a remarksTemplate with the order's fields. Dates use
+// the IMOVEIN dd/mm/yyyy form.
+func renderRemarks(template string, orderNum int64, req OrderRequest, createdDate, dueDate time.Time) string {
+	return strings.NewReplacer(
+		"{comment}", req.Comment,
+		"{historyId}", req.HistoryID,
+		"{orderNumber}", fmt.Sprintf("%d", orderNum),
+		"{userExtId}", req.UserExtID,
+		"{createdDate}", createdDate.Format("02/01/2006"),
+		"{dueDate}", dueDate.Format("02/01/2006"),
+	).Replace(template)
+}
+
 // queryAccount fetches account columns required for the DOC header.
 func (s *Sender) queryAccount(ctx context.Context, dbName, userExtID string) (accountInfo, error) {
 	if !isSafeDBName(dbName) {
@@ -316,13 +261,17 @@ func (s *Sender) queryAccount(ctx context.Context, dbName, userExtID string) (ac
 			` FROM [%s].[dbo].[Accounts] WHERE AccountKey = @userExtId`,
 		dbName,
 	)
-	row := s.db.QueryRowContext(ctx, query, sql.Named("userExtId", userExtID))
+	dbConn := s.db.DB()
+	if dbConn == nil {
+		return accountInfo{}, db.ErrUnavailable
+	}
+	row := dbConn.QueryRowContext(ctx, query, sql.Named("userExtId", userExtID))
 
 	var a accountInfo
 	var fullName, address, city, phone, agent, hprotect sql.NullString
 	if err := row.Scan(&a.AccountKey, &fullName, &address, &city, &phone, &agent, &hprotect); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return accountInfo{}, fmt.Errorf("account not found: %q", userExtID)
+			return accountInfo{}, fmt.Errorf("account %w: %q", erp.ErrNotFound, userExtID)
 		}
 		return accountInfo{}, err
 	}
@@ -346,7 +295,11 @@ func (s *Sender) queryRate(ctx context.Context, dbName, currencyCode string) (fl
 			` WHERE CurrencyCode = @currencyCode ORDER BY DatF DESC`,
 		dbName,
 	)
-	row := s.db.QueryRowContext(ctx, query, sql.Named("currencyCode", currencyCode))
+	dbConn := s.db.DB()
+	if dbConn == nil {
+		return 1.0, db.ErrUnavailable
+	}
+	row := dbConn.QueryRowContext(ctx, query, sql.Named("currencyCode", currencyCode))
 	var rate sql.NullFloat64
 	if err := row.Scan(&rate); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -402,6 +355,42 @@ func validateOrderRequest(req OrderRequest) error {
 		if d.Title == "" {
 			return fmt.Errorf("details[%d]: title is required", i)
 		}
+		if err := validateOrderLine(d); err != nil {
+			return fmt.Errorf("details[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// validateOrderLine rejects optional line attributes that IMOVEIN would
+// truncate or that are not dates, rather than importing them altered.
+func validateOrderLine(d OrderLineItem) error {
+	if d.Warehouse < 0 || len(fmt.Sprintf("%d", d.Warehouse)) > imoveInFieldLengths["line72"] {
+		return fmt.Errorf("warehouse %d does not fit line72", d.Warehouse)
+	}
+	texts := []struct{ name, value, key string }{
+		{"unit", d.Unit, "line29"},
+		{"batch", d.Batch, "line41"},
+		{"serial", d.Serial, "line44"},
+		{"extra.text1", sanitizeField(d.Extra.Text1), "line56"},
+		{"extra.text2", sanitizeField(d.Extra.Text2), "line57"},
+		{"remarks", sanitizeField(d.Remarks), "line71"},
+		{"extra.sum1", formatOptionalAmount(d.Extra.Sum1), "line58"},
+		{"extra.sum2", formatOptionalAmount(d.Extra.Sum2), "line59"},
+		{"extra.num1", formatOptionalNumber(d.Extra.Num1), "line85"},
+		{"extra.num2", formatOptionalNumber(d.Extra.Num2), "line86"},
+	}
+	for _, t := range texts {
+		if n := utf8.RuneCountInString(t.value); n > imoveInFieldLengths[t.key] {
+			return fmt.Errorf("%s is %d characters; %s allows %d", t.name, n, t.key, imoveInFieldLengths[t.key])
+		}
+	}
+	for _, date := range []struct{ name, value string }{
+		{"dueDate", d.DueDate}, {"extra.date1", d.Extra.Date1}, {"extra.date2", d.Extra.Date2},
+	} {
+		if _, ok := parseTime(date.value); date.value != "" && !ok {
+			return fmt.Errorf("%s %q is not a date", date.name, date.value)
+		}
 	}
 	return nil
 }
@@ -423,16 +412,24 @@ func isSafeDBName(name string) bool {
 
 // parseTimeOrNow parses an ISO-8601 date string; returns fallback on any parse failure.
 func parseTimeOrNow(s string, fallback time.Time) time.Time {
+	if t, ok := parseTime(s); ok {
+		return t
+	}
+	return fallback
+}
+
+// parseTime parses the ISO-8601 forms the API accepts for dates.
+func parseTime(s string) (time.Time, bool) {
 	for _, layout := range []string{
 		time.RFC3339,
 		"2006-01-02T15:04:05",
 		"2006-01-02",
 	} {
 		if t, err := time.Parse(layout, s); err == nil {
-			return t
+			return t, true
 		}
 	}
-	return fallback
+	return time.Time{}, false
 }
 
 // sanitizeField removes single quotes and converts newlines to ". "
@@ -443,6 +440,33 @@ func sanitizeField(s string) string {
 	return s
 }
 
+// formatOptionalDate formats an ISO-8601 date in the IMOVEIN dd/mm/yyyy
+// form, or "" when s is empty or not a date.
+func formatOptionalDate(s string) string {
+	t, ok := parseTime(s)
+	if !ok {
+		return ""
+	}
+	return t.Format("02/01/2006")
+}
+
+// formatOptionalAmount formats an amount field, "" when unset.
+func formatOptionalAmount(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprintf("%.2f", *v)
+}
+
+// formatOptionalNumber formats a number field without trailing zeros, ""
+// when unset.
+func formatOptionalNumber(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
+
 // sanitizeComment applies sanitizeField and truncates to maxLen runes.
 func sanitizeComment(s string, maxLen int) string {
 	s = sanitizeField(s)
@@ -0,0 +1,158 @@
+package hasavshevet
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"erp-connector/internal/config"
+)
+
+func mappingOrder() OrderRequest {
+	return OrderRequest{
+		DocumentType: "ORDER",
+		UserExtID:    "CUST001",
+		HistoryID:    "HID-7",
+		CreatedDate:  "2026-03-01",
+		DueDate:      "2026-03-05",
+		Currency:     "$",
+		Comment:      "leave at gate",
+		Details:      []OrderLineItem{{SKU: "SKU-001", Title: "Item", Quantity: 1}},
+	}
+}
+
+// TestBuildIMOVEIN_DefaultMapping verifies the values sendOrder always wrote.
+func TestBuildIMOVEIN_DefaultMapping(t *testing.T) {
+	hdr, moves := buildIMOVEIN(config.HasavshevetConfig{}, 1000295, accountInfo{AccountKey: "CUST001", Agent: "3"}, mappingOrder(), 3.7, vatDecision{Rate: 18})
+	if hdr.DocumentID != 32 || hdr.WareHouse != 1 || hdr.Copies != "1" || hdr.Agent != "3" || hdr.Remarks != "leave at gate" {
+		t.Errorf("header = %+v", hdr)
+	}
+	if moves[0].Unit != "יח'" {
+		t.Errorf("unit = %q", moves[0].Unit)
+	}
+}
+
+func TestBuildIMOVEIN_ConfiguredMapping(t *testing.T) {
+	h := config.HasavshevetConfig{SendOrder: map[string]config.HasavshevetDocumentMapping{
+		"ORDER": {
+			CurrencyDocumentIDs: map[string]int{"$": 35},
+			Warehouse:           4,
+			Copies:              2,
+			Unit:                "קרטון",
+			Agent:               "9",
+			RemarksTemplate:     "{historyId} {dueDate}: {comment}",
+		},
+	}}
+	hdr, moves := buildIMOVEIN(h, 1000295, accountInfo{AccountKey: "CUST001", Agent: "3"}, mappingOrder(), 3.7, vatDecision{Rate: 18})
+	if hdr.DocumentID != 35 || hdr.WareHouse != 4 || hdr.Copies != "2" || hdr.Agent != "9" {
+		t.Errorf("header = %+v", hdr)
+	}
+	if want := "HID-7 05/03/2026: leave at gate"; hdr.Remarks != want {
+		t.Errorf("remarks = %q, want %q", hdr.Remarks, want)
+	}
+	if moves[0].Unit != "קרטון" {
+		t.Errorf("unit = %q", moves[0].Unit)
+	}
+}
+
+func TestBuildIMOVEIN_VAT(t *testing.T) {
+	hdr, moves := buildIMOVEIN(config.HasavshevetConfig{}, 1000295, accountInfo{AccountKey: "CUST001"}, mappingOrder(), 3.7, vatDecision{Rate: 17})
+	if hdr.VatPrc != "17.00" || moves[0].VatExempt {
+		t.Errorf("VatPrc = %q, exempt = %v", hdr.VatPrc, moves[0].VatExempt)
+	}
+
+	hdr, moves = buildIMOVEIN(config.HasavshevetConfig{}, 1000295, accountInfo{AccountKey: "CUST001"}, mappingOrder(), 3.7, vatDecision{Exempt: true})
+	if hdr.VatPrc != "0.00" || !moves[0].VatExempt {
+		t.Errorf("exempt: VatPrc = %q, exempt = %v", hdr.VatPrc, moves[0].VatExempt)
+	}
+}
+
+func TestExemptValue(t *testing.T) {
+	for v, want := range map[string]bool{"": false, "0": false, " n ": false, "לא": false, "1": true, "Y": true, "כן": true} {
+		if got := exemptValue(v); got != want {
+			t.Errorf("exemptValue(%q) = %v, want %v", v, got, want)
+		}
+	}
+}
+
+var updateGolden = flag.Bool("update", false, "rewrite testdata/*.golden")
+
+// TestBuildDOCRecord_LineFieldsGolden cuts a record built from a line with
+// every optional attribute set into its imoveInFieldLengths fields and
+// compares the non-blank ones with testdata/doc_line_fields.golden.
+func TestBuildDOCRecord_LineFieldsGolden(t *testing.T) {
+	sum, num := 12.5, 7.0
+	req := mappingOrder()
+	req.Details = []OrderLineItem{
+		{SKU: "SKU-001", Title: "Item", Quantity: 2, OriginalPrice: 10},
+		{
+			SKU: "SKU-002", Title: "Boxed", Quantity: 3, Packs: 1, OriginalPrice: 4.5, Discount: 5,
+			Warehouse: 7, Remarks: "fragile", Unit: "קרטון", DueDate: "2026-03-09",
+			Batch: "LOT-42", Serial: "SN-0001",
+			Extra: OrderLineExtra{Text1: "gift wrap", Text2: "door 2", Sum1: &sum, Date1: "2026-04-01", Num1: &num},
+		},
+	}
+	if err := validateOrderRequest(req); err != nil {
+		t.Fatalf("validateOrderRequest: %v", err)
+	}
+	hdr, moves := buildIMOVEIN(config.HasavshevetConfig{}, 1000295, accountInfo{AccountKey: "CUST001", Agent: "3"}, req, 3.7, vatDecision{Rate: 18})
+
+	var got strings.Builder
+	for i, m := range moves {
+		rec := buildDOCRecord(hdr, m)
+		if len(rec) != prmTotalRecordLength {
+			t.Fatalf("record %d is %d bytes, want %d", i+1, len(rec), prmTotalRecordLength)
+		}
+		fmt.Fprintf(&got, "record %d\n", i+1)
+		pos := 0
+		for n := 2; n <= 87; n++ {
+			key := fmt.Sprintf("line%d", n)
+			field := rec[pos : pos+imoveInFieldLengths[key]]
+			pos += len(field)
+			if v := strings.TrimRight(decodeImportText(field), " "); v != "" {
+				fmt.Fprintf(&got, "%s\t%s\n", key, v)
+			}
+		}
+	}
+
+	path := filepath.Join("testdata", "doc_line_fields.golden")
+	if *updateGolden {
+		if err := os.WriteFile(path, []byte(got.String()), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if got.String() != string(want) {
+		t.Errorf("DOC fields differ from %s:\n%s", path, got.String())
+	}
+}
+
+func TestValidateOrderRequest_LineFields(t *testing.T) {
+	tests := []struct {
+		name string
+		line func(*OrderLineItem)
+	}{
+		{"unit too long", func(d *OrderLineItem) { d.Unit = "carton" }},
+		{"remarks too long", func(d *OrderLineItem) { d.Remarks = strings.Repeat("x", 21) }},
+		{"batch too long", func(d *OrderLineItem) { d.Batch = strings.Repeat("9", 21) }},
+		{"warehouse too wide", func(d *OrderLineItem) { d.Warehouse = 1234567890 }},
+		{"negative warehouse", func(d *OrderLineItem) { d.Warehouse = -1 }},
+		{"bad due date", func(d *OrderLineItem) { d.DueDate = "09/03/2026" }},
+		{"bad extra date", func(d *OrderLineItem) { d.Extra.Date2 = "tomorrow" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := mappingOrder()
+			tt.line(&req.Details[0])
+			if err := validateOrderRequest(req); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
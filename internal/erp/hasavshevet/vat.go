@@ -0,0 +1,99 @@
+package hasavshevet
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"erp-connector/internal/config"
+)
+
+// vatDecision is the VAT an order is imported with: the header rate (line18)
+// and, for exempt customers, the per-line exemption flag (line34).
+type vatDecision struct {
+	Rate   float64
+	Exempt bool
+}
+
+// resolveVAT decides the VAT for an order dated date. Exempt customers get
+// 0%; otherwise the rate comes from the configured table or the ERP query.
+func resolveVAT(ctx context.Context, dbConn *sql.DB, cfg config.HasavshevetVATConfig, dbName, accountKey string, date time.Time) (vatDecision, error) {
+	if column := cfg.ExemptColumnName(); column != "" {
+		exempt, err := queryVATExempt(ctx, dbConn, dbName, column, accountKey)
+		if err != nil {
+			return vatDecision{}, fmt.Errorf("read VAT exemption: %w", err)
+		}
+		if exempt {
+			return vatDecision{Exempt: true}, nil
+		}
+	}
+
+	if cfg.Source == config.VATSourceERP {
+		rate, err := queryERPVATRate(ctx, dbConn, cfg.ERPQuery, date)
+		if err != nil {
+			return vatDecision{}, fmt.Errorf("read VAT rate from ERP: %w", err)
+		}
+		return vatDecision{Rate: rate}, nil
+	}
+	rate, ok := cfg.RateOn(date)
+	if !ok {
+		return vatDecision{}, fmt.Errorf("no VAT rate configured for %s", date.Format(time.DateOnly))
+	}
+	return vatDecision{Rate: rate}, nil
+}
+
+// queryVATExempt reads the customer's exemption column from Accounts. A
+// column the installation does not have means no customer is exempt.
+func queryVATExempt(ctx context.Context, dbConn *sql.DB, dbName, column, accountKey string) (bool, error) {
+	if !isSafeDBName(dbName) || !isSafeDBName(column) {
+		return false, fmt.Errorf("invalid Accounts column [%s].[dbo].[Accounts].[%s]", dbName, column)
+	}
+	table := fmt.Sprintf("[%s].[dbo].[Accounts]", dbName)
+
+	var length sql.NullInt64
+	if err := dbConn.QueryRowContext(ctx, `SELECT COL_LENGTH(@table, @column);`,
+		sql.Named("table", table), sql.Named("column", column)).Scan(&length); err != nil {
+		return false, err
+	}
+	if !length.Valid {
+		return false, nil
+	}
+
+	var value sql.NullString
+	err := dbConn.QueryRowContext(ctx,
+		fmt.Sprintf(`SELECT TOP 1 CAST([%s] AS nvarchar(50)) FROM %s WHERE AccountKey = @key;`, column, table),
+		sql.Named("key", accountKey)).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return exemptValue(value.String), nil
+}
+
+// exemptValue reports whether an exemption column value marks the customer
+// as exempt: anything but blank, 0, N or "לא".
+func exemptValue(v string) bool {
+	switch strings.ToUpper(strings.TrimSpace(v)) {
+	case "", "0", "N", "NO", "FALSE", "לא":
+		return false
+	}
+	return true
+}
+
+// queryERPVATRate runs the configured vat.erpQuery with @date and returns the
+// rate it selects.
+func queryERPVATRate(ctx context.Context, dbConn *sql.DB, query string, date time.Time) (float64, error) {
+	var rate sql.NullFloat64
+	if err := dbConn.QueryRowContext(ctx, query, sql.Named("date", date)).Scan(&rate); err != nil {
+		return 0, err
+	}
+	if !rate.Valid || rate.Float64 < 0 || rate.Float64 >= 100 {
+		return 0, fmt.Errorf("query returned %v, want a rate between 0 and 100", rate)
+	}
+	return rate.Float64, nil
+}
@@ -0,0 +1,151 @@
+package hasavshevet
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// defaultImportVerifyWindow is how long the sender waits for the imported
+// document when hasavshevet.importVerifySeconds is not set.
+const defaultImportVerifyWindow = 60 * time.Second
+
+// importVerifyInterval is the delay between Stock lookups while waiting.
+const importVerifyInterval = 2 * time.Second
+
+// maxImportOutput bounds the importer output kept on a failed job.
+const maxImportOutput = 4000
+
+// ImportError is returned when the importer ran but no Stock document with
+// Asmahta2 = OrderNumber appeared within Window, or, with verification off
+// (Window 0), when the importer reported errors or exited non-zero. Output is the importer's
+// combined output, trimmed to its last maxImportOutput bytes; Issues are the
+// errors parsed from its error files and output.
+type ImportError struct {
+	OrderNumber int64
+	ExitCode    int
+	Output      string
+	Window      time.Duration
+	Issues      []ImportIssue
+}
+
+func (e *ImportError) Error() string {
+	var b strings.Builder
+	if e.Window > 0 {
+		fmt.Fprintf(&b, "import not verified: no Stock document with Asmahta2=%d after %s", e.OrderNumber, e.Window)
+	} else {
+		fmt.Fprintf(&b, "import failed for order %d", e.OrderNumber)
+	}
+	for i, issue := range e.Issues {
+		if i == 0 {
+			b.WriteString(": ")
+		} else {
+			b.WriteString("; ")
+		}
+		b.WriteString(issue.String())
+	}
+	fmt.Fprintf(&b, " (importer exit=%d output=%q)", e.ExitCode, e.Output)
+	return b.String()
+}
+
+// UnverifiedImportError is returned when the importer ran but its document
+// could not be looked up: the database was unavailable or the Stock query
+// failed. The order may be in the ERP, so the job is not a failure and must
+// not be resubmitted. Output and Issues are as in ImportError.
+type UnverifiedImportError struct {
+	OrderNumber int64
+	ExitCode    int
+	Output      string
+	Issues      []ImportIssue
+	Err         error
+}
+
+func (e *UnverifiedImportError) Error() string {
+	return fmt.Sprintf("import unverified for order %d: %v; check the ERP before resubmitting (importer exit=%d output=%q)",
+		e.OrderNumber, e.Err, e.ExitCode, e.Output)
+}
+
+func (e *UnverifiedImportError) Unwrap() error { return e.Err }
+
+// importedDocument is the Stock row the importer created for an order.
+type importedDocument struct {
+	ID         int64
+	DocumentID int
+	Number     string
+}
+
+// importVerifyWindow returns the configured wait for the imported document;
+// zero or less disables verification.
+func (s *Sender) importVerifyWindow() time.Duration {
+	switch secs := s.cfg.Hasavshevet.ImportVerifySeconds; {
+	case secs < 0:
+		return 0
+	case secs == 0:
+		return defaultImportVerifyWindow
+	default:
+		return time.Duration(secs) * time.Second
+	}
+}
+
+// findImportedDocument looks up the newest Stock document carrying the
+// connector order number in Asmahta2 (IMOVEIN header field, see buildIMOVEIN).
+func findImportedDocument(ctx context.Context, dbConn *sql.DB, orderNum int64) (importedDocument, bool, error) {
+	var (
+		doc       importedDocument
+		docNumber sql.NullString
+	)
+	err := dbConn.QueryRowContext(ctx, `
+		SELECT TOP 1 S.ID, S.DocumentID, S.DocNumber
+		FROM dbo.Stock AS S WITH (NOLOCK)
+		WHERE S.Asmahta2 = @asmahta2
+		ORDER BY S.ID DESC;`,
+		sql.Named("asmahta2", strconv.FormatInt(orderNum, 10)),
+	).Scan(&doc.ID, &doc.DocumentID, &docNumber)
+	if errors.Is(err, sql.ErrNoRows) {
+		return importedDocument{}, false, nil
+	}
+	if err != nil {
+		return importedDocument{}, false, err
+	}
+	doc.Number = strings.TrimSpace(docNumber.String)
+	return doc, true, nil
+}
+
+// waitForDocument calls find until it reports the document, the window
+// elapses or ctx is cancelled. The first lookup runs immediately.
+func waitForDocument(ctx context.Context, find func(context.Context) (importedDocument, bool, error), window, interval time.Duration) (importedDocument, bool, error) {
+	deadline := time.Now().Add(window)
+	for {
+		doc, ok, err := find(ctx)
+		if err != nil || ok {
+			return doc, ok, err
+		}
+		if !time.Now().Add(interval).Before(deadline) {
+			return importedDocument{}, false, nil
+		}
+		select {
+		case <-ctx.Done():
+			return importedDocument{}, false, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// trimOutput keeps the tail of the importer output, where has.exe reports
+// the error. The cut moves forward to a rune start so Hebrew output stays
+// valid UTF-8.
+func trimOutput(out string) string {
+	if len(out) <= maxImportOutput {
+		return out
+	}
+	cut := len(out) - maxImportOutput
+	for cut < len(out) && !utf8.RuneStart(out[cut]) {
+		cut++
+	}
+	return "…" + out[cut:]
+}
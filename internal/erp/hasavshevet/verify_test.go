@@ -0,0 +1,89 @@
+package hasavshevet
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"erp-connector/internal/config"
+)
+
+func TestWaitForDocument_FoundAfterRetries(t *testing.T) {
+	calls := 0
+	find := func(context.Context) (importedDocument, bool, error) {
+		calls++
+		if calls < 3 {
+			return importedDocument{}, false, nil
+		}
+		return importedDocument{ID: 7, DocumentID: 30, Number: "5001"}, true, nil
+	}
+	doc, ok, err := waitForDocument(context.Background(), find, time.Second, time.Millisecond)
+	if err != nil || !ok || doc.ID != 7 || calls != 3 {
+		t.Fatalf("doc=%+v ok=%v err=%v calls=%d", doc, ok, err, calls)
+	}
+}
+
+func TestWaitForDocument_WindowElapses(t *testing.T) {
+	calls := 0
+	find := func(context.Context) (importedDocument, bool, error) {
+		calls++
+		return importedDocument{}, false, nil
+	}
+	_, ok, err := waitForDocument(context.Background(), find, 20*time.Millisecond, 5*time.Millisecond)
+	if err != nil || ok {
+		t.Fatalf("ok=%v err=%v, want not found", ok, err)
+	}
+	if calls < 2 {
+		t.Errorf("calls = %d, want the lookup retried within the window", calls)
+	}
+}
+
+func TestWaitForDocument_LookupError(t *testing.T) {
+	boom := errors.New("boom")
+	find := func(context.Context) (importedDocument, bool, error) { return importedDocument{}, false, boom }
+	if _, _, err := waitForDocument(context.Background(), find, time.Second, time.Millisecond); !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want lookup error", err)
+	}
+}
+
+func TestImportVerifyWindow(t *testing.T) {
+	tests := []struct {
+		secs int
+		want time.Duration
+	}{
+		{0, defaultImportVerifyWindow},
+		{15, 15 * time.Second},
+		{-1, 0},
+	}
+	for _, tt := range tests {
+		s := &Sender{cfg: config.Config{Hasavshevet: config.HasavshevetConfig{ImportVerifySeconds: tt.secs}}}
+		if got := s.importVerifyWindow(); got != tt.want {
+			t.Errorf("importVerifySeconds=%d: window = %s, want %s", tt.secs, got, tt.want)
+		}
+	}
+}
+
+func TestImportError_IncludesOutput(t *testing.T) {
+	err := &ImportError{OrderNumber: 1000295, ExitCode: 1, Output: trimOutput(strings.Repeat("x", maxImportOutput+10) + "ERROR line 12"), Window: time.Minute}
+	msg := err.Error()
+	if !strings.Contains(msg, "Asmahta2=1000295") || !strings.Contains(msg, "ERROR line 12") {
+		t.Errorf("message = %q", msg)
+	}
+	if len(err.Output) > maxImportOutput+len("…") {
+		t.Errorf("output not trimmed: %d bytes", len(err.Output))
+	}
+}
+
+func TestTrimOutput_RuneBoundary(t *testing.T) {
+	// "ש" is two bytes: the odd suffix puts the byte cut inside a rune.
+	out := trimOutput(strings.Repeat("ש", maxImportOutput) + "x")
+	if !utf8.ValidString(out) {
+		t.Errorf("trimmed output is not valid UTF-8: %q", out[:8])
+	}
+	if len(out) > maxImportOutput+len("…") {
+		t.Errorf("output not trimmed: %d bytes", len(out))
+	}
+}
@@ -0,0 +1,58 @@
+package hasavshevet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// voidedOrder is the history record of a reserved order number that was
+// never imported, so the gap in the numbering can be explained.
+type voidedOrder struct {
+	OrderNumber int64  `json:"orderNumber"`
+	JobID       string `json:"jobId"`
+	HistoryID   string `json:"historyId,omitempty"`
+	UserExtID   string `json:"userExtId,omitempty"`
+	VoidedAt    string `json:"voidedAt"`
+	Reason      string `json:"reason"`
+}
+
+// VoidedPath is where voidOrderNumber records order orderNum as voided.
+func VoidedPath(sendOrderDir string, orderNum int64) string {
+	return filepath.Join(sendOrderDir, "history", fmt.Sprintf("%d", orderNum), fmt.Sprintf("VOIDED_%d.json", orderNum))
+}
+
+// voidOrderNumber records the number reserved for job as voided in
+// history/<N>/. Numbers are never handed back: the job ID is the order
+// number, so a reused number would make a client's old job ID point at
+// another order.
+func (s *Sender) voidOrderNumber(job *orderJob, reason string) error {
+	if s == nil || s.numberStore == nil || job.orderNumber == 0 {
+		return nil
+	}
+	if s.cfg.SendOrderDir == "" {
+		return errors.New("sendOrderDir is not configured")
+	}
+	path := VoidedPath(s.cfg.SendOrderDir, job.orderNumber)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create history dir: %w", err)
+	}
+	b, err := json.MarshalIndent(voidedOrder{
+		OrderNumber: job.orderNumber,
+		JobID:       job.id,
+		HistoryID:   job.req.HistoryID,
+		UserExtID:   job.req.UserExtID,
+		VoidedAt:    time.Now().Format(time.RFC3339),
+		Reason:      reason,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package erp
+
+import "time"
+
+// Item is an ERP item normalised across ERPs. Fields an ERP does not keep are
+// left empty; ERP-specific columns go to Details.
+type Item struct {
+	SKU           string
+	Name          string
+	Barcodes      []string
+	Group         string
+	Manufacturer  string
+	SalesUnit     string
+	InventoryUnit string
+	// PackSize is the number of inventory units in one sales pack (1 when the
+	// ERP keeps none).
+	PackSize   float64
+	VATClass   string
+	Active     bool
+	ModifiedAt *time.Time
+	Details    map[string]any
+}
+
+// ItemSearch is one page of an item master search. Query matches the SKU
+// prefix or the name; the other filters are exact and combine with AND.
+type ItemSearch struct {
+	Query         string
+	Barcode       string
+	Group         string
+	Manufacturer  string
+	ModifiedSince time.Time // zero: no filter
+	Page          int       // 1-based
+	PageSize      int
+}
+
+// Offset is the number of rows before the requested page.
+func (q ItemSearch) Offset() int {
+	if q.Page < 1 {
+		return 0
+	}
+	return (q.Page - 1) * q.PageSize
+}
+
+type ItemPage struct {
+	Items    []Item
+	Page     int
+	PageSize int
+	Total    int
+}
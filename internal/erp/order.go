@@ -0,0 +1,131 @@
+package erp
+
+import (
+	"context"
+	"time"
+)
+
+// OrderRequest is the ERP-neutral send-order request, translated from the API
+// DTO before it is handed to an ERP's order queue.
+type OrderRequest struct {
+	DocumentType  string // ORDER, QUOATE or RETURN
+	UserExtID     string
+	DueDate       string
+	CreatedDate   string
+	Comment       string
+	Discount      float64
+	HistoryID     string
+	Total         float64
+	Currency      string
+	CustomerEmail string // optional; used for PDF email delivery
+	Priority      string // queue lane: PriorityUrgent, PriorityNormal (empty) or PriorityBulk
+	Details       []OrderLineItem
+}
+
+// Order priorities. Queues with lanes take urgent orders first and bulk
+// orders last; the others process orders in submission order.
+const (
+	PriorityUrgent = "urgent"
+	PriorityNormal = "normal"
+	PriorityBulk   = "bulk"
+)
+
+// OrderPriorities lists the accepted OrderRequest.Priority values, highest
+// first.
+func OrderPriorities() []string {
+	return []string{PriorityUrgent, PriorityNormal, PriorityBulk}
+}
+
+// QueuePosition is where a queued job stands. Position is 1-based among the
+// waiting jobs (1 runs next). EstimatedWait is only set when Estimated is,
+// i.e. once the queue has timed an order.
+type QueuePosition struct {
+	Position      int
+	EstimatedWait time.Duration
+	Estimated     bool
+}
+
+// OrderLineItem is one line item in an order.
+type OrderLineItem struct {
+	Title         string
+	SKU           string
+	Quantity      float64
+	Packs         float64 // number of packages (Hasavshevet IMOVEIN line33)
+	OriginalPrice float64
+	SinglePrice   float64
+	TotalPrice    float64
+	Discount      float64
+
+	// Optional line attributes; zero values leave the ERP's defaults.
+	Warehouse int            // warehouse for this line; 0 uses the document's
+	Remarks   string         // line remarks
+	Unit      string         // unit of measure; empty uses the document's
+	DueDate   string         // line due date; empty uses the order's dueDate
+	Batch     string         // batch (lot) number
+	Serial    string         // serial number
+	Extra     OrderLineExtra // free-text and numeric extra fields
+}
+
+// OrderLineExtra holds an order line's extra fields. Nil numbers are unset.
+type OrderLineExtra struct {
+	Text1, Text2 string
+	Sum1, Sum2   *float64
+	Date1, Date2 string
+	Num1, Num2   *float64
+}
+
+// OrderResult is the outcome of a processed order, handed to post-order hooks.
+type OrderResult struct {
+	OrderNumber    int64  // connector order number (Hasavshevet lastOrderNumber)
+	DocumentNumber string // number assigned by the ERP, when it assigns one
+	Entity         string // ERP object the document was created in (e.g. ORDERS, Orders)
+	WrittenFiles   []string
+	Account        AccountInfo
+	VATRate        *float64 // VAT percent the document was created with, when the connector sets it
+	VATExempt      bool     // the customer is VAT exempt (VATRate is 0)
+}
+
+// OrderPreview is a dry run of an order: the document the ERP would receive,
+// built without writing or submitting anything.
+type OrderPreview struct {
+	OrderNumber  int64 // number the order would get now; not reserved
+	Account      AccountInfo
+	CurrencyRate float64
+	VATRate      *float64
+	VATExempt    bool
+	Records      []PreviewRecord // one per order line
+	Warnings     []string        // fields the ERP would receive truncated
+}
+
+// PreviewRecord is one document line of an OrderPreview.
+type PreviewRecord struct {
+	Line   int // 1-based order line
+	Fields []PreviewField
+}
+
+// PreviewField is one non-blank field of a PreviewRecord. Value is what the
+// ERP receives; Truncated marks values cut to Width.
+type PreviewField struct {
+	Key       string
+	Title     string
+	Start     int
+	Width     int
+	Value     string
+	Truncated bool
+}
+
+// AccountInfo holds customer data needed for PDF generation.
+type AccountInfo struct {
+	AccountKey string
+	FullName   string
+	Address    string
+	City       string
+	Phone      string
+}
+
+// PostOrderHook is called after an order has been processed successfully.
+// Implementations run on the order queue's worker goroutine. Errors are
+// logged but never fail the order.
+type PostOrderHook interface {
+	AfterOrder(ctx context.Context, req OrderRequest, result *OrderResult) error
+}
@@ -0,0 +1,124 @@
+package priority
+
+import (
+	"context"
+	"errors"
+
+	"erp-connector/internal/config"
+	"erp-connector/internal/erp"
+	"erp-connector/internal/logger"
+)
+
+func init() {
+	erp.Register(config.ERPPriority, erp.Registration{New: newAdapter})
+}
+
+// Adapter exposes Priority's OData API through the erp.Adapter capability
+// interfaces.
+type Adapter struct {
+	client  *Client
+	queue   *erp.OrderQueue
+	initErr error // configuration error; the adapter then advertises nothing
+	log     logger.LoggerService
+}
+
+// NewAdapter wraps a client and its order queue.
+func NewAdapter(client *Client, queue *erp.OrderQueue, log logger.LoggerService) *Adapter {
+	return &Adapter{client: client, queue: queue, log: log}
+}
+
+// newAdapter never fails: an invalid priority block is logged and reported by
+// Health so the daemon (and other companies) keep running.
+func newAdapter(d erp.Deps) (erp.Adapter, error) {
+	client, err := NewClient(d.Config.Priority, d.Password)
+	if err != nil {
+		if d.Logger != nil {
+			d.Logger.Error("priority client configuration invalid", err)
+		}
+		return &Adapter{initErr: err, log: d.Logger}, nil
+	}
+	return NewAdapter(client, NewOrderQueue(client, d.Config.Priority.DefaultWarehouse, d.Logger, d.Hooks...), d.Logger), nil
+}
+
+func (a *Adapter) ERP() config.ERPType { return config.ERPPriority }
+
+func (a *Adapter) Capabilities() []erp.Capability {
+	if a.client == nil {
+		return nil
+	}
+	caps := []erp.Capability{erp.CapPriceStock}
+	if a.queue != nil {
+		caps = append(caps, erp.CapSendOrder)
+	}
+	return caps
+}
+
+func (a *Adapter) Health(ctx context.Context) error {
+	if a.client == nil {
+		if a.initErr != nil {
+			return a.initErr
+		}
+		return errors.New("priority client is not configured")
+	}
+	return a.client.Ping(ctx)
+}
+
+func (a *Adapter) Start(ctx context.Context) {
+	if a.queue == nil {
+		return
+	}
+	a.queue.Start(ctx)
+	if a.log != nil {
+		a.log.Info("priority order queue started")
+	}
+}
+
+func (a *Adapter) FetchPriceAndStock(ctx context.Context, req erp.PriceStockRequest) (erp.PriceStockResult, error) {
+	return FetchPriceAndStock(ctx, a.client, req)
+}
+
+func (a *Adapter) SubmitOrder(req erp.OrderRequest) (string, error) {
+	if a.queue == nil {
+		return "", erp.ErrNotSupported
+	}
+	return a.queue.Submit(req)
+}
+
+// Queue returns the order submission queue.
+func (a *Adapter) Queue() *erp.OrderQueue { return a.queue }
+
+// OrderJob reports the state of a sendOrder job.
+func (a *Adapter) OrderJob(jobID string) (erp.JobResult, bool) {
+	if a.queue == nil {
+		return erp.JobResult{}, false
+	}
+	r, ok := a.queue.Status(jobID)
+	if !ok {
+		return erp.JobResult{}, false
+	}
+	return *r, true
+}
+
+// CancelOrder cancels a sendOrder job that is still queued.
+func (a *Adapter) CancelOrder(jobID string) (erp.JobResult, error) {
+	if a.queue == nil {
+		return erp.JobResult{}, erp.ErrNotSupported
+	}
+	r, err := a.queue.Cancel(jobID)
+	if err != nil {
+		return erp.JobResult{}, err
+	}
+	return *r, nil
+}
+
+// UpdateOrder replaces the order of a sendOrder job that is still queued.
+func (a *Adapter) UpdateOrder(jobID string, req erp.OrderRequest) (erp.JobResult, error) {
+	if a.queue == nil {
+		return erp.JobResult{}, erp.ErrNotSupported
+	}
+	r, err := a.queue.Update(jobID, req)
+	if err != nil {
+		return erp.JobResult{}, err
+	}
+	return *r, nil
+}
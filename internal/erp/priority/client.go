@@ -0,0 +1,238 @@
+// Package priority talks to Priority ERP through its REST/OData API.
+//
+// Unlike Hasavshevet and SAP B1, Priority is never read through SQL Server:
+// price lists, customer discounts, warehouse balances and order documents are
+// all OData entities (forms) under the company's service root.
+package priority
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"erp-connector/internal/config"
+)
+
+const (
+	defaultTimeout  = 30 * time.Second
+	maxResponseSize = 16 << 20
+	// maxPages bounds @odata.nextLink paging so a misbehaving server cannot
+	// keep a request running forever.
+	maxPages = 200
+)
+
+// APIError is a non-2xx response from the OData service.
+type APIError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *APIError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("priority api: %d %s: %s", e.Status, e.Code, e.Message)
+	}
+	return fmt.Sprintf("priority api: %d: %s", e.Status, e.Message)
+}
+
+// Client is a minimal OData client bound to one Priority company.
+type Client struct {
+	baseURL *url.URL
+	user    string
+	pass    string
+	appID   string
+	appKey  string
+	http    *http.Client
+}
+
+// NewClient validates cfg and returns a client. It does not contact the server.
+func NewClient(cfg config.PriorityConfig, password string) (*Client, error) {
+	raw := strings.TrimSpace(cfg.ServiceURL)
+	if raw == "" {
+		return nil, errors.New("priority.serviceURL is required")
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("priority.serviceURL %q must be an absolute http(s) URL", raw)
+	}
+	if strings.TrimSpace(cfg.User) == "" {
+		return nil, errors.New("priority.user is required")
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+
+	timeout := defaultTimeout
+	if cfg.TimeoutSeconds > 0 {
+		timeout = time.Duration(cfg.TimeoutSeconds) * time.Second
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.TrustServerCertificate {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	return &Client{
+		baseURL: u,
+		user:    strings.TrimSpace(cfg.User),
+		pass:    password,
+		appID:   cfg.AppID,
+		appKey:  cfg.AppKey,
+		http:    &http.Client{Timeout: timeout, Transport: transport},
+	}, nil
+}
+
+// Ping fetches the service document to verify URL and credentials.
+func (c *Client) Ping(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, c.baseURL.String(), nil, nil)
+}
+
+// getAll GETs an entity set and follows @odata.nextLink, returning every
+// element of the "value" arrays.
+func getAll[T any](ctx context.Context, c *Client, entitySet string, query url.Values) ([]T, error) {
+	next := c.resolve(entitySet, query)
+	var all []T
+	for page := 0; next != ""; page++ {
+		if page == maxPages {
+			return nil, fmt.Errorf("priority api: %s returned more than %d pages", entitySet, maxPages)
+		}
+		var resp struct {
+			Value    []T    `json:"value"`
+			NextLink string `json:"@odata.nextLink"`
+		}
+		if err := c.do(ctx, http.MethodGet, next, nil, &resp); err != nil {
+			return nil, err
+		}
+		all = append(all, resp.Value...)
+		next = ""
+		if resp.NextLink != "" {
+			ref, err := url.Parse(resp.NextLink)
+			if err != nil {
+				return nil, fmt.Errorf("priority api: invalid nextLink %q", resp.NextLink)
+			}
+			next = c.baseURL.ResolveReference(ref).String()
+		}
+	}
+	return all, nil
+}
+
+// post creates an entity (deep insert) and decodes the created entity.
+func (c *Client) post(ctx context.Context, entitySet string, body, out any) error {
+	return c.do(ctx, http.MethodPost, c.resolve(entitySet, nil), body, out)
+}
+
+func (c *Client) resolve(entitySet string, query url.Values) string {
+	u := *c.baseURL
+	u.Path += entitySet
+	if len(query) > 0 {
+		// OData expects %20 rather than '+' for spaces inside $filter, and
+		// some gateways only recognise system options with a literal '$'.
+		q := strings.ReplaceAll(query.Encode(), "+", "%20")
+		u.RawQuery = strings.ReplaceAll(q, "%24", "$")
+	}
+	return u.String()
+}
+
+func (c *Client) do(ctx context.Context, method, target string, body, out any) error {
+	var rd io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		rd = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, rd)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.user, c.pass)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.appID != "" {
+		req.Header.Set("X-App-Id", c.appID)
+	}
+	if c.appKey != "" {
+		req.Header.Set("X-App-Key", c.appKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("priority api: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return fmt.Errorf("priority api: read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return parseAPIError(resp.StatusCode, data)
+	}
+	if out == nil || len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("priority api: decode response: %w", err)
+	}
+	return nil
+}
+
+// parseAPIError understands the standard OData error body and Priority's form
+// interface errors ({"FORM":{"InterfaceErrors":{"text":...}}}), falling back
+// to the raw body text.
+func parseAPIError(status int, data []byte) error {
+	var odata struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(data, &odata) == nil && odata.Error.Message != "" {
+		return &APIError{Status: status, Code: odata.Error.Code, Message: odata.Error.Message}
+	}
+
+	var form struct {
+		Form struct {
+			InterfaceErrors struct {
+				Text string `json:"text"`
+			} `json:"InterfaceErrors"`
+		} `json:"FORM"`
+	}
+	if json.Unmarshal(data, &form) == nil && form.Form.InterfaceErrors.Text != "" {
+		return &APIError{Status: status, Code: "InterfaceErrors", Message: form.Form.InterfaceErrors.Text}
+	}
+
+	msg := strings.TrimSpace(string(data))
+	if len(msg) > 500 {
+		msg = msg[:500]
+	}
+	if msg == "" {
+		msg = http.StatusText(status)
+	}
+	return &APIError{Status: status, Message: msg}
+}
+
+// quote renders s as an OData string literal.
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// anyOf builds "field eq 'a' or field eq 'b'" for values.
+func anyOf(field string, values []string) string {
+	parts := make([]string, 0, len(values))
+	for _, v := range values {
+		parts = append(parts, field+" eq "+quote(v))
+	}
+	return strings.Join(parts, " or ")
+}
@@ -0,0 +1,265 @@
+package priority
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+
+	"erp-connector/internal/erp"
+)
+
+// skuChunkSize bounds the number of PARTNAME terms in one $filter so request
+// URLs stay well below IIS' default query string limit.
+const skuChunkSize = 50
+
+type customerEntity struct {
+	CustName  string `json:"CUSTNAME"`
+	PriceList []struct {
+		PLName string `json:"PLNAME"`
+	} `json:"CUSTPLIST_SUBFORM"`
+	PartDiscounts []struct {
+		PartName string  `json:"PARTNAME"`
+		Percent  float64 `json:"PERCENT"`
+	} `json:"CUSTPARTDISC_SUBFORM"`
+}
+
+type priceListEntity struct {
+	PLName string `json:"PLNAME"`
+	Parts  []struct {
+		PartName string  `json:"PARTNAME"`
+		Price    float64 `json:"PRICE"`
+		Quant    float64 `json:"QUANT"`
+		Code     string  `json:"CODE"`
+	} `json:"PARTPRICE2_SUBFORM"`
+}
+
+type partEntity struct {
+	PartName string `json:"PARTNAME"`
+	Balances []struct {
+		WarhsName string  `json:"WARHSNAME"`
+		Balance   float64 `json:"BALANCE"`
+	} `json:"PARTBAL_SUBFORM"`
+}
+
+type partPrice struct {
+	price    float64
+	quant    float64
+	currency string
+}
+
+// FetchPriceAndStock resolves prices from the requested price lists (or the
+// customer's assigned price lists), applies the customer's per-part discount
+// and returns warehouse balances from LOGPART.
+//
+// Price lists are tried in order; the first list that prices a SKU wins. Only
+// the base tier (lowest minimum quantity) of each list is used.
+func FetchPriceAndStock(ctx context.Context, client *Client, req erp.PriceStockRequest) (erp.PriceStockResult, error) {
+	if client == nil {
+		return erp.PriceStockResult{}, errors.New("priority client is required")
+	}
+	skus := uniqueStrings(req.SKUList)
+	if len(skus) == 0 {
+		return erp.PriceStockResult{Items: []erp.PriceStockItem{}}, nil
+	}
+
+	priceLists := nonEmpty(req.PriceList)
+	discounts := map[string]float64{}
+	if custName := strings.TrimSpace(req.UserExtID); custName != "" {
+		cust, err := fetchCustomer(ctx, client, custName)
+		if err != nil {
+			return erp.PriceStockResult{}, err
+		}
+		if cust != nil {
+			if len(priceLists) == 0 {
+				for _, pl := range cust.PriceList {
+					if pl.PLName != "" {
+						priceLists = append(priceLists, pl.PLName)
+					}
+				}
+			}
+			for _, d := range cust.PartDiscounts {
+				discounts[d.PartName] = d.Percent
+			}
+		}
+	}
+
+	pricesByList, err := fetchPriceLists(ctx, client, priceLists, skus)
+	if err != nil {
+		return erp.PriceStockResult{}, err
+	}
+	stockBySKU, err := fetchBalances(ctx, client, skus, nonEmpty(req.Warehouses))
+	if err != nil {
+		return erp.PriceStockResult{}, err
+	}
+
+	items := make([]erp.PriceStockItem, 0, len(skus))
+	for _, sku := range skus {
+		var prices map[string]float64
+		details := map[string]any{}
+
+		byList := map[string]float64{}
+		for _, pl := range priceLists {
+			p, ok := pricesByList[pl][sku]
+			if !ok {
+				continue
+			}
+			byList[pl] = p.price
+			if prices != nil {
+				continue
+			}
+			disc := discounts[sku]
+			prices = map[string]float64{
+				"price":              p.price,
+				"priceAfterDiscount": p.price * (1 - disc/100),
+			}
+			details["priceList"] = pl
+			if p.currency != "" {
+				details["currency"] = p.currency
+			}
+			if disc != 0 {
+				details["discountPrc"] = disc
+			}
+		}
+		if len(byList) > 1 {
+			details["priceLists"] = byList
+		}
+		if len(details) == 0 {
+			details = nil
+		}
+
+		items = append(items, erp.PriceStockItem{
+			SKU:              sku,
+			Prices:           prices,
+			StockByWarehouse: stockBySKU[sku],
+			Details:          details,
+		})
+	}
+	return erp.PriceStockResult{Items: items}, nil
+}
+
+// fetchCustomer returns the customer with its price lists and part discounts,
+// or nil when CUSTNAME does not exist.
+func fetchCustomer(ctx context.Context, client *Client, custName string) (*customerEntity, error) {
+	q := url.Values{}
+	q.Set("$filter", "CUSTNAME eq "+quote(custName))
+	q.Set("$select", "CUSTNAME")
+	q.Set("$expand", "CUSTPLIST_SUBFORM($select=PLNAME),CUSTPARTDISC_SUBFORM($select=PARTNAME,PERCENT)")
+	custs, err := getAll[customerEntity](ctx, client, "CUSTOMERS", q)
+	if err != nil {
+		return nil, err
+	}
+	if len(custs) == 0 {
+		return nil, nil
+	}
+	return &custs[0], nil
+}
+
+// fetchPriceLists returns price list → SKU → base tier price.
+func fetchPriceLists(ctx context.Context, client *Client, priceLists, skus []string) (map[string]map[string]partPrice, error) {
+	out := make(map[string]map[string]partPrice, len(priceLists))
+	if len(priceLists) == 0 {
+		return out, nil
+	}
+	for _, chunk := range chunks(skus, skuChunkSize) {
+		q := url.Values{}
+		q.Set("$filter", anyOf("PLNAME", priceLists))
+		q.Set("$select", "PLNAME")
+		q.Set("$expand", "PARTPRICE2_SUBFORM($filter="+anyOf("PARTNAME", chunk)+";$select=PARTNAME,PRICE,QUANT,CODE)")
+		lists, err := getAll[priceListEntity](ctx, client, "PRICELIST", q)
+		if err != nil {
+			return nil, err
+		}
+		for _, pl := range lists {
+			m := out[pl.PLName]
+			if m == nil {
+				m = map[string]partPrice{}
+				out[pl.PLName] = m
+			}
+			for _, p := range pl.Parts {
+				cur, seen := m[p.PartName]
+				if seen && cur.quant <= p.Quant {
+					continue
+				}
+				m[p.PartName] = partPrice{price: p.Price, quant: p.Quant, currency: p.Code}
+			}
+		}
+	}
+	return out, nil
+}
+
+// fetchBalances returns SKU → warehouse → balance, summed over bin locations.
+// An empty warehouse list returns every warehouse.
+func fetchBalances(ctx context.Context, client *Client, skus, warehouses []string) (map[string]map[string]float64, error) {
+	want := map[string]bool{}
+	for _, w := range warehouses {
+		want[w] = true
+	}
+	out := make(map[string]map[string]float64, len(skus))
+	for _, chunk := range chunks(skus, skuChunkSize) {
+		q := url.Values{}
+		q.Set("$filter", anyOf("PARTNAME", chunk))
+		q.Set("$select", "PARTNAME")
+		q.Set("$expand", "PARTBAL_SUBFORM($select=WARHSNAME,BALANCE)")
+		parts, err := getAll[partEntity](ctx, client, "LOGPART", q)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range parts {
+			for _, b := range p.Balances {
+				if len(want) > 0 && !want[b.WarhsName] {
+					continue
+				}
+				m := out[p.PartName]
+				if m == nil {
+					m = map[string]float64{}
+					out[p.PartName] = m
+				}
+				m[b.WarhsName] += b.Balance
+			}
+		}
+	}
+	return out, nil
+}
+
+func chunks(values []string, size int) [][]string {
+	var out [][]string
+	for len(values) > size {
+		out = append(out, values[:size])
+		values = values[size:]
+	}
+	if len(values) > 0 {
+		out = append(out, values)
+	}
+	return out
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		out = append(out, v)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// nonEmpty trims values and drops blanks, keeping order.
+func nonEmpty(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
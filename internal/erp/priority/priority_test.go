@@ -0,0 +1,508 @@
+package priority
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"erp-connector/internal/config"
+	"erp-connector/internal/erp"
+)
+
+// odataStandIn is a minimal in-memory stand-in for Priority's OData service.
+// It understands just enough of $filter ("FIELD eq 'x' or ...") to serve the
+// connector's queries and records every request for assertions.
+type odataStandIn struct {
+	t *testing.T
+
+	mu       sync.Mutex
+	requests []*http.Request
+	posted   map[string]map[string]any
+
+	customers  []map[string]any
+	priceLists []map[string]any
+	parts      []map[string]any
+	// pageSize > 0 splits LOGPART responses using @odata.nextLink.
+	pageSize int
+}
+
+var eqRe = regexp.MustCompile(`(\w+) eq '((?:[^']|'')*)'`)
+
+// matches reports whether entity satisfies an "a eq 'x' or b eq 'y'" filter.
+func matches(filter string, entity map[string]any) bool {
+	if filter == "" {
+		return true
+	}
+	for _, m := range eqRe.FindAllStringSubmatch(filter, -1) {
+		if v, _ := entity[m[1]].(string); v == strings.ReplaceAll(m[2], "''", "'") {
+			return true
+		}
+	}
+	return false
+}
+
+// expandFilter extracts the nested $filter of a subform from $expand.
+func expandFilter(expand, subform string) string {
+	i := strings.Index(expand, subform+"(")
+	if i < 0 {
+		return ""
+	}
+	inner := expand[i+len(subform)+1:]
+	if j := strings.Index(inner, ")"); j >= 0 {
+		inner = inner[:j]
+	}
+	for _, opt := range strings.Split(inner, ";") {
+		if strings.HasPrefix(opt, "$filter=") {
+			return strings.TrimPrefix(opt, "$filter=")
+		}
+	}
+	return ""
+}
+
+func (s *odataStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r)
+	s.mu.Unlock()
+
+	if user, pass, ok := r.BasicAuth(); !ok || user != "api" || pass != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	entitySet := strings.TrimPrefix(r.URL.Path, "/odata/Priority/tabula.ini/demo/")
+	q := r.URL.Query()
+
+	if r.Method == http.MethodPost {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			s.t.Errorf("decode POST body: %v", err)
+		}
+		s.mu.Lock()
+		if s.posted == nil {
+			s.posted = map[string]map[string]any{}
+		}
+		s.posted[entitySet] = body
+		s.mu.Unlock()
+		if body["CUSTNAME"] == "BLOCKED" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"FORM":{"InterfaceErrors":{"text":"Customer is inactive"}}}`))
+			return
+		}
+		body["ORDNAME"] = "SO2600123"
+		body["CPROFNUM"] = "PQ2600009"
+		body["DOCNO"] = "RT2600004"
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(body)
+		return
+	}
+
+	var source []map[string]any
+	subform := ""
+	switch entitySet {
+	case "":
+		_, _ = w.Write([]byte(`{"value":[]}`))
+		return
+	case "CUSTOMERS":
+		source = s.customers
+	case "PRICELIST":
+		source, subform = s.priceLists, "PARTPRICE2_SUBFORM"
+	case "LOGPART":
+		source = s.parts
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":{"code":"404","message":"unknown entity"}}`))
+		return
+	}
+
+	var out []map[string]any
+	for _, e := range source {
+		if !matches(q.Get("$filter"), e) {
+			continue
+		}
+		if subform != "" {
+			f := expandFilter(q.Get("$expand"), subform)
+			rows, _ := e[subform].([]map[string]any)
+			var kept []map[string]any
+			for _, row := range rows {
+				if matches(f, row) {
+					kept = append(kept, row)
+				}
+			}
+			cp := map[string]any{}
+			for k, v := range e {
+				cp[k] = v
+			}
+			cp[subform] = kept
+			e = cp
+		}
+		out = append(out, e)
+	}
+
+	resp := map[string]any{"value": out}
+	if s.pageSize > 0 && entitySet == "LOGPART" {
+		skip, _ := strconv.Atoi(q.Get("$skip"))
+		end := min(skip+s.pageSize, len(out))
+		resp["value"] = out[skip:end]
+		if end < len(out) {
+			next := *r.URL
+			nq := next.Query()
+			nq.Set("$skip", strconv.Itoa(end))
+			next.RawQuery = nq.Encode()
+			resp["@odata.nextLink"] = next.String()
+		}
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func newStandIn(t *testing.T) (*odataStandIn, *Client) {
+	t.Helper()
+	s := &odataStandIn{
+		t: t,
+		customers: []map[string]any{{
+			"CUSTNAME":             "C100",
+			"CUSTPLIST_SUBFORM":    []map[string]any{{"PLNAME": "Wholesale"}},
+			"CUSTPARTDISC_SUBFORM": []map[string]any{{"PARTNAME": "A-1", "PERCENT": 10.0}},
+		}},
+		priceLists: []map[string]any{
+			{"PLNAME": "Wholesale", "PARTPRICE2_SUBFORM": []map[string]any{
+				{"PARTNAME": "A-1", "PRICE": 90.0, "QUANT": 10.0, "CODE": "ILS"},
+				{"PARTNAME": "A-1", "PRICE": 100.0, "QUANT": 0.0, "CODE": "ILS"},
+			}},
+			{"PLNAME": "Retail", "PARTPRICE2_SUBFORM": []map[string]any{
+				{"PARTNAME": "A-1", "PRICE": 120.0, "QUANT": 0.0, "CODE": "ILS"},
+				{"PARTNAME": "B'2", "PRICE": 50.0, "QUANT": 0.0, "CODE": "USD"},
+			}},
+		},
+		parts: []map[string]any{
+			{"PARTNAME": "A-1", "PARTBAL_SUBFORM": []map[string]any{
+				{"WARHSNAME": "Main", "BALANCE": 5.0},
+				{"WARHSNAME": "Main", "BALANCE": 3.0},
+				{"WARHSNAME": "North", "BALANCE": 7.0},
+			}},
+			{"PARTNAME": "B'2", "PARTBAL_SUBFORM": []map[string]any{
+				{"WARHSNAME": "Main", "BALANCE": 1.0},
+			}},
+		},
+	}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+
+	c, err := NewClient(config.PriorityConfig{
+		ServiceURL: srv.URL + "/odata/Priority/tabula.ini/demo",
+		User:       "api",
+		AppID:      "APP01",
+	}, "secret")
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return s, c
+}
+
+func itemBySKU(t *testing.T, res erp.PriceStockResult, sku string) erp.PriceStockItem {
+	t.Helper()
+	for _, it := range res.Items {
+		if it.SKU == sku {
+			return it
+		}
+	}
+	t.Fatalf("sku %q missing from result", sku)
+	return erp.PriceStockItem{}
+}
+
+// TestFetchPriceAndStock_CustomerPriceList verifies the customer's assigned
+// price list, base tier selection, per-part discount and warehouse sums.
+func TestFetchPriceAndStock_CustomerPriceList(t *testing.T) {
+	s, c := newStandIn(t)
+
+	res, err := FetchPriceAndStock(context.Background(), c, erp.PriceStockRequest{
+		SKUList:   []string{"A-1", "B'2", "A-1"},
+		UserExtID: "C100",
+	})
+	if err != nil {
+		t.Fatalf("FetchPriceAndStock: %v", err)
+	}
+	if len(res.Items) != 2 {
+		t.Fatalf("items = %d, want 2 (deduplicated)", len(res.Items))
+	}
+
+	a := itemBySKU(t, res, "A-1")
+	if a.Prices["price"] != 100 || a.Prices["priceAfterDiscount"] != 90 {
+		t.Errorf("A-1 prices = %v, want base tier 100 less 10%%", a.Prices)
+	}
+	if a.Details["priceList"] != "Wholesale" || a.Details["discountPrc"] != 10.0 {
+		t.Errorf("A-1 details = %v", a.Details)
+	}
+	if a.StockByWarehouse["Main"] != 8 || a.StockByWarehouse["North"] != 7 {
+		t.Errorf("A-1 stock = %v, want Main=8 North=7", a.StockByWarehouse)
+	}
+
+	b := itemBySKU(t, res, "B'2")
+	if b.Prices != nil {
+		t.Errorf("B'2 is not on the customer's list; prices = %v", b.Prices)
+	}
+
+	for _, r := range s.requests {
+		if r.Header.Get("X-App-Id") != "APP01" {
+			t.Errorf("%s missing X-App-Id header", r.URL.Path)
+		}
+	}
+}
+
+// TestFetchPriceAndStock_ExplicitPriceLists verifies requested price lists
+// override the customer's and are tried in order, and that warehouses filter
+// balances.
+func TestFetchPriceAndStock_ExplicitPriceLists(t *testing.T) {
+	_, c := newStandIn(t)
+
+	res, err := FetchPriceAndStock(context.Background(), c, erp.PriceStockRequest{
+		SKUList:    []string{"A-1", "B'2"},
+		PriceList:  []string{"Wholesale", "Retail"},
+		Warehouses: []string{"North"},
+	})
+	if err != nil {
+		t.Fatalf("FetchPriceAndStock: %v", err)
+	}
+
+	a := itemBySKU(t, res, "A-1")
+	if a.Prices["price"] != 100 || a.Details["priceList"] != "Wholesale" {
+		t.Errorf("A-1 should come from the first list: %v %v", a.Prices, a.Details)
+	}
+	if lists, _ := a.Details["priceLists"].(map[string]float64); lists["Retail"] != 120 {
+		t.Errorf("A-1 priceLists = %v, want Retail=120 listed", a.Details["priceLists"])
+	}
+	if len(a.StockByWarehouse) != 1 || a.StockByWarehouse["North"] != 7 {
+		t.Errorf("A-1 stock = %v, want North only", a.StockByWarehouse)
+	}
+
+	b := itemBySKU(t, res, "B'2")
+	if b.Prices["price"] != 50 || b.Details["currency"] != "USD" {
+		t.Errorf("B'2 should fall through to Retail: %v %v", b.Prices, b.Details)
+	}
+	if b.StockByWarehouse != nil {
+		t.Errorf("B'2 has no North balance; stock = %v", b.StockByWarehouse)
+	}
+}
+
+// TestFetchPriceAndStock_Paging verifies @odata.nextLink is followed.
+func TestFetchPriceAndStock_Paging(t *testing.T) {
+	s, c := newStandIn(t)
+	s.pageSize = 1
+
+	res, err := FetchPriceAndStock(context.Background(), c, erp.PriceStockRequest{SKUList: []string{"A-1", "B'2"}})
+	if err != nil {
+		t.Fatalf("FetchPriceAndStock: %v", err)
+	}
+	if itemBySKU(t, res, "B'2").StockByWarehouse["Main"] != 1 {
+		t.Error("second page of LOGPART was not fetched")
+	}
+}
+
+// TestSubmitOrder_DeepInsert verifies the ORDERS body and the returned number.
+func TestSubmitOrder_DeepInsert(t *testing.T) {
+	s, c := newStandIn(t)
+
+	res, err := SubmitOrder(context.Background(), c, "Main", erp.OrderRequest{
+		DocumentType: "ORDER",
+		UserExtID:    "C100",
+		CreatedDate:  "2026-02-23",
+		DueDate:      "2026-03-01",
+		Comment:      "leave at gate",
+		Discount:     5,
+		HistoryID:    "HID-001",
+		Details: []erp.OrderLineItem{
+			{SKU: "A-1", Quantity: 2, OriginalPrice: 100, Discount: 10},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SubmitOrder: %v", err)
+	}
+	if res.Entity != "ORDERS" || res.DocumentNumber != "SO2600123" {
+		t.Errorf("result = %+v", res)
+	}
+
+	body := s.posted["ORDERS"]
+	if body["CUSTNAME"] != "C100" || body["BOOKNUM"] != "HID-001" || body["PERCENT"] != 5.0 {
+		t.Errorf("header = %v", body)
+	}
+	if body["CURDATE"] != "2026-02-23T00:00:00Z" {
+		t.Errorf("CURDATE = %v", body["CURDATE"])
+	}
+	if text, _ := body["ORDERSTEXT_SUBFORM"].(map[string]any); text["TEXT"] != "leave at gate" {
+		t.Errorf("comment = %v", body["ORDERSTEXT_SUBFORM"])
+	}
+	lines, _ := body["ORDERITEMS_SUBFORM"].([]any)
+	if len(lines) != 1 {
+		t.Fatalf("lines = %v", body["ORDERITEMS_SUBFORM"])
+	}
+	line := lines[0].(map[string]any)
+	if line["PARTNAME"] != "A-1" || line["TQUANT"] != 2.0 || line["PRICE"] != 100.0 ||
+		line["PERCENT"] != 10.0 || line["WARHSNAME"] != "Main" || line["DUEDATE"] != "2026-03-01T00:00:00Z" {
+		t.Errorf("line = %v", line)
+	}
+}
+
+// TestSubmitOrder_DocumentTypes verifies quotes and returns use their forms.
+func TestSubmitOrder_DocumentTypes(t *testing.T) {
+	s, c := newStandIn(t)
+	for docType, want := range map[string]string{"QUOATE": "PQ2600009", "RETURN": "RT2600004"} {
+		res, err := SubmitOrder(context.Background(), c, "", erp.OrderRequest{
+			DocumentType: docType,
+			UserExtID:    "C100",
+			Details:      []erp.OrderLineItem{{SKU: "A-1", Quantity: 1, OriginalPrice: 10}},
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", docType, err)
+		}
+		if res.DocumentNumber != want {
+			t.Errorf("%s: number = %q, want %q", docType, res.DocumentNumber, want)
+		}
+	}
+	if _, ok := s.posted["CPROF"]["CPROFITEMS_SUBFORM"]; !ok {
+		t.Error("quote lines not sent in CPROFITEMS_SUBFORM")
+	}
+	if _, ok := s.posted["DOCUMENTS_N"]["TRANSORDER_N_SUBFORM"]; !ok {
+		t.Error("return lines not sent in TRANSORDER_N_SUBFORM")
+	}
+}
+
+// TestSubmitOrder_InterfaceError verifies Priority form errors surface as
+// APIError with the form's message.
+func TestSubmitOrder_InterfaceError(t *testing.T) {
+	_, c := newStandIn(t)
+	_, err := SubmitOrder(context.Background(), c, "", erp.OrderRequest{
+		DocumentType: "ORDER",
+		UserExtID:    "BLOCKED",
+		Details:      []erp.OrderLineItem{{SKU: "A-1", Quantity: 1}},
+	})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want *APIError", err)
+	}
+	if apiErr.Status != http.StatusBadRequest || apiErr.Message != "Customer is inactive" {
+		t.Errorf("APIError = %+v", apiErr)
+	}
+}
+
+// TestOrderQueue_Done verifies the async queue records Priority's number.
+func TestOrderQueue_Done(t *testing.T) {
+	_, c := newStandIn(t)
+	q := NewOrderQueue(c, "", noopLogger{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q.Start(ctx)
+
+	id, err := q.Submit(erp.OrderRequest{
+		DocumentType: "ORDER",
+		UserExtID:    "C100",
+		Details:      []erp.OrderLineItem{{SKU: "A-1", Quantity: 1, OriginalPrice: 10}},
+	})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if r, _ := q.Status(id); r.Status == erp.JobStatusDone {
+			if r.DocumentNumber != "SO2600123" {
+				t.Errorf("document number = %q", r.DocumentNumber)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("job did not finish")
+}
+
+type recordingHook struct {
+	mu     sync.Mutex
+	result *erp.OrderResult
+}
+
+func (h *recordingHook) AfterOrder(_ context.Context, _ erp.OrderRequest, r *erp.OrderResult) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.result = r
+	return nil
+}
+
+// TestAdapter_RunsHooks verifies the registered adapter hands its post-order
+// hooks to the queue and they see Priority's document number.
+func TestAdapter_RunsHooks(t *testing.T) {
+	_, c := newStandIn(t)
+	hook := &recordingHook{}
+	built, err := newAdapter(erp.Deps{
+		Config: config.Config{ERP: config.ERPPriority, Priority: config.PriorityConfig{
+			ServiceURL: c.baseURL.String(),
+			User:       "api",
+			AppID:      "APP01",
+		}},
+		Password: "secret",
+		Logger:   noopLogger{},
+		Hooks:    []erp.PostOrderHook{hook},
+	})
+	if err != nil {
+		t.Fatalf("newAdapter: %v", err)
+	}
+	a := built.(*Adapter)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a.Start(ctx)
+
+	id, err := a.SubmitOrder(erp.OrderRequest{
+		DocumentType: "ORDER",
+		UserExtID:    "C100",
+		Details:      []erp.OrderLineItem{{SKU: "A-1", Quantity: 1, OriginalPrice: 10}},
+	})
+	if err != nil {
+		t.Fatalf("SubmitOrder: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if r, _ := a.OrderJob(id); r.Status == erp.JobStatusDone {
+			// Hooks run right after the job is marked done.
+			for time.Now().Before(deadline) {
+				hook.mu.Lock()
+				got := hook.result
+				hook.mu.Unlock()
+				if got != nil {
+					if got.DocumentNumber != "SO2600123" {
+						t.Errorf("hook result = %+v", got)
+					}
+					return
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			t.Fatal("hook did not run")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("job did not finish")
+}
+
+func TestNewClient_Validation(t *testing.T) {
+	for _, cfg := range []config.PriorityConfig{
+		{User: "api"},
+		{ServiceURL: "erp.example.com/odata", User: "api"},
+		{ServiceURL: "https://erp.example.com/odata/Priority/tabula.ini/demo"},
+	} {
+		if _, err := NewClient(cfg, "x"); err == nil {
+			t.Errorf("NewClient(%+v) accepted invalid config", cfg)
+		}
+	}
+}
+
+type noopLogger struct{}
+
+func (noopLogger) Info(string)         {}
+func (noopLogger) Error(string, error) {}
+func (noopLogger) Warn(string)         {}
+func (noopLogger) Success(string)      {}
+func (noopLogger) Close() error        { return nil }
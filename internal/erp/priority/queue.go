@@ -0,0 +1,16 @@
+package priority
+
+import (
+	"context"
+
+	"erp-connector/internal/erp"
+	"erp-connector/internal/logger"
+)
+
+// NewOrderQueue returns the shared async order queue bound to client.
+// Priority assigns the document number, so job IDs are opaque.
+func NewOrderQueue(client *Client, defaultWarehouse string, log logger.LoggerService, hooks ...erp.PostOrderHook) *erp.OrderQueue {
+	return erp.NewOrderQueue("priority", func(ctx context.Context, req erp.OrderRequest) (*erp.OrderResult, error) {
+		return SubmitOrder(ctx, client, defaultWarehouse, req)
+	}, log, hooks...)
+}
@@ -0,0 +1,129 @@
+package priority
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"erp-connector/internal/erp"
+)
+
+// documentForm maps a connector documentType to the Priority form (entity
+// set) that stores it, its line subform and the field holding the number
+// Priority assigns.
+type documentForm struct {
+	entity      string
+	items       string
+	text        string
+	numberField string
+}
+
+var documentForms = map[string]documentForm{
+	"ORDER":  {entity: "ORDERS", items: "ORDERITEMS_SUBFORM", text: "ORDERSTEXT_SUBFORM", numberField: "ORDNAME"},
+	"QUOATE": {entity: "CPROF", items: "CPROFITEMS_SUBFORM", text: "CPROFTEXT_SUBFORM", numberField: "CPROFNUM"},
+	"RETURN": {entity: "DOCUMENTS_N", items: "TRANSORDER_N_SUBFORM", text: "DOCUMENTSTEXT_SUBFORM", numberField: "DOCNO"},
+}
+
+// SubmitOrder creates the document in Priority with a single deep insert, so
+// header and lines are committed (or rejected) together. The result carries
+// the form and the number Priority assigned (ORDNAME / CPROFNUM / DOCNO).
+func SubmitOrder(ctx context.Context, client *Client, defaultWarehouse string, req erp.OrderRequest) (*erp.OrderResult, error) {
+	if client == nil {
+		return nil, errors.New("priority client is required")
+	}
+	form, ok := documentForms[req.DocumentType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported documentType %q", req.DocumentType)
+	}
+	if strings.TrimSpace(req.UserExtID) == "" {
+		return nil, errors.New("userExtId is required")
+	}
+	if len(req.Details) == 0 {
+		return nil, errors.New("order has no lines")
+	}
+
+	body, err := buildDocument(form, defaultWarehouse, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var created map[string]any
+	if err := client.post(ctx, form.entity, body, &created); err != nil {
+		return nil, fmt.Errorf("create %s: %w", form.entity, err)
+	}
+	num, _ := created[form.numberField].(string)
+	if num == "" {
+		return nil, fmt.Errorf("create %s: response has no %s", form.entity, form.numberField)
+	}
+	return &erp.OrderResult{Entity: form.entity, DocumentNumber: num}, nil
+}
+
+// buildDocument renders the deep-insert body for one order. Prices are sent
+// as list price plus line discount, matching what the Hasavshevet importer
+// receives, so Priority recomputes totals with its own rounding.
+func buildDocument(form documentForm, defaultWarehouse string, req erp.OrderRequest) (map[string]any, error) {
+	curDate, err := odataDate(req.CreatedDate)
+	if err != nil {
+		return nil, fmt.Errorf("createdDate: %w", err)
+	}
+	dueDate, err := odataDate(req.DueDate)
+	if err != nil {
+		return nil, fmt.Errorf("dueDate: %w", err)
+	}
+
+	lines := make([]map[string]any, 0, len(req.Details))
+	for _, d := range req.Details {
+		line := map[string]any{
+			"PARTNAME": d.SKU,
+			"TQUANT":   d.Quantity,
+			"PRICE":    d.OriginalPrice,
+			"PERCENT":  d.Discount,
+		}
+		if form.entity == "ORDERS" && dueDate != "" {
+			line["DUEDATE"] = dueDate
+		}
+		if defaultWarehouse != "" {
+			line["WARHSNAME"] = defaultWarehouse
+		}
+		lines = append(lines, line)
+	}
+
+	doc := map[string]any{
+		"CUSTNAME": req.UserExtID,
+		form.items: lines,
+	}
+	if curDate != "" {
+		doc["CURDATE"] = curDate
+	}
+	if req.HistoryID != "" {
+		// BOOKNUM is the customer's reference; it links the Priority document
+		// back to the connector order.
+		doc["BOOKNUM"] = req.HistoryID
+	}
+	if req.Discount != 0 {
+		doc["PERCENT"] = req.Discount
+	}
+	if c := strings.TrimSpace(req.Comment); c != "" {
+		doc[form.text] = map[string]any{"TEXT": c}
+	}
+	return doc, nil
+}
+
+// odataDate converts the API's date (YYYY-MM-DD or RFC 3339) to an
+// Edm.DateTimeOffset literal. Empty stays empty.
+func odataDate(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.Format(time.RFC3339), nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return "", fmt.Errorf("invalid date %q", s)
+	}
+	return t.Format("2006-01-02") + "T00:00:00Z", nil
+}
@@ -0,0 +1,288 @@
+package erp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+
+	"erp-connector/internal/logger"
+)
+
+const defaultQueueSize = 64
+
+// JobStatus is the lifecycle state of an enqueued order job. The values match
+// the Hasavshevet queue so API clients see the same states regardless of ERP.
+type JobStatus string
+
+const (
+	JobStatusQueued    JobStatus = "queued"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusDone      JobStatus = "done"
+	JobStatusFailed    JobStatus = "failed"
+	JobStatusCancelled JobStatus = "cancelled"
+	// JobStatusUnverified: the order was handed to the ERP but its document
+	// could not be checked. It must not be resubmitted.
+	JobStatusUnverified JobStatus = "unverified"
+)
+
+// JobResult holds the outcome of a processed order job. Errors breaks a
+// failure down per order line when the ERP reports which lines it rejected.
+type JobResult struct {
+	ID             string
+	Status         JobStatus
+	OrderNumber    int64
+	DocumentNumber string
+	Entity         string
+	Err            error
+	Errors         []JobError
+	VATRate        *float64
+	VATExempt      bool
+}
+
+// JobError is one problem the ERP reported for an order. Line is the 1-based
+// order line (0 for the document header) and Field the ERP field it names,
+// when known.
+type JobError struct {
+	Line       int
+	SKU        string
+	Field      string
+	FieldTitle string
+	Message    string
+}
+
+// String formats the error for logs and job status, e.g.
+// "line 3 (SKU-001): unknown SKU".
+func (e JobError) String() string {
+	var b strings.Builder
+	if e.Line > 0 {
+		fmt.Fprintf(&b, "line %d", e.Line)
+		if e.SKU != "" {
+			fmt.Fprintf(&b, " (%s)", e.SKU)
+		}
+		b.WriteString(": ")
+	}
+	if e.FieldTitle != "" {
+		b.WriteString(e.FieldTitle + ": ")
+	}
+	b.WriteString(e.Message)
+	return b.String()
+}
+
+// ProcessFunc submits one order to the ERP.
+type ProcessFunc func(ctx context.Context, req OrderRequest) (*OrderResult, error)
+
+// orderJob is a submitted order. req and cancelled are guarded by the
+// queue's mu until the worker claims the job.
+type orderJob struct {
+	id        string
+	req       OrderRequest
+	cancelled bool
+}
+
+// OrderQueue submits orders asynchronously on a single worker so sendOrder
+// answers 202 immediately. It is used by ERPs that assign the document number
+// themselves (over HTTP), so job IDs are opaque. Jobs can be cancelled or
+// edited until the worker takes them.
+type OrderQueue struct {
+	name      string
+	ch        chan *orderJob
+	process   ProcessFunc
+	log       logger.LoggerService
+	postHooks []PostOrderHook
+
+	mu      sync.RWMutex
+	jobs    map[string]*JobResult
+	queued  map[string]*orderJob
+	running *orderJob // claimed and not yet recorded as done or failed
+}
+
+// NewOrderQueue creates a queue; name prefixes its log lines. Optional
+// PostOrderHook instances are called after each successful order. Call Start
+// to begin processing.
+func NewOrderQueue(name string, process ProcessFunc, log logger.LoggerService, hooks ...PostOrderHook) *OrderQueue {
+	return &OrderQueue{
+		name:      name,
+		ch:        make(chan *orderJob, defaultQueueSize),
+		process:   process,
+		log:       log,
+		postHooks: hooks,
+		jobs:      make(map[string]*JobResult),
+		queued:    make(map[string]*orderJob),
+	}
+}
+
+// Start launches the background worker. It exits when ctx is cancelled.
+func (q *OrderQueue) Start(ctx context.Context) {
+	go q.run(ctx)
+}
+
+func (q *OrderQueue) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-q.ch:
+			req, ok := q.claim(job)
+			if !ok {
+				continue
+			}
+			result, err := q.process(ctx, req)
+			if err != nil {
+				q.log.Error(fmt.Sprintf("%s order job %s failed historyId=%s", q.name, job.id, req.HistoryID), err)
+				q.set(&JobResult{ID: job.id, Status: JobStatusFailed, Err: err})
+				continue
+			}
+			q.log.Success(fmt.Sprintf("%s order job %s done %s=%s historyId=%s",
+				q.name, job.id, result.Entity, result.DocumentNumber, req.HistoryID))
+			q.set(&JobResult{
+				ID:             job.id,
+				Status:         JobStatusDone,
+				OrderNumber:    result.OrderNumber,
+				DocumentNumber: result.DocumentNumber,
+				Entity:         result.Entity,
+				VATRate:        result.VATRate,
+				VATExempt:      result.VATExempt,
+			})
+
+			// Post-order hooks (PDF generation, printing, email).
+			// Errors are logged but never fail the order.
+			for _, hook := range q.postHooks {
+				if hookErr := hook.AfterOrder(ctx, req, result); hookErr != nil {
+					q.log.Warn(fmt.Sprintf("post-order hook failed for %s job %s: %v", q.name, job.id, hookErr))
+				}
+			}
+		}
+	}
+}
+
+// Submit enqueues an order and returns its job ID, or an error if the queue
+// is full.
+func (q *OrderQueue) Submit(req OrderRequest) (string, error) {
+	job := &orderJob{id: newJobID(), req: req}
+	id := job.id
+	q.mu.Lock()
+	q.jobs[id] = &JobResult{ID: id, Status: JobStatusQueued}
+	q.queued[id] = job
+	q.mu.Unlock()
+	select {
+	case q.ch <- job:
+		return id, nil
+	default:
+		q.mu.Lock()
+		delete(q.jobs, id)
+		delete(q.queued, id)
+		q.mu.Unlock()
+		return "", fmt.Errorf("order queue full (capacity %d)", defaultQueueSize)
+	}
+}
+
+// Cancel cancels a job that is still queued. It returns ErrNotFound for an
+// unknown job and ErrJobNotQueued once the worker has taken it.
+func (q *OrderQueue) Cancel(jobID string) (*JobResult, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, err := q.queuedJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+	job.cancelled = true
+	delete(q.queued, jobID)
+	r := &JobResult{ID: jobID, Status: JobStatusCancelled}
+	q.jobs[jobID] = r
+	q.log.Info(fmt.Sprintf("%s order job %s cancelled historyId=%s", q.name, jobID, job.req.HistoryID))
+	return r, nil
+}
+
+// Update replaces the order of a job that is still queued; the job keeps its
+// place in the queue. Errors are as for Cancel.
+func (q *OrderQueue) Update(jobID string, req OrderRequest) (*JobResult, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, err := q.queuedJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+	job.req = req
+	q.log.Info(fmt.Sprintf("%s order job %s updated historyId=%s", q.name, jobID, req.HistoryID))
+	return q.jobs[jobID], nil
+}
+
+// queuedJob returns the queued job jobID. The caller holds mu.
+func (q *OrderQueue) queuedJob(jobID string) (*orderJob, error) {
+	if job, ok := q.queued[jobID]; ok {
+		return job, nil
+	}
+	if r, ok := q.jobs[jobID]; ok {
+		return nil, fmt.Errorf("job %s is %s: %w", jobID, r.Status, ErrJobNotQueued)
+	}
+	return nil, fmt.Errorf("job %s: %w", jobID, ErrNotFound)
+}
+
+// claim marks job running and returns its current order, or false when it
+// was cancelled.
+func (q *OrderQueue) claim(job *orderJob) (OrderRequest, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if job.cancelled {
+		return OrderRequest{}, false
+	}
+	delete(q.queued, job.id)
+	q.running = job
+	q.jobs[job.id] = &JobResult{ID: job.id, Status: JobStatusRunning}
+	return job.req, true
+}
+
+// PendingTotal is the total of extID's orders that are queued or being
+// submitted, i.e. accepted but not yet created in the ERP.
+func (q *OrderQueue) PendingTotal(extID string) float64 {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	var total float64
+	for _, job := range q.queued {
+		if SameExtID(job.req.UserExtID, extID) {
+			total += job.req.Total
+		}
+	}
+	if q.running != nil && SameExtID(q.running.req.UserExtID, extID) {
+		total += q.running.req.Total
+	}
+	return total
+}
+
+// PendingJobTotal returns the total of job jobID while it is queued for
+// extID.
+func (q *OrderQueue) PendingJobTotal(jobID, extID string) (float64, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	job, ok := q.queued[jobID]
+	if !ok || !SameExtID(job.req.UserExtID, extID) {
+		return 0, false
+	}
+	return job.req.Total, true
+}
+
+// Status returns the current result for a job ID, or false if not found.
+func (q *OrderQueue) Status(jobID string) (*JobResult, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	r, ok := q.jobs[jobID]
+	return r, ok
+}
+
+func (q *OrderQueue) set(r *JobResult) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.jobs[r.ID] = r
+	if q.running != nil && q.running.id == r.ID {
+		q.running = nil
+	}
+}
+
+func newJobID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,95 @@
+package erp
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"erp-connector/internal/config"
+	"erp-connector/internal/db"
+	"erp-connector/internal/db/migrate"
+	"erp-connector/internal/logger"
+)
+
+// Deps are the runtime dependencies handed to an adapter factory. DB is only
+// set for registrations with UsesSQL.
+type Deps struct {
+	Config   config.Config // effective (per-company) config
+	DB       *db.Handle
+	Password string // ERP secret: SQL password or API password
+	// Secret reads another named secret of the company (e.g. a second API
+	// password), falling back to the connector-wide value. May be nil.
+	Secret func(key string) (string, error)
+	Logger logger.LoggerService
+	Hooks  []PostOrderHook
+}
+
+// Factory builds an adapter from its dependencies.
+type Factory func(deps Deps) (Adapter, error)
+
+// Registration describes one ERP backend.
+type Registration struct {
+	// UsesSQL makes the daemon open a SQL Server pool (db block) for the ERP.
+	UsesSQL bool
+	New     Factory
+	// Objects lists the SQL objects the connector installs in the ERP
+	// database (see package migrate); nil when it installs none.
+	Objects func() []migrate.Object
+}
+
+// DBObjects returns the SQL objects registered for t, or nil.
+func DBObjects(t config.ERPType) []migrate.Object {
+	r, ok := Lookup(t)
+	if !ok || r.Objects == nil {
+		return nil
+	}
+	return r.Objects()
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[config.ERPType]Registration{}
+)
+
+// Register makes an ERP backend available. ERP packages call it from init;
+// registering the same type twice panics.
+func Register(t config.ERPType, r Registration) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if r.New == nil {
+		panic("erp: Register with nil factory for " + string(t))
+	}
+	if _, dup := registry[t]; dup {
+		panic("erp: Register called twice for " + string(t))
+	}
+	registry[t] = r
+}
+
+// Lookup returns the registration for t.
+func Lookup(t config.ERPType) (Registration, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	r, ok := registry[t]
+	return r, ok
+}
+
+// New builds the adapter registered for t.
+func New(t config.ERPType, deps Deps) (Adapter, error) {
+	r, ok := Lookup(t)
+	if !ok {
+		return nil, fmt.Errorf("erp %q: %w", t, ErrNotSupported)
+	}
+	return r.New(deps)
+}
+
+// Registered returns the registered ERP types, sorted.
+func Registered() []config.ERPType {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	out := make([]config.ERPType, 0, len(registry))
+	for t := range registry {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
@@ -0,0 +1,51 @@
+package erp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"erp-connector/internal/config"
+)
+
+type fakeAdapter struct{ caps []Capability }
+
+func (f fakeAdapter) ERP() config.ERPType              { return "fake" }
+func (f fakeAdapter) Capabilities() []Capability       { return f.caps }
+func (f fakeAdapter) Health(ctx context.Context) error { return nil }
+func (f fakeAdapter) Start(ctx context.Context)        {}
+
+func TestRegistry_RegisterLookupNew(t *testing.T) {
+	const typ config.ERPType = "fake-registry-test"
+	Register(typ, Registration{New: func(Deps) (Adapter, error) {
+		return fakeAdapter{caps: []Capability{CapPriceStock}}, nil
+	}})
+
+	reg, ok := Lookup(typ)
+	if !ok || reg.UsesSQL {
+		t.Fatalf("Lookup = %+v, %v", reg, ok)
+	}
+	a, err := New(typ, Deps{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if !Supports(a, CapPriceStock) || Supports(a, CapSendOrder) {
+		t.Errorf("capabilities = %v", a.Capabilities())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("duplicate Register did not panic")
+		}
+	}()
+	Register(typ, reg)
+}
+
+func TestRegistry_UnknownERP(t *testing.T) {
+	if _, err := New("no-such-erp", Deps{}); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("New(unknown) error = %v, want ErrNotSupported", err)
+	}
+	if Supports(nil, CapSQL) {
+		t.Error("Supports(nil) = true")
+	}
+}
@@ -0,0 +1,213 @@
+package sap
+
+import (
+	"context"
+	"fmt"
+
+	"erp-connector/internal/config"
+	"erp-connector/internal/db"
+	"erp-connector/internal/erp"
+	"erp-connector/internal/logger"
+)
+
+// ServiceLayerPasswordKey is the secret holding the Service Layer password.
+const ServiceLayerPasswordKey = "sap_service_layer_password"
+
+func init() {
+	erp.Register(config.ERPSAP, erp.Registration{UsesSQL: true, New: newAdapter})
+}
+
+// Adapter exposes SAP Business One through the erp.Adapter capability
+// interfaces: price/stock are read from its SQL Server database, orders are
+// created through the Service Layer when it is configured.
+type Adapter struct {
+	db    *db.Handle
+	cfg   config.Config
+	queue *erp.OrderQueue
+	log   logger.LoggerService
+}
+
+func NewAdapter(dbHandle *db.Handle, cfg config.Config) *Adapter {
+	return &Adapter{db: dbHandle, cfg: cfg}
+}
+
+// newAdapter builds the order queue when sap.serviceLayerURL is set. An
+// invalid sap block is logged and leaves sendOrder unsupported so price/stock
+// keep working.
+func newAdapter(d erp.Deps) (erp.Adapter, error) {
+	a := NewAdapter(d.DB, d.Config)
+	a.log = d.Logger
+	if d.Config.SAP.ServiceLayerURL == "" {
+		return a, nil
+	}
+
+	password := ""
+	if d.Secret != nil {
+		p, err := d.Secret(ServiceLayerPasswordKey)
+		if err != nil && d.Logger != nil {
+			d.Logger.Error("failed to load sap service layer password", err)
+		}
+		password = p
+	}
+	client, err := NewServiceLayerClient(d.Config.SAP, d.Config.DB.Database, password)
+	if err != nil {
+		if d.Logger != nil {
+			d.Logger.Error("sap service layer configuration invalid; sendOrder disabled", err)
+		}
+		return a, nil
+	}
+	opts := OrderOptions{Drafts: d.Config.SAP.Drafts, DefaultWarehouse: d.Config.SAP.DefaultWarehouse}
+	a.queue = NewOrderQueue(client, opts, d.Logger, d.Hooks...)
+	return a, nil
+}
+
+func (a *Adapter) ERP() config.ERPType { return config.ERPSAP }
+
+func (a *Adapter) Capabilities() []erp.Capability {
+	caps := []erp.Capability{erp.CapPriceStock, erp.CapCustomerLookup, erp.CapItemLookup, erp.CapCustomerDocuments, erp.CapCreditCheck, erp.CapSQL}
+	if a.queue != nil {
+		caps = append(caps, erp.CapSendOrder)
+	}
+	return caps
+}
+
+func (a *Adapter) Health(ctx context.Context) error {
+	return a.db.Ping(ctx)
+}
+
+func (a *Adapter) Start(ctx context.Context) {
+	if a.queue == nil {
+		return
+	}
+	a.queue.Start(ctx)
+	if a.log != nil {
+		a.log.Info(fmt.Sprintf("sap order queue started (drafts=%v)", a.cfg.SAP.Drafts))
+	}
+}
+
+func (a *Adapter) FetchPriceAndStock(ctx context.Context, req erp.PriceStockRequest) (erp.PriceStockResult, error) {
+	dbConn := a.db.DB()
+	if dbConn == nil {
+		return erp.PriceStockResult{}, db.ErrUnavailable
+	}
+	return FetchPriceAndStock(ctx, dbConn, a.cfg, req)
+}
+
+// CustomerCredit counts the customer's queued sendOrder jobs next to SAP's
+// balance and open documents.
+func (a *Adapter) CustomerCredit(ctx context.Context, extID string) (erp.CreditStatus, error) {
+	dbConn := a.db.DB()
+	if dbConn == nil {
+		return erp.CreditStatus{}, db.ErrUnavailable
+	}
+	s, err := CustomerCredit(ctx, dbConn, extID)
+	if err != nil || a.queue == nil {
+		return s, err
+	}
+	s.PendingOrders = a.queue.PendingTotal(s.ExtID)
+	return s, nil
+}
+
+// PendingJobTotal returns the total of a queued sendOrder job of extID.
+func (a *Adapter) PendingJobTotal(jobID, extID string) (float64, bool) {
+	if a.queue == nil {
+		return 0, false
+	}
+	return a.queue.PendingJobTotal(jobID, extID)
+}
+
+func (a *Adapter) GetCustomer(ctx context.Context, extID string) (erp.Customer, error) {
+	dbConn := a.db.DB()
+	if dbConn == nil {
+		return erp.Customer{}, db.ErrUnavailable
+	}
+	return GetCustomer(ctx, dbConn, extID)
+}
+
+func (a *Adapter) SearchCustomers(ctx context.Context, q erp.CustomerSearch) (erp.CustomerPage, error) {
+	dbConn := a.db.DB()
+	if dbConn == nil {
+		return erp.CustomerPage{}, db.ErrUnavailable
+	}
+	return SearchCustomers(ctx, dbConn, q)
+}
+
+func (a *Adapter) GetItem(ctx context.Context, sku string) (erp.Item, error) {
+	dbConn := a.db.DB()
+	if dbConn == nil {
+		return erp.Item{}, db.ErrUnavailable
+	}
+	return GetItem(ctx, dbConn, sku)
+}
+
+func (a *Adapter) SearchItems(ctx context.Context, q erp.ItemSearch) (erp.ItemPage, error) {
+	dbConn := a.db.DB()
+	if dbConn == nil {
+		return erp.ItemPage{}, db.ErrUnavailable
+	}
+	return SearchItems(ctx, dbConn, q)
+}
+
+// CustomerDocuments links documents to connector orders only when the
+// Service Layer is configured, since its user marks orders the connector
+// created.
+func (a *Adapter) CustomerDocuments(ctx context.Context, q erp.DocumentSearch) (erp.DocumentPage, error) {
+	dbConn := a.db.DB()
+	if dbConn == nil {
+		return erp.DocumentPage{}, db.ErrUnavailable
+	}
+	slUser := ""
+	if a.cfg.SAP.ServiceLayerURL != "" {
+		slUser = a.cfg.SAP.User
+	}
+	return CustomerDocuments(ctx, dbConn, slUser, q)
+}
+
+// SubmitOrder enqueues the order for the Service Layer. Orders do not need
+// the SQL database, so they are accepted while it is unreachable.
+func (a *Adapter) SubmitOrder(req erp.OrderRequest) (string, error) {
+	if a.queue == nil {
+		return "", erp.ErrNotSupported
+	}
+	return a.queue.Submit(req)
+}
+
+// Queue returns the order submission queue, or nil when the Service Layer is
+// not configured.
+func (a *Adapter) Queue() *erp.OrderQueue { return a.queue }
+
+// OrderJob reports the state of a sendOrder job.
+func (a *Adapter) OrderJob(jobID string) (erp.JobResult, bool) {
+	if a.queue == nil {
+		return erp.JobResult{}, false
+	}
+	r, ok := a.queue.Status(jobID)
+	if !ok {
+		return erp.JobResult{}, false
+	}
+	return *r, true
+}
+
+// CancelOrder cancels a sendOrder job that is still queued.
+func (a *Adapter) CancelOrder(jobID string) (erp.JobResult, error) {
+	if a.queue == nil {
+		return erp.JobResult{}, erp.ErrNotSupported
+	}
+	r, err := a.queue.Cancel(jobID)
+	if err != nil {
+		return erp.JobResult{}, err
+	}
+	return *r, nil
+}
+
+// UpdateOrder replaces the order of a sendOrder job that is still queued.
+func (a *Adapter) UpdateOrder(jobID string, req erp.OrderRequest) (erp.JobResult, error) {
+	if a.queue == nil {
+		return erp.JobResult{}, erp.ErrNotSupported
+	}
+	r, err := a.queue.Update(jobID, req)
+	if err != nil {
+		return erp.JobResult{}, err
+	}
+	return *r, nil
+}
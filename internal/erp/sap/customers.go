@@ -0,0 +1,176 @@
+package sap
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"erp-connector/internal/db"
+	"erp-connector/internal/erp"
+)
+
+// customerColumns read a business partner with its price list, payment terms
+// and sales employee names. Address/City/ZipCode/Country are the bill-to
+// address and the Mail* columns the ship-to address, as OCRD keeps the
+// default of each.
+const customerColumns = `
+	C.CardCode, C.CardName, C.Address, C.City, C.ZipCode, C.Country,
+	C.MailAddres, C.MailCity, C.MailZipCod, C.MailCountr,
+	C.Phone1, C.Phone2, C.Cellular, C.E_Mail, C.LicTradNum,
+	S.SlpName, L.ListName, T.PymntGroup, C.Currency,
+	C.CreditLine, C.Balance, C.frozenFor`
+
+func queryCustomers(ctx context.Context, dbConn *sql.DB, where string, args []any, q erp.CustomerSearch) ([]erp.Customer, int, error) {
+	query := fmt.Sprintf(`
+		SELECT %s,
+			COUNT(*) OVER () AS TotalRows
+		FROM OCRD AS C WITH (NOLOCK)
+		LEFT JOIN OSLP AS S WITH (NOLOCK) ON S.SlpCode = C.SlpCode
+		LEFT JOIN OPLN AS L WITH (NOLOCK) ON L.ListNum = C.ListNum
+		LEFT JOIN OCTG AS T WITH (NOLOCK) ON T.GroupNum = C.GroupNum
+		WHERE C.CardType = 'C' AND %s
+		ORDER BY C.CardCode
+		OFFSET @offset ROWS FETCH NEXT @limit ROWS ONLY;`, customerColumns, where)
+	args = append(args, sql.Named("offset", q.Offset()), sql.Named("limit", q.PageSize))
+
+	rows, err := dbConn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	customers := []erp.Customer{}
+	total := 0
+	for rows.Next() {
+		var (
+			code                                      string
+			name, street, city, zip, country          sql.NullString
+			mStreet, mCity, mZip, mCountry            sql.NullString
+			phone1, phone2, cellular, email, taxID    sql.NullString
+			agent, priceList, terms, currency, frozen sql.NullString
+			creditLine, balance                       sql.NullFloat64
+		)
+		if err := rows.Scan(&code, &name, &street, &city, &zip, &country,
+			&mStreet, &mCity, &mZip, &mCountry,
+			&phone1, &phone2, &cellular, &email, &taxID,
+			&agent, &priceList, &terms, &currency,
+			&creditLine, &balance, &frozen, &total); err != nil {
+			return nil, 0, err
+		}
+		c := erp.Customer{
+			ExtID:        strings.TrimSpace(code),
+			Name:         strings.TrimSpace(name.String),
+			Phones:       erp.AppendNonEmpty(nil, phone1.String, phone2.String, cellular.String),
+			Email:        strings.TrimSpace(email.String),
+			TaxID:        strings.TrimSpace(taxID.String),
+			Agent:        strings.TrimSpace(agent.String),
+			PriceList:    strings.TrimSpace(priceList.String),
+			PaymentTerms: strings.TrimSpace(terms.String),
+			Currency:     strings.TrimSpace(currency.String),
+			Blocked:      frozen.String == "Y",
+		}
+		c.Addresses = appendAddress(c.Addresses, erp.AddressBilling, street, city, zip, country)
+		c.Addresses = appendAddress(c.Addresses, erp.AddressShipping, mStreet, mCity, mZip, mCountry)
+		if creditLine.Valid && creditLine.Float64 != 0 {
+			limit := creditLine.Float64
+			c.CreditLimit = &limit
+		}
+		if balance.Valid {
+			b := balance.Float64
+			c.Balance = &b
+		}
+		customers = append(customers, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return customers, total, nil
+}
+
+func appendAddress(dst []erp.CustomerAddress, typ string, street, city, zip, country sql.NullString) []erp.CustomerAddress {
+	a := erp.CustomerAddress{
+		Type:    typ,
+		Street:  strings.TrimSpace(street.String),
+		City:    strings.TrimSpace(city.String),
+		ZipCode: strings.TrimSpace(zip.String),
+		Country: strings.TrimSpace(country.String),
+	}
+	if a.Street == "" && a.City == "" && a.ZipCode == "" {
+		return dst
+	}
+	return append(dst, a)
+}
+
+// GetCustomer reads one customer (CardType C) by CardCode.
+func GetCustomer(ctx context.Context, dbConn *sql.DB, extID string) (erp.Customer, error) {
+	customers, _, err := queryCustomers(ctx, dbConn, "C.CardCode = @key",
+		[]any{sql.Named("key", strings.TrimSpace(extID))},
+		erp.CustomerSearch{Page: 1, PageSize: 1})
+	if err != nil {
+		return erp.Customer{}, err
+	}
+	if len(customers) == 0 {
+		return erp.Customer{}, erp.ErrNotFound
+	}
+	return customers[0], nil
+}
+
+// SearchCustomers pages through customers matching q.Query on the CardCode
+// prefix, name, foreign name or phone numbers.
+func SearchCustomers(ctx context.Context, dbConn *sql.DB, q erp.CustomerSearch) (erp.CustomerPage, error) {
+	where, args := customerSearchFilter(q.Query)
+	customers, total, err := queryCustomers(ctx, dbConn, where, args, q)
+	if err != nil {
+		return erp.CustomerPage{}, err
+	}
+	return erp.CustomerPage{Customers: customers, Page: q.Page, PageSize: q.PageSize, Total: total}, nil
+}
+
+func customerSearchFilter(text string) (string, []any) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "1 = 1", nil
+	}
+	like := db.EscapeLike(text)
+	return `(C.CardCode LIKE @prefix OR C.CardName LIKE @contains OR C.CardFName LIKE @contains
+			OR C.Phone1 LIKE @contains OR C.Phone2 LIKE @contains OR C.Cellular LIKE @contains)`,
+		[]any{sql.Named("prefix", like+"%"), sql.Named("contains", "%"+like+"%")}
+}
+
+// CustomerCredit reads the customer's credit line and the balances SAP keeps
+// on OCRD for its own credit check: the account balance, open delivery notes
+// (DNotesBal) and open orders (OrdersBal), all in local currency.
+func CustomerCredit(ctx context.Context, dbConn *sql.DB, extID string) (erp.CreditStatus, error) {
+	var (
+		code                                      string
+		creditLine, balance, dNotesBal, ordersBal sql.NullFloat64
+		frozen                                    sql.NullString
+	)
+	err := dbConn.QueryRowContext(ctx, `
+		SELECT C.CardCode, C.CreditLine, C.Balance, C.DNotesBal, C.OrdersBal, C.frozenFor
+		FROM OCRD AS C WITH (NOLOCK)
+		WHERE C.CardType = 'C' AND C.CardCode = @key;`,
+		sql.Named("key", strings.TrimSpace(extID)),
+	).Scan(&code, &creditLine, &balance, &dNotesBal, &ordersBal, &frozen)
+	if errors.Is(err, sql.ErrNoRows) {
+		return erp.CreditStatus{}, erp.ErrNotFound
+	}
+	if err != nil {
+		return erp.CreditStatus{}, err
+	}
+	s := erp.CreditStatus{
+		ExtID:          strings.TrimSpace(code),
+		OpenDeliveries: dNotesBal.Float64,
+		OpenOrders:     ordersBal.Float64,
+		Blocked:        frozen.String == "Y",
+	}
+	if creditLine.Valid && creditLine.Float64 != 0 {
+		limit := creditLine.Float64
+		s.CreditLimit = &limit
+	}
+	b := balance.Float64
+	s.Balance = &b
+	return s, nil
+}
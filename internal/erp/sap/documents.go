@@ -0,0 +1,265 @@
+package sap
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"erp-connector/internal/erp"
+)
+
+// docTable is one marketing document object: header and line tables.
+type docTable struct {
+	kind   string
+	header string
+	lines  string
+}
+
+var docTables = []docTable{
+	{kind: erp.DocOrder, header: "ORDR", lines: "RDR1"},
+	{kind: erp.DocDeliveryNote, header: "ODLN", lines: "DLN1"},
+	{kind: erp.DocInvoice, header: "OINV", lines: "INV1"},
+}
+
+const (
+	objSalesOrder   = 17
+	objDeliveryNote = 15
+)
+
+// connectorOrderExpr returns the DocNum of the sales order the document came
+// from, when that order was created by the Service Layer user (@slUser), i.e.
+// through /api/sendOrder. Invoices are followed through their delivery notes.
+func connectorOrderExpr(t docTable, linked bool) string {
+	if !linked {
+		return "CAST(NULL AS INT)"
+	}
+	fromOrder := func(lines, docEntry string) string {
+		return fmt.Sprintf(`(SELECT TOP 1 O.DocNum
+			FROM %s AS BL WITH (NOLOCK)
+			INNER JOIN ORDR AS O WITH (NOLOCK) ON O.DocEntry = BL.BaseEntry
+			INNER JOIN OUSR AS U WITH (NOLOCK) ON U.USERID = O.UserSign
+			WHERE BL.DocEntry = %s AND BL.BaseType = %d AND U.USER_CODE = @slUser)`,
+			lines, docEntry, objSalesOrder)
+	}
+	switch t.kind {
+	case erp.DocOrder:
+		return `(SELECT O.DocNum FROM ORDR AS O WITH (NOLOCK)
+			INNER JOIN OUSR AS U WITH (NOLOCK) ON U.USERID = O.UserSign
+			WHERE O.DocEntry = H.DocEntry AND U.USER_CODE = @slUser)`
+	case erp.DocInvoice:
+		viaDelivery := fmt.Sprintf(`(SELECT TOP 1 %s
+			FROM INV1 AS IL WITH (NOLOCK)
+			WHERE IL.DocEntry = H.DocEntry AND IL.BaseType = %d)`,
+			fromOrder("DLN1", "IL.BaseEntry"), objDeliveryNote)
+		return fmt.Sprintf("COALESCE(%s, %s)", fromOrder(t.lines, "H.DocEntry"), viaDelivery)
+	default:
+		return fromOrder(t.lines, "H.DocEntry")
+	}
+}
+
+// buildDocumentQuery selects one page of the customer's document headers
+// across the requested document tables, newest first. slUser is the Service
+// Layer user whose documents link back to connector orders; empty disables
+// the link.
+func buildDocumentQuery(q erp.DocumentSearch, slUser string) (string, []any) {
+	conds := []string{"H.CardCode = @key", "H.CANCELED <> 'C'"}
+	args := []any{sql.Named("key", strings.TrimSpace(q.ExtID))}
+	if q.OpenOnly {
+		conds = append(conds, "H.DocStatus = 'O'", "H.CANCELED = 'N'")
+	}
+	if !q.From.IsZero() {
+		conds = append(conds, "H.DocDate >= @from")
+		args = append(args, sql.Named("from", q.From))
+	}
+	if !q.To.IsZero() {
+		conds = append(conds, "H.DocDate < @to")
+		args = append(args, sql.Named("to", q.To.AddDate(0, 0, 1)))
+	}
+	linked := strings.TrimSpace(slUser) != ""
+	if linked {
+		args = append(args, sql.Named("slUser", strings.TrimSpace(slUser)))
+	}
+
+	var parts []string
+	for _, t := range docTables {
+		if !q.WantsKind(t.kind) {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf(`
+		SELECT '%s' AS Kind, H.DocEntry, H.DocNum, H.DocDate, H.DocDueDate,
+			H.DocStatus, H.CANCELED, H.DocCur,
+			CASE WHEN H.DocTotalFC <> 0 THEN H.DocTotalFC ELSE H.DocTotal END AS DocTotal,
+			CASE WHEN H.DocTotalFC <> 0 THEN H.VatSumFC ELSE H.VatSum END AS VatSum,
+			H.NumAtCard, %s AS ConnectorOrder
+		FROM %s AS H WITH (NOLOCK)
+		WHERE %s`, t.kind, connectorOrderExpr(t, linked), t.header, strings.Join(conds, " AND ")))
+	}
+	if len(parts) == 0 {
+		return "", nil
+	}
+
+	query := `
+WITH Docs AS (` + strings.Join(parts, `
+		UNION ALL`) + `
+)
+SELECT Kind, DocEntry, DocNum, DocDate, DocDueDate, DocStatus, CANCELED, DocCur,
+	DocTotal, VatSum, NumAtCard, ConnectorOrder,
+	COUNT(*) OVER () AS TotalRows
+FROM Docs
+ORDER BY DocDate DESC, DocEntry DESC
+OFFSET @offset ROWS FETCH NEXT @limit ROWS ONLY;`
+	args = append(args, sql.Named("offset", q.Offset()), sql.Named("limit", q.PageSize))
+	return query, args
+}
+
+// CustomerDocuments reads the customer's sales orders, delivery notes and
+// invoices with their lines. slUser is sap.user (see buildDocumentQuery).
+func CustomerDocuments(ctx context.Context, dbConn *sql.DB, slUser string, q erp.DocumentSearch) (erp.DocumentPage, error) {
+	page := erp.DocumentPage{Documents: []erp.Document{}, Page: q.Page, PageSize: q.PageSize}
+	query, args := buildDocumentQuery(q, slUser)
+	if query == "" {
+		return page, nil
+	}
+
+	rows, err := dbConn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return erp.DocumentPage{}, err
+	}
+	defer rows.Close()
+
+	entries := map[string][]int64{}
+	var docEntries []int64 // parallel to page.Documents
+	for rows.Next() {
+		var (
+			kind                       string
+			docEntry, docNum           int64
+			docDate, dueDate           sql.NullTime
+			status, canceled, currency sql.NullString
+			total, vat                 sql.NullFloat64
+			numAtCard                  sql.NullString
+			connectorOrder             sql.NullInt64
+		)
+		if err := rows.Scan(&kind, &docEntry, &docNum, &docDate, &dueDate, &status, &canceled, &currency,
+			&total, &vat, &numAtCard, &connectorOrder, &page.Total); err != nil {
+			return erp.DocumentPage{}, err
+		}
+		doc := erp.Document{
+			Kind:      kind,
+			Number:    strconv.FormatInt(docNum, 10),
+			Status:    docStatus(status.String, canceled.String),
+			Currency:  strings.TrimSpace(currency.String),
+			Reference: strings.TrimSpace(numAtCard.String),
+			Lines:     []erp.DocumentLine{},
+			Details:   map[string]any{"docEntry": docEntry},
+		}
+		if docDate.Valid {
+			t := docDate.Time
+			doc.Date = &t
+		}
+		if dueDate.Valid {
+			t := dueDate.Time
+			doc.DueDate = &t
+		}
+		if total.Valid {
+			v := total.Float64
+			doc.Total = &v
+		}
+		if vat.Valid {
+			v := vat.Float64
+			doc.VAT = &v
+		}
+		if connectorOrder.Valid {
+			doc.OrderNumber = strconv.FormatInt(connectorOrder.Int64, 10)
+		}
+		page.Documents = append(page.Documents, doc)
+		entries[kind] = append(entries[kind], docEntry)
+		docEntries = append(docEntries, docEntry)
+	}
+	if err := rows.Err(); err != nil {
+		return erp.DocumentPage{}, err
+	}
+
+	for _, t := range docTables {
+		if len(entries[t.kind]) == 0 {
+			continue
+		}
+		lines, err := fetchDocumentLines(ctx, dbConn, t.lines, entries[t.kind])
+		if err != nil {
+			return erp.DocumentPage{}, err
+		}
+		for i := range page.Documents {
+			doc := &page.Documents[i]
+			if doc.Kind != t.kind {
+				continue
+			}
+			for _, l := range lines[docEntries[i]] {
+				doc.Lines = append(doc.Lines, l)
+				doc.NetTotal += l.Total
+			}
+		}
+	}
+	return page, nil
+}
+
+func docStatus(status, canceled string) string {
+	switch {
+	case canceled == "Y":
+		return erp.DocStatusCancelled
+	case status == "O":
+		return erp.DocStatusOpen
+	default:
+		return erp.DocStatusClosed
+	}
+}
+
+func fetchDocumentLines(ctx context.Context, dbConn *sql.DB, table string, docEntries []int64) (map[int64][]erp.DocumentLine, error) {
+	names := make([]string, 0, len(docEntries))
+	args := make([]any, 0, len(docEntries))
+	for i, e := range docEntries {
+		name := fmt.Sprintf("e%d", i)
+		names = append(names, "@"+name)
+		args = append(args, sql.Named(name, e))
+	}
+	query := fmt.Sprintf(`
+		SELECT L.DocEntry, L.LineNum, L.ItemCode, L.Dscription, L.Quantity, L.OpenQty,
+			L.PriceBefDi, L.DiscPrcnt,
+			CASE WHEN L.TotalFrgn <> 0 THEN L.TotalFrgn ELSE L.LineTotal END AS LineTotal,
+			L.LineStatus
+		FROM %s AS L WITH (NOLOCK)
+		WHERE L.DocEntry IN (%s)
+		ORDER BY L.DocEntry, L.LineNum;`, table, strings.Join(names, ", "))
+
+	rows, err := dbConn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[int64][]erp.DocumentLine, len(docEntries))
+	for rows.Next() {
+		var (
+			docEntry                  int64
+			lineNum                   int
+			itemCode, descr, status   sql.NullString
+			qty, openQty, price, disc sql.NullFloat64
+			total                     sql.NullFloat64
+		)
+		if err := rows.Scan(&docEntry, &lineNum, &itemCode, &descr, &qty, &openQty, &price, &disc, &total, &status); err != nil {
+			return nil, err
+		}
+		out[docEntry] = append(out[docEntry], erp.DocumentLine{
+			LineNum:      lineNum,
+			SKU:          strings.TrimSpace(itemCode.String),
+			Name:         strings.TrimSpace(descr.String),
+			Quantity:     qty.Float64,
+			OpenQuantity: openQty.Float64,
+			Price:        price.Float64,
+			DiscountPct:  disc.Float64,
+			Total:        total.Float64,
+			Status:       docStatus(status.String, ""),
+		})
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,53 @@
+package sap
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"erp-connector/internal/erp"
+)
+
+func TestBuildDocumentQuery(t *testing.T) {
+	query, args := buildDocumentQuery(erp.DocumentSearch{
+		ExtID: "C001", Kinds: []string{erp.DocOrder, erp.DocInvoice}, OpenOnly: true,
+		To: time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC), PageSize: 20, Page: 2,
+	}, "connector")
+
+	for _, frag := range []string{"FROM ORDR AS H", "FROM OINV AS H", "H.DocStatus = 'O'", "H.DocDate < @to", "U.USER_CODE = @slUser", "INV1 AS IL"} {
+		if !strings.Contains(query, frag) {
+			t.Errorf("query lacks %q", frag)
+		}
+	}
+	if strings.Contains(query, "FROM ODLN AS H") {
+		t.Error("delivery notes queried although not requested")
+	}
+	got := namedArgs(args)
+	if got["key"] != "C001" || got["slUser"] != "connector" || got["offset"] != 20 || got["limit"] != 20 {
+		t.Errorf("args = %v", got)
+	}
+	if to := got["to"].(time.Time); !to.Equal(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("to = %v, want the day after (inclusive date)", to)
+	}
+
+	query, args = buildDocumentQuery(erp.DocumentSearch{ExtID: "C001", PageSize: 10}, "")
+	if strings.Contains(query, "@slUser") || namedArgs(args)["slUser"] != nil {
+		t.Error("connector order link without a Service Layer user")
+	}
+	if strings.Count(query, "UNION ALL") != 2 {
+		t.Errorf("want all three document tables:\n%s", query)
+	}
+}
+
+func TestDocStatus(t *testing.T) {
+	cases := []struct{ status, canceled, want string }{
+		{"O", "N", erp.DocStatusOpen},
+		{"C", "N", erp.DocStatusClosed},
+		{"C", "Y", erp.DocStatusCancelled},
+	}
+	for _, c := range cases {
+		if got := docStatus(c.status, c.canceled); got != c.want {
+			t.Errorf("docStatus(%q, %q) = %q, want %q", c.status, c.canceled, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package sap
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"erp-connector/internal/db"
+	"erp-connector/internal/erp"
+)
+
+// itemModifiedAt combines OITM.UpdateDate with UpdateTS (HHMMSS as an int)
+// into the item's last change time.
+const itemModifiedAt = `DATEADD(SECOND,
+		(ISNULL(I.UpdateTS, 0) / 10000) * 3600 + (ISNULL(I.UpdateTS, 0) / 100 % 100) * 60 + ISNULL(I.UpdateTS, 0) % 100,
+		CAST(I.UpdateDate AS DATETIME))`
+
+// itemColumns read an item with its group and manufacturer names. Barcodes
+// are the main CodeBars plus every OBCD entry.
+const itemColumns = `
+	I.ItemCode, I.ItemName, I.CodeBars,
+	(SELECT STRING_AGG(B.BcdCode, '|') FROM OBCD AS B WITH (NOLOCK) WHERE B.ItemCode = I.ItemCode) AS Barcodes,
+	G.ItmsGrpNam, M.FirmName, I.SalUnitMsr, I.InvntryUom, I.NumInSale,
+	I.VatGourpSa, I.frozenFor, ` + itemModifiedAt + ` AS ModifiedAt`
+
+func queryItems(ctx context.Context, dbConn *sql.DB, where string, args []any, pageSize, offset int) ([]erp.Item, int, error) {
+	query := fmt.Sprintf(`
+		SELECT %s,
+			COUNT(*) OVER () AS TotalRows
+		FROM OITM AS I WITH (NOLOCK)
+		LEFT JOIN OITB AS G WITH (NOLOCK) ON G.ItmsGrpCod = I.ItmsGrpCod
+		LEFT JOIN OMRC AS M WITH (NOLOCK) ON M.FirmCode = I.FirmCode
+		WHERE %s
+		ORDER BY I.ItemCode
+		OFFSET @offset ROWS FETCH NEXT @limit ROWS ONLY;`, itemColumns, where)
+	args = append(args, sql.Named("offset", offset), sql.Named("limit", pageSize))
+
+	rows, err := dbConn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	items := []erp.Item{}
+	total := 0
+	for rows.Next() {
+		var (
+			code                            string
+			name, codeBars, barcodes        sql.NullString
+			group, firm, salesUnit, invUnit sql.NullString
+			vatGroup, frozen                sql.NullString
+			numInSale                       sql.NullFloat64
+			modifiedAt                      sql.NullTime
+		)
+		if err := rows.Scan(&code, &name, &codeBars, &barcodes,
+			&group, &firm, &salesUnit, &invUnit, &numInSale,
+			&vatGroup, &frozen, &modifiedAt, &total); err != nil {
+			return nil, 0, err
+		}
+		it := erp.Item{
+			SKU:           strings.TrimSpace(code),
+			Name:          strings.TrimSpace(name.String),
+			Barcodes:      itemBarcodes(codeBars.String, barcodes.String),
+			Group:         strings.TrimSpace(group.String),
+			Manufacturer:  strings.TrimSpace(firm.String),
+			SalesUnit:     strings.TrimSpace(salesUnit.String),
+			InventoryUnit: strings.TrimSpace(invUnit.String),
+			PackSize:      1,
+			VATClass:      strings.TrimSpace(vatGroup.String),
+			Active:        frozen.String != "Y",
+		}
+		if numInSale.Valid && numInSale.Float64 > 0 {
+			it.PackSize = numInSale.Float64
+		}
+		if modifiedAt.Valid {
+			t := modifiedAt.Time
+			it.ModifiedAt = &t
+		}
+		items = append(items, it)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+// itemBarcodes merges the main barcode with the '|'-joined OBCD list,
+// dropping blanks and duplicates.
+func itemBarcodes(main, others string) []string {
+	out := erp.AppendNonEmpty(nil, main)
+	for _, b := range strings.Split(others, "|") {
+		b = strings.TrimSpace(b)
+		if b == "" || slices.Contains(out, b) {
+			continue
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+// GetItem reads one item by ItemCode.
+func GetItem(ctx context.Context, dbConn *sql.DB, sku string) (erp.Item, error) {
+	items, _, err := queryItems(ctx, dbConn, "I.ItemCode = @sku",
+		[]any{sql.Named("sku", strings.TrimSpace(sku))}, 1, 0)
+	if err != nil {
+		return erp.Item{}, err
+	}
+	if len(items) == 0 {
+		return erp.Item{}, erp.ErrNotFound
+	}
+	return items[0], nil
+}
+
+// SearchItems pages through OITM with the filters of q.
+func SearchItems(ctx context.Context, dbConn *sql.DB, q erp.ItemSearch) (erp.ItemPage, error) {
+	where, args := itemSearchFilter(q)
+	items, total, err := queryItems(ctx, dbConn, where, args, q.PageSize, q.Offset())
+	if err != nil {
+		return erp.ItemPage{}, err
+	}
+	return erp.ItemPage{Items: items, Page: q.Page, PageSize: q.PageSize, Total: total}, nil
+}
+
+func itemSearchFilter(q erp.ItemSearch) (string, []any) {
+	conds := []string{"1 = 1"}
+	var args []any
+	if text := strings.TrimSpace(q.Query); text != "" {
+		like := db.EscapeLike(text)
+		conds = append(conds, "(I.ItemCode LIKE @prefix OR I.ItemName LIKE @contains OR I.FrgnName LIKE @contains)")
+		args = append(args, sql.Named("prefix", like+"%"), sql.Named("contains", "%"+like+"%"))
+	}
+	if bc := strings.TrimSpace(q.Barcode); bc != "" {
+		conds = append(conds, "(I.CodeBars = @barcode OR EXISTS (SELECT 1 FROM OBCD AS B WITH (NOLOCK) WHERE B.ItemCode = I.ItemCode AND B.BcdCode = @barcode))")
+		args = append(args, sql.Named("barcode", bc))
+	}
+	if g := strings.TrimSpace(q.Group); g != "" {
+		conds = append(conds, "(CONVERT(NVARCHAR(20), I.ItmsGrpCod) = @group OR G.ItmsGrpNam = @group)")
+		args = append(args, sql.Named("group", g))
+	}
+	if m := strings.TrimSpace(q.Manufacturer); m != "" {
+		conds = append(conds, "(CONVERT(NVARCHAR(20), I.FirmCode) = @manufacturer OR M.FirmName = @manufacturer)")
+		args = append(args, sql.Named("manufacturer", m))
+	}
+	if !q.ModifiedSince.IsZero() {
+		// UpdateDate narrows the scan before the exact time comparison. SAP
+		// stores server-local times, so the filter is compared in local time.
+		conds = append(conds, "I.UpdateDate >= CAST(@since AS DATE) AND "+itemModifiedAt+" >= @since")
+		args = append(args, sql.Named("since", q.ModifiedSince.In(time.Local)))
+	}
+	return strings.Join(conds, " AND "), args
+}
@@ -0,0 +1,41 @@
+package sap
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"erp-connector/internal/erp"
+)
+
+func TestItemSearchFilter(t *testing.T) {
+	where, args := itemSearchFilter(erp.ItemSearch{})
+	if where != "1 = 1" || args != nil {
+		t.Errorf("no filters: %q %v", where, args)
+	}
+
+	where, args = itemSearchFilter(erp.ItemSearch{
+		Query: "50%", Barcode: "729", Group: "Tools", Manufacturer: "3",
+		ModifiedSince: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+	})
+	for _, frag := range []string{"I.ItemCode LIKE @prefix", "B.BcdCode = @barcode", "G.ItmsGrpNam = @group", "M.FirmName = @manufacturer", "I.UpdateDate >= CAST(@since AS DATE)"} {
+		if !strings.Contains(where, frag) {
+			t.Errorf("where lacks %q: %s", frag, where)
+		}
+	}
+	got := namedArgs(args)
+	if got["prefix"] != "50[%]%" || got["contains"] != "%50[%]%" || got["barcode"] != "729" || got["group"] != "Tools" || got["manufacturer"] != "3" {
+		t.Errorf("args = %v", got)
+	}
+}
+
+func TestItemBarcodes(t *testing.T) {
+	got := itemBarcodes("111", "222| 111 ||333")
+	if !slices.Equal(got, []string{"111", "222", "333"}) {
+		t.Errorf("barcodes = %v", got)
+	}
+	if got := itemBarcodes("", ""); len(got) != 0 {
+		t.Errorf("empty barcodes = %v", got)
+	}
+}
@@ -34,23 +34,23 @@ func FetchPriceAndStock(ctx context.Context, dbConn *sql.DB, cfg config.Config,
 		return erp.PriceStockResult{}, errors.New("userExtId is required")
 	}
 
-	warehouse := firstNonEmpty(req.Warehouses)
-	if warehouse == "" {
-		return erp.PriceStockResult{}, errors.New("warehouse is required")
-	}
+	warehouses := uniqueStrings(req.Warehouses)
 
 	asOfDate := strings.TrimSpace(req.Date)
 	if asOfDate == "" {
 		asOfDate = time.Now().Format("2006-01-02")
 	}
 
-	skuUnion, skuArgs := buildSkuUnion(skus)
+	skuUnion, skuArgs := buildSkuUnion(skus, req.Quantities)
+	listSelect, listArgs := buildPriceListSelect(req.PriceList)
+	whsFilter, whsArgs := buildWarehouseFilter(warehouses)
 	args := []any{
 		sql.Named("cardCode", cardCode),
 		sql.Named("asOfDate", asOfDate),
-		sql.Named("warehouse", warehouse),
 	}
 	args = append(args, skuArgs...)
+	args = append(args, listArgs...)
+	args = append(args, whsArgs...)
 
 	query := fmt.Sprintf(`
 WITH SkuList AS (
@@ -61,38 +61,106 @@ Cust AS (
     FROM OCRD AS T5 WITH (NOLOCK)
     WHERE T5.CardCode = @cardCode
 ),
+RequestedLists AS (
+        %s
+),
 CustGroup AS (
     SELECT TOP 1 GroupCode
     FROM OCRD WITH (NOLOCK)
     WHERE CardCode = @cardCode
 ),
-BasePrice AS (
+BasePriceRows AS (
     SELECT
         OITM.ItemCode,
         OITM.FirmCode,
-        ITM1.Price    AS PriceListPrice,
-        ITM1.Currency AS Currency
+        ITM1.PriceList AS ListNum,
+        ITM1.Price     AS PriceListPrice,
+        ITM1.Currency  AS Currency,
+        SL.qty         AS Quantity,
+        ROW_NUMBER() OVER (
+            PARTITION BY OITM.ItemCode
+            ORDER BY CASE WHEN ITM1.Price > 0 THEN 0 ELSE 1 END, RL.Pref
+        ) AS rn
     FROM OITM WITH (NOLOCK)
+    INNER JOIN SkuList AS SL
+        ON SL.sku = OITM.ItemCode
     INNER JOIN ITM1 WITH (NOLOCK)
         ON OITM.ItemCode = ITM1.ItemCode
-    CROSS JOIN Cust
-    WHERE ITM1.PriceList = Cust.ListNum
-      AND OITM.ItemCode IN (SELECT sku FROM SkuList)
+    INNER JOIN RequestedLists AS RL
+        ON RL.ListNum = ITM1.PriceList
 ),
-SpecialPrice AS (
+BasePrice AS (
+    SELECT ItemCode, FirmCode, ListNum, PriceListPrice, Currency, Quantity
+    FROM BasePriceRows
+    WHERE rn = 1
+),
+SpecialRules AS (
     SELECT
-        P.ItemCode,
-        P.ListNum,
-        P.Price    AS OSPPPrice,
-        P.Discount AS OSPPDiscount
-    FROM OSPP AS P WITH (NOLOCK)
-    WHERE P.CardCode = @cardCode
-      AND P.Valid = 'Y'
+        BP.ItemCode,
+        CASE WHEN P.CardCode = @cardCode THEN 1 ELSE 2 END AS Scope,
+        CASE
+            WHEN Vol.Amount IS NOT NULL THEN 'V'
+            WHEN Per.LINENUM IS NOT NULL THEN 'P'
+            ELSE 'S'
+        END AS RuleKind,
+        CASE
+            WHEN Vol.Amount IS NOT NULL THEN Vol.Price
+            WHEN Per.LINENUM IS NOT NULL THEN Per.Price
+            ELSE P.Price
+        END AS RulePrice,
+        CASE
+            WHEN Vol.Amount IS NOT NULL THEN Vol.Discount
+            WHEN Per.LINENUM IS NOT NULL THEN Per.Discount
+            ELSE P.Discount
+        END AS RuleDiscount,
+        Per.FromDate AS PeriodFrom,
+        Per.ToDate   AS PeriodTo,
+        Vol.Amount   AS VolumeQty
+    FROM BasePrice AS BP
+    CROSS JOIN Cust
+    INNER JOIN OSPP AS P WITH (NOLOCK)
+        ON P.ItemCode = BP.ItemCode
+       AND (
+             (P.CardCode = @cardCode AND (P.ListNum IS NULL OR P.ListNum = Cust.ListNum))
+          OR P.CardCode = N'*' + CONVERT(NVARCHAR(10), BP.ListNum)
+           )
+    OUTER APPLY (
+        SELECT TOP 1 S1.LINENUM, S1.Price, S1.Discount, S1.FromDate, S1.ToDate
+        FROM SPP1 AS S1 WITH (NOLOCK)
+        WHERE S1.ItemCode = P.ItemCode
+          AND S1.CardCode = P.CardCode
+          AND (S1.FromDate IS NULL OR S1.FromDate <= @asOfDate)
+          AND (S1.ToDate   IS NULL OR S1.ToDate   >= @asOfDate)
+        ORDER BY S1.FromDate DESC, S1.LINENUM
+    ) AS Per
+    OUTER APPLY (
+        SELECT TOP 1 S2.Amount, S2.Price, S2.Discount
+        FROM SPP2 AS S2 WITH (NOLOCK)
+        WHERE S2.ItemCode = P.ItemCode
+          AND S2.CardCode = P.CardCode
+          AND S2.SPP1LNum = Per.LINENUM
+          AND S2.Amount  <= BP.Quantity
+        ORDER BY S2.Amount DESC
+    ) AS Vol
+    WHERE P.Valid = 'Y'
       AND (
             (P.ValidFrom IS NULL OR P.ValidFrom <= @asOfDate)
         AND (P.ValidTo   IS NULL OR P.ValidTo   >= @asOfDate)
       )
 ),
+SpecialPrice AS (
+    SELECT
+        R.ItemCode,
+        R.Scope,
+        R.RuleKind,
+        R.RulePrice    AS OSPPPrice,
+        R.RuleDiscount AS OSPPDiscount,
+        R.PeriodFrom,
+        R.PeriodTo,
+        R.VolumeQty,
+        ROW_NUMBER() OVER (PARTITION BY R.ItemCode ORDER BY R.Scope) AS rn
+    FROM SpecialRules AS R
+),
 AllDiscountRules AS (
     SELECT
         BP.ItemCode,
@@ -250,8 +318,7 @@ StockRaw AS (
         W.OnOrder,
         W.IsCommited
     FROM OITW AS W WITH (NOLOCK)
-    WHERE W.WhsCode = @warehouse
-      AND W.ItemCode IN (SELECT ItemCodeToCheck FROM AllItemsForStock)
+    WHERE W.ItemCode IN (SELECT ItemCodeToCheck FROM AllItemsForStock)%s
 ),
 StockPerParentRows AS (
     SELECT
@@ -262,13 +329,11 @@ StockPerParentRows AS (
         S.OnOrder,
         S.IsCommited,
         ROW_NUMBER() OVER (
-            PARTITION BY A.ParentCode
-            ORDER BY
-                CASE WHEN S.OnHand IS NULL THEN 1 ELSE 0 END,
-                S.OnHand ASC
+            PARTITION BY A.ParentCode, S.WhsCode
+            ORDER BY S.OnHand - S.IsCommited + S.OnOrder ASC, S.OnHand ASC
         ) AS rn
     FROM AllItemsForStock AS A
-    LEFT JOIN StockRaw AS S
+    INNER JOIN StockRaw AS S
       ON S.ItemCode = A.ItemCodeToCheck
 ),
 Stock AS (
@@ -277,14 +342,16 @@ Stock AS (
         SPR.WhsCode    AS warehouseCode,
         SPR.OnHand     AS stock,
         SPR.OnOrder    AS onOrder,
-        SPR.IsCommited AS commited
+        SPR.IsCommited AS commited,
+        SPR.OnHand - SPR.IsCommited + SPR.OnOrder AS available
     FROM StockPerParentRows AS SPR
     WHERE SPR.rn = 1
 )
 SELECT
     BP.ItemCode                                                      AS sku,
     @cardCode                                                        AS CardCode,
-    CAST((SELECT ListNum FROM Cust) AS DECIMAL(19,4))                AS PriceList,
+    CAST(BP.ListNum AS DECIMAL(19,4))                                AS PriceList,
+    CAST(BP.Quantity AS DECIMAL(19,4))                               AS Quantity,
     BP.Currency,
     CAST(BP.PriceListPrice AS DECIMAL(19,4))                         AS PriceListPrice,
     CAST(SP.OSPPPrice AS DECIMAL(19,4))                              AS OSPPPrice,
@@ -308,6 +375,12 @@ SELECT
     CAST(S.stock AS DECIMAL(19,4))                                   AS stock,
     CAST(S.onOrder AS DECIMAL(19,4))                                 AS onOrder,
     CAST(S.commited AS DECIMAL(19,4))                                AS commited,
+    CAST(S.available AS DECIMAL(19,4))                               AS available,
+    CAST(SP.Scope AS INT)                                            AS SpecialScope,
+    CAST(SP.RuleKind AS NVARCHAR(1))                                 AS SpecialKind,
+    SP.PeriodFrom,
+    SP.PeriodTo,
+    CAST(SP.VolumeQty AS DECIMAL(19,4))                              AS VolumeQty,
     CASE
         WHEN SP.OSPPPrice IS NOT NULL AND SP.OSPPPrice > 0 THEN N'OSPP explicit price'
         WHEN SP.OSPPDiscount IS NOT NULL THEN N'OSPP discount'
@@ -340,7 +413,7 @@ SELECT
 FROM BasePrice AS BP
 LEFT JOIN SpecialPrice AS SP
        ON SP.ItemCode = BP.ItemCode
-      AND (SP.ListNum IS NULL OR SP.ListNum = (SELECT ListNum FROM Cust))
+      AND SP.rn = 1
 LEFT JOIN BestDiscountPerItem AS BD
        ON BD.ItemCode = BP.ItemCode
 LEFT JOIN DiscountRuleType AS DRT
@@ -350,9 +423,9 @@ LEFT JOIN PromoDiscount AS PD
        ON PD.ItemCode = BP.ItemCode
 LEFT JOIN Stock AS S
        ON S.ItemCode = BP.ItemCode
-ORDER BY BP.ItemCode
+ORDER BY BP.ItemCode, S.warehouseCode
 OPTION (RECOMPILE);
-`, skuUnion)
+`, skuUnion, listSelect, whsFilter)
 
 	rows, err := dbConn.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -366,6 +439,7 @@ OPTION (RECOMPILE);
 			sku                  string
 			cardCodeVal          sql.NullString
 			priceList            sql.NullFloat64
+			quantity             sql.NullFloat64
 			currency             sql.NullString
 			priceListPrice       sql.NullFloat64
 			osppPrice            sql.NullFloat64
@@ -380,6 +454,12 @@ OPTION (RECOMPILE);
 			stock                sql.NullFloat64
 			onOrder              sql.NullFloat64
 			commited             sql.NullFloat64
+			available            sql.NullFloat64
+			specialScope         sql.NullInt64
+			specialKind          sql.NullString
+			periodFrom           sql.NullTime
+			periodTo             sql.NullTime
+			volumeQty            sql.NullFloat64
 			priceSource          sql.NullString
 			finalPrice           sql.NullFloat64
 		)
@@ -388,6 +468,7 @@ OPTION (RECOMPILE);
 			&sku,
 			&cardCodeVal,
 			&priceList,
+			&quantity,
 			&currency,
 			&priceListPrice,
 			&osppPrice,
@@ -402,12 +483,26 @@ OPTION (RECOMPILE);
 			&stock,
 			&onOrder,
 			&commited,
+			&available,
+			&specialScope,
+			&specialKind,
+			&periodFrom,
+			&periodTo,
+			&volumeQty,
 			&priceSource,
 			&finalPrice,
 		); err != nil {
 			return erp.PriceStockResult{}, err
 		}
 
+		// One row per (item, warehouse): price columns repeat, so only the
+		// first row of an item builds it and later rows add warehouses.
+		wh := strings.TrimSpace(warehouseCode.String)
+		if n := len(items); n > 0 && items[n-1].SKU == sku {
+			addWarehouseStock(&items[n-1], wh, stock, commited, onOrder, available)
+			continue
+		}
+
 		prices := map[string]float64{}
 		addFloat(prices, "priceListPrice", priceListPrice)
 		addFloat(prices, "osppPrice", osppPrice)
@@ -417,59 +512,147 @@ OPTION (RECOMPILE);
 		addFloat(prices, "manufacturerDiscount", manufacturerDiscount)
 		addFloat(prices, "finalPrice", finalPrice)
 
-		wh := strings.TrimSpace(warehouseCode.String)
-		if wh == "" {
-			wh = warehouse
-		}
-		stockByWarehouse := map[string]float64{}
-		if stock.Valid {
-			stockByWarehouse[wh] = stock.Float64
-		}
+		rule := priceRule(priceRuleRow{
+			source:        priceSource.String,
+			priceList:     priceList,
+			scope:         specialScope,
+			kind:          specialKind.String,
+			periodFrom:    periodFrom,
+			periodTo:      periodTo,
+			volumeQty:     volumeQty,
+			specialPrice:  osppPrice,
+			specialDisc:   osppDiscount,
+			groupDiscount: bpGroupDiscount,
+			promoDiscount: promoDiscount,
+		})
 
 		details := map[string]any{
 			"cardCode":            cardCodeVal.String,
 			"priceList":           nullFloat(priceList),
 			"currency":            currency.String,
 			"priceSource":         priceSource.String,
-			"warehouseCode":       wh,
 			"osppDiscount":        nullFloat(osppDiscount),
 			"bpGroupDiscountType": bpGroupDiscountType.String,
 			"oedgType":            oedgType.String,
 			"manufacturerName":    manufacturerName.String,
-			"onOrder":             nullFloat(onOrder),
-			"commited":            nullFloat(commited),
+			"quantity":            nullFloat(quantity),
+			"priceRule":           rule,
 		}
 
 		items = append(items, erp.PriceStockItem{
 			SKU:              sku,
 			Prices:           prices,
-			StockByWarehouse: stockByWarehouse,
+			StockByWarehouse: map[string]float64{},
+			WarehouseStock:   map[string]erp.WarehouseStock{},
 			Details:          details,
 		})
+		addWarehouseStock(&items[len(items)-1], wh, stock, commited, onOrder, available)
 	}
 
 	if err := rows.Err(); err != nil {
 		return erp.PriceStockResult{}, err
 	}
 
+	for i := range items {
+		fillWarehouses(&items[i], warehouses)
+	}
 	return erp.PriceStockResult{Items: items}, nil
 }
 
-func buildSkuUnion(skus []string) (string, []any) {
+// addWarehouseStock records one warehouse row of an item. Rows without a
+// warehouse come from items with no OITW entry in the selected warehouses.
+func addWarehouseStock(item *erp.PriceStockItem, wh string, onHand, committed, onOrder, available sql.NullFloat64) {
+	if wh == "" {
+		return
+	}
+	item.StockByWarehouse[wh] = onHand.Float64
+	item.WarehouseStock[wh] = erp.WarehouseStock{
+		OnHand:    onHand.Float64,
+		Committed: committed.Float64,
+		OnOrder:   onOrder.Float64,
+		Available: available.Float64,
+	}
+}
+
+// fillWarehouses reports zero stock for requested warehouses the item has no
+// row in, and keeps the single-warehouse detail fields older clients read.
+func fillWarehouses(item *erp.PriceStockItem, warehouses []string) {
+	for _, wh := range warehouses {
+		if _, ok := item.WarehouseStock[wh]; !ok {
+			item.StockByWarehouse[wh] = 0
+			item.WarehouseStock[wh] = erp.WarehouseStock{}
+		}
+	}
+	if len(warehouses) == 1 {
+		ws := item.WarehouseStock[warehouses[0]]
+		item.Details["warehouseCode"] = warehouses[0]
+		item.Details["onOrder"] = ws.OnOrder
+		item.Details["commited"] = ws.Committed
+	}
+}
+
+// buildWarehouseFilter restricts OITW to the requested warehouses; no
+// warehouses means every warehouse.
+func buildWarehouseFilter(warehouses []string) (string, []any) {
+	if len(warehouses) == 0 {
+		return "", nil
+	}
+	names := make([]string, 0, len(warehouses))
+	args := make([]any, 0, len(warehouses))
+	for i, wh := range warehouses {
+		name := fmt.Sprintf("wh%d", i)
+		names = append(names, "@"+name)
+		args = append(args, sql.Named(name, wh))
+	}
+	return "\n      AND W.WhsCode IN (" + strings.Join(names, ", ") + ")", args
+}
+
+// buildSkuUnion renders the SkuList CTE body: one row per SKU with the
+// quantity it is priced at (1 when the request names none).
+func buildSkuUnion(skus []string, quantities map[string]float64) (string, []any) {
 	parts := make([]string, 0, len(skus))
-	args := make([]any, 0, len(skus))
+	args := make([]any, 0, 2*len(skus))
 	for i, sku := range skus {
 		name := fmt.Sprintf("sku%d", i)
+		qty := fmt.Sprintf("qty%d", i)
 		if i == 0 {
-			parts = append(parts, fmt.Sprintf("SELECT @%s AS sku", name))
+			parts = append(parts, fmt.Sprintf("SELECT @%s AS sku, CAST(@%s AS DECIMAL(19,6)) AS qty", name, qty))
 		} else {
-			parts = append(parts, fmt.Sprintf("UNION ALL SELECT @%s", name))
+			parts = append(parts, fmt.Sprintf("UNION ALL SELECT @%s, CAST(@%s AS DECIMAL(19,6))", name, qty))
 		}
-		args = append(args, sql.Named(name, sku))
+		args = append(args, sql.Named(name, sku), sql.Named(qty, erp.QuantityFor(quantities, sku)))
 	}
 	return strings.Join(parts, "\n        "), args
 }
 
+// buildPriceListSelect renders the RequestedLists CTE body (ListNum, Pref).
+// Requested lists may be given by number or by OPLN.ListName and are tried in
+// order; without any, the customer's OCRD.ListNum is used.
+func buildPriceListSelect(priceLists []string) (string, []any) {
+	lists := make([]string, 0, len(priceLists))
+	for _, pl := range priceLists {
+		if pl = strings.TrimSpace(pl); pl != "" {
+			lists = append(lists, pl)
+		}
+	}
+	if len(lists) == 0 {
+		return "SELECT ListNum, 0 AS Pref FROM Cust", nil
+	}
+
+	values := make([]string, 0, len(lists))
+	args := make([]any, 0, len(lists))
+	for i, pl := range lists {
+		name := fmt.Sprintf("pl%d", i)
+		values = append(values, fmt.Sprintf("(@%s, %d)", name, i))
+		args = append(args, sql.Named(name, pl))
+	}
+	return fmt.Sprintf(`SELECT L.ListNum, MIN(R.Pref) AS Pref
+        FROM OPLN AS L WITH (NOLOCK)
+        INNER JOIN (VALUES %s) AS R(Val, Pref)
+            ON CONVERT(NVARCHAR(50), L.ListNum) = R.Val OR L.ListName = R.Val
+        GROUP BY L.ListNum`, strings.Join(values, ", ")), args
+}
+
 func uniqueStrings(values []string) []string {
 	seen := make(map[string]struct{}, len(values))
 	out := make([]string, 0, len(values))
@@ -488,14 +671,70 @@ func uniqueStrings(values []string) []string {
 	return out
 }
 
-func firstNonEmpty(values []string) string {
-	for _, v := range values {
-		v = strings.TrimSpace(v)
-		if v != "" {
-			return v
+// priceRuleRow is the part of a result row that describes which pricing rule
+// produced FinalPrice.
+type priceRuleRow struct {
+	source        string // PriceSource column
+	priceList     sql.NullFloat64
+	scope         sql.NullInt64 // 1 = BP special price, 2 = price list period/volume discount
+	kind          string        // S = special price, P = period, V = volume break
+	periodFrom    sql.NullTime
+	periodTo      sql.NullTime
+	volumeQty     sql.NullFloat64
+	specialPrice  sql.NullFloat64
+	specialDisc   sql.NullFloat64
+	groupDiscount sql.NullFloat64
+	promoDiscount sql.NullFloat64
+}
+
+// priceRule describes the winning rule in the order SAP applies them: BP
+// special prices, then period/volume discounts of the price list, then
+// discount groups and promotions, then the plain price list.
+func priceRule(r priceRuleRow) map[string]any {
+	rule := map[string]any{"priceList": nullFloat(r.priceList)}
+	switch {
+	case strings.HasPrefix(r.source, "OSPP") && r.scope.Valid:
+		switch r.kind {
+		case "V":
+			rule["type"] = "volumeDiscount"
+		case "P":
+			rule["type"] = "periodDiscount"
+		default:
+			rule["type"] = "specialPrice"
+		}
+		if r.scope.Int64 == 1 {
+			rule["scope"] = "businessPartner"
+		} else {
+			rule["scope"] = "priceList"
 		}
+		if r.specialPrice.Valid && r.specialPrice.Float64 > 0 {
+			rule["price"] = r.specialPrice.Float64
+		}
+		rule["discountPct"] = nullFloat(r.specialDisc)
+		if r.kind == "P" || r.kind == "V" {
+			rule["validFrom"] = nullDate(r.periodFrom)
+			rule["validTo"] = nullDate(r.periodTo)
+		}
+		if r.kind == "V" {
+			rule["minQuantity"] = nullFloat(r.volumeQty)
+		}
+	case strings.HasPrefix(r.source, "Discount groups"):
+		rule["type"] = "discountGroup"
+		rule["discountPct"] = nullFloat(r.groupDiscount)
+	case strings.HasPrefix(r.source, "Promo"):
+		rule["type"] = "promotion"
+		rule["discountPct"] = nullFloat(r.promoDiscount)
+	default:
+		rule["type"] = "priceList"
+	}
+	return rule
+}
+
+func nullDate(v sql.NullTime) any {
+	if v.Valid {
+		return v.Time.Format("2006-01-02")
 	}
-	return ""
+	return nil
 }
 
 func addFloat(out map[string]float64, key string, val sql.NullFloat64) {
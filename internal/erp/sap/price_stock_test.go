@@ -0,0 +1,164 @@
+package sap
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"erp-connector/internal/erp"
+)
+
+func namedArgs(args []any) map[string]any {
+	out := make(map[string]any, len(args))
+	for _, a := range args {
+		n := a.(sql.NamedArg)
+		out[n.Name] = n.Value
+	}
+	return out
+}
+
+func TestBuildSkuUnion_Quantities(t *testing.T) {
+	query, args := buildSkuUnion([]string{"A-1", "B-2"}, map[string]float64{"A-1": 12, "B-2": -3})
+	if !strings.Contains(query, "SELECT @sku0 AS sku, CAST(@qty0 AS DECIMAL(19,6)) AS qty") ||
+		!strings.Contains(query, "UNION ALL SELECT @sku1, CAST(@qty1 AS DECIMAL(19,6))") {
+		t.Errorf("query = %s", query)
+	}
+	got := namedArgs(args)
+	if got["sku0"] != "A-1" || got["qty0"] != 12.0 || got["sku1"] != "B-2" || got["qty1"] != 1.0 {
+		t.Errorf("args = %v", got)
+	}
+}
+
+func TestBuildPriceListSelect(t *testing.T) {
+	query, args := buildPriceListSelect(nil)
+	if query != "SELECT ListNum, 0 AS Pref FROM Cust" || args != nil {
+		t.Errorf("default: %q %v", query, args)
+	}
+
+	query, args = buildPriceListSelect([]string{" 3 ", "", "Wholesale"})
+	if !strings.Contains(query, "(VALUES (@pl0, 0), (@pl1, 1))") || !strings.Contains(query, "L.ListName = R.Val") {
+		t.Errorf("query = %s", query)
+	}
+	if strings.Contains(query, "Wholesale") {
+		t.Error("price list name interpolated into SQL")
+	}
+	got := namedArgs(args)
+	if len(got) != 2 || got["pl0"] != "3" || got["pl1"] != "Wholesale" {
+		t.Errorf("args = %v", got)
+	}
+}
+
+func TestPriceRule(t *testing.T) {
+	from := sql.NullTime{Time: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Valid: true}
+	cases := []struct {
+		name string
+		row  priceRuleRow
+		want map[string]any
+	}{
+		{
+			name: "volume break on BP special price",
+			row: priceRuleRow{
+				source: "OSPP explicit price", priceList: sql.NullFloat64{Float64: 1, Valid: true},
+				scope: sql.NullInt64{Int64: 1, Valid: true}, kind: "V", periodFrom: from,
+				volumeQty:    sql.NullFloat64{Float64: 10, Valid: true},
+				specialPrice: sql.NullFloat64{Float64: 8.5, Valid: true},
+				specialDisc:  sql.NullFloat64{Float64: 15, Valid: true},
+			},
+			want: map[string]any{
+				"type": "volumeDiscount", "scope": "businessPartner", "priceList": 1.0,
+				"price": 8.5, "discountPct": 15.0, "validFrom": "2026-01-01", "validTo": nil, "minQuantity": 10.0,
+			},
+		},
+		{
+			name: "price list period discount",
+			row: priceRuleRow{
+				source: "OSPP discount", priceList: sql.NullFloat64{Float64: 2, Valid: true},
+				scope: sql.NullInt64{Int64: 2, Valid: true}, kind: "P", periodFrom: from,
+				specialDisc: sql.NullFloat64{Float64: 5, Valid: true},
+			},
+			want: map[string]any{
+				"type": "periodDiscount", "scope": "priceList", "priceList": 2.0,
+				"discountPct": 5.0, "validFrom": "2026-01-01", "validTo": nil,
+			},
+		},
+		{
+			name: "discount group",
+			row: priceRuleRow{
+				source: "Discount groups (highest)", priceList: sql.NullFloat64{Float64: 1, Valid: true},
+				groupDiscount: sql.NullFloat64{Float64: 7, Valid: true},
+			},
+			want: map[string]any{"type": "discountGroup", "priceList": 1.0, "discountPct": 7.0},
+		},
+		{
+			name: "plain price list",
+			row:  priceRuleRow{source: "Base price list", priceList: sql.NullFloat64{Float64: 4, Valid: true}},
+			want: map[string]any{"type": "priceList", "priceList": 4.0},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := priceRule(tc.row)
+			if len(got) != len(tc.want) {
+				t.Fatalf("rule = %v, want %v", got, tc.want)
+			}
+			for k, v := range tc.want {
+				if got[k] != v {
+					t.Errorf("%s = %v, want %v", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildWarehouseFilter(t *testing.T) {
+	if query, args := buildWarehouseFilter(nil); query != "" || args != nil {
+		t.Errorf("all warehouses: %q %v", query, args)
+	}
+
+	query, args := buildWarehouseFilter([]string{"01", "02"})
+	if !strings.Contains(query, "AND W.WhsCode IN (@wh0, @wh1)") {
+		t.Errorf("query = %s", query)
+	}
+	got := namedArgs(args)
+	if len(got) != 2 || got["wh0"] != "01" || got["wh1"] != "02" {
+		t.Errorf("args = %v", got)
+	}
+}
+
+func TestWarehouseStockBreakdown(t *testing.T) {
+	f := func(v float64) sql.NullFloat64 { return sql.NullFloat64{Float64: v, Valid: true} }
+	item := erp.PriceStockItem{
+		SKU:              "A-1",
+		StockByWarehouse: map[string]float64{},
+		WarehouseStock:   map[string]erp.WarehouseStock{},
+		Details:          map[string]any{},
+	}
+	addWarehouseStock(&item, "01", f(10), f(4), f(2), f(8))
+	addWarehouseStock(&item, "", f(99), f(0), f(0), f(99))
+	fillWarehouses(&item, []string{"01", "02"})
+
+	want := map[string]erp.WarehouseStock{
+		"01": {OnHand: 10, Committed: 4, OnOrder: 2, Available: 8},
+		"02": {},
+	}
+	if len(item.WarehouseStock) != len(want) {
+		t.Fatalf("warehouseStock = %v", item.WarehouseStock)
+	}
+	for wh, ws := range want {
+		if item.WarehouseStock[wh] != ws {
+			t.Errorf("%s = %+v, want %+v", wh, item.WarehouseStock[wh], ws)
+		}
+	}
+	if item.StockByWarehouse["01"] != 10 || item.StockByWarehouse["02"] != 0 {
+		t.Errorf("stockByWarehouse = %v", item.StockByWarehouse)
+	}
+	if _, ok := item.Details["warehouseCode"]; ok {
+		t.Error("single-warehouse details set for a multi-warehouse request")
+	}
+
+	fillWarehouses(&item, []string{"01"})
+	if item.Details["warehouseCode"] != "01" || item.Details["commited"] != 4.0 || item.Details["onOrder"] != 2.0 {
+		t.Errorf("details = %v", item.Details)
+	}
+}
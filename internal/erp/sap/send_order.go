@@ -0,0 +1,176 @@
+package sap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"erp-connector/internal/erp"
+	"erp-connector/internal/logger"
+)
+
+// documentObject maps a connector documentType to the Service Layer entity
+// set (ORDR / OQUT / ORDN) and the object code a draft (ODRF) of it carries.
+type documentObject struct {
+	entity     string
+	objectCode string
+}
+
+var documentObjects = map[string]documentObject{
+	"ORDER":  {entity: "Orders", objectCode: "oOrders"},
+	"QUOATE": {entity: "Quotations", objectCode: "oQuotations"},
+	"RETURN": {entity: "Returns", objectCode: "oReturns"},
+}
+
+const draftsEntity = "Drafts"
+
+// OrderOptions are the per-company settings used when creating documents.
+type OrderOptions struct {
+	Drafts           bool
+	DefaultWarehouse string
+}
+
+type slDocument struct {
+	DocObjectCode   string   `json:"DocObjectCode,omitempty"`
+	CardCode        string   `json:"CardCode"`
+	DocDate         string   `json:"DocDate,omitempty"`
+	DocDueDate      string   `json:"DocDueDate,omitempty"`
+	NumAtCard       string   `json:"NumAtCard,omitempty"`
+	Comments        string   `json:"Comments,omitempty"`
+	DiscountPercent float64  `json:"DiscountPercent"`
+	DocumentLines   []slLine `json:"DocumentLines"`
+}
+
+type slLine struct {
+	ItemCode        string  `json:"ItemCode"`
+	Quantity        float64 `json:"Quantity"`
+	UnitPrice       float64 `json:"UnitPrice"`
+	DiscountPercent float64 `json:"DiscountPercent"`
+	WarehouseCode   string  `json:"WarehouseCode,omitempty"`
+}
+
+type slCreated struct {
+	DocEntry int64  `json:"DocEntry"`
+	DocNum   int64  `json:"DocNum"`
+	CardCode string `json:"CardCode"`
+	CardName string `json:"CardName"`
+	Address  string `json:"Address"`
+}
+
+// SubmitOrder creates the document through Service Layer in one POST, so
+// header and lines are committed (or rejected) together. The result carries
+// SAP's DocNum as OrderNumber and DocumentNumber, and names the created object
+// with its DocEntry (e.g. Orders(123)) in Entity.
+func SubmitOrder(ctx context.Context, client *ServiceLayerClient, opts OrderOptions, req erp.OrderRequest) (*erp.OrderResult, error) {
+	if client == nil {
+		return nil, errors.New("sap service layer client is required")
+	}
+	obj, ok := documentObjects[req.DocumentType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported documentType %q", req.DocumentType)
+	}
+	if strings.TrimSpace(req.UserExtID) == "" {
+		return nil, errors.New("userExtId is required")
+	}
+	if len(req.Details) == 0 {
+		return nil, errors.New("order has no lines")
+	}
+
+	doc, err := buildDocument(opts, req)
+	if err != nil {
+		return nil, err
+	}
+	entity := obj.entity
+	if opts.Drafts {
+		entity = draftsEntity
+		doc.DocObjectCode = obj.objectCode
+	}
+
+	var created slCreated
+	if err := client.post(ctx, entity, doc, &created); err != nil {
+		return nil, fmt.Errorf("create %s: %w", entity, err)
+	}
+	if created.DocEntry == 0 {
+		return nil, fmt.Errorf("create %s: response has no DocEntry", entity)
+	}
+	return &erp.OrderResult{
+		OrderNumber:    created.DocNum,
+		DocumentNumber: strconv.FormatInt(created.DocNum, 10),
+		Entity:         fmt.Sprintf("%s(%d)", entity, created.DocEntry),
+		Account: erp.AccountInfo{
+			AccountKey: created.CardCode,
+			FullName:   created.CardName,
+			Address:    created.Address,
+		},
+	}, nil
+}
+
+// buildDocument maps the order to a Service Layer document. Prices are sent
+// as list price plus line discount, matching what the Hasavshevet importer
+// receives, so SAP recomputes totals with its own rounding. HistoryID goes to
+// NumAtCard (customer reference) to link the document to the connector order.
+// The document currency is left to the business partner's currency.
+func buildDocument(opts OrderOptions, req erp.OrderRequest) (slDocument, error) {
+	docDate, err := slDate(req.CreatedDate)
+	if err != nil {
+		return slDocument{}, fmt.Errorf("createdDate: %w", err)
+	}
+	dueDate, err := slDate(req.DueDate)
+	if err != nil {
+		return slDocument{}, fmt.Errorf("dueDate: %w", err)
+	}
+	if dueDate == "" {
+		// Sales orders require a delivery date; default to the document date
+		// (SAP itself defaults DocDate to today when both are empty).
+		dueDate = docDate
+	}
+
+	lines := make([]slLine, 0, len(req.Details))
+	for _, d := range req.Details {
+		lines = append(lines, slLine{
+			ItemCode:        d.SKU,
+			Quantity:        d.Quantity,
+			UnitPrice:       d.OriginalPrice,
+			DiscountPercent: d.Discount,
+			WarehouseCode:   opts.DefaultWarehouse,
+		})
+	}
+
+	return slDocument{
+		CardCode:        req.UserExtID,
+		DocDate:         docDate,
+		DocDueDate:      dueDate,
+		NumAtCard:       req.HistoryID,
+		Comments:        strings.TrimSpace(req.Comment),
+		DiscountPercent: req.Discount,
+		DocumentLines:   lines,
+	}, nil
+}
+
+// slDate converts the API's date (YYYY-MM-DD or RFC 3339) to the
+// Service Layer's YYYY-MM-DD. Empty stays empty.
+func slDate(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.Format("2006-01-02"), nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return "", fmt.Errorf("invalid date %q", s)
+	}
+	return t.Format("2006-01-02"), nil
+}
+
+// NewOrderQueue returns the shared async order queue bound to client, running
+// hooks (PDF, print, email) after each created document.
+func NewOrderQueue(client *ServiceLayerClient, opts OrderOptions, log logger.LoggerService, hooks ...erp.PostOrderHook) *erp.OrderQueue {
+	return erp.NewOrderQueue("sap", func(ctx context.Context, req erp.OrderRequest) (*erp.OrderResult, error) {
+		return SubmitOrder(ctx, client, opts, req)
+	}, log, hooks...)
+}
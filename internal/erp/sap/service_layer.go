@@ -0,0 +1,229 @@
+package sap
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"erp-connector/internal/config"
+)
+
+const (
+	defaultServiceLayerTimeout = 30 * time.Second
+	maxServiceLayerResponse    = 16 << 20
+)
+
+// ServiceLayerError is a non-2xx response from the SAP Business One Service
+// Layer.
+type ServiceLayerError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *ServiceLayerError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("sap service layer: %d %s: %s", e.Status, e.Code, e.Message)
+	}
+	return fmt.Sprintf("sap service layer: %d: %s", e.Status, e.Message)
+}
+
+// ServiceLayerClient is a minimal Service Layer client bound to one company
+// database. It logs in lazily, keeps the B1SESSION (and load balancer
+// ROUTEID) cookies in a jar and logs in again once when the session expires.
+type ServiceLayerClient struct {
+	baseURL   *url.URL
+	companyDB string
+	user      string
+	pass      string
+	http      *http.Client
+
+	mu       sync.Mutex
+	loggedIn bool
+}
+
+// NewServiceLayerClient validates cfg and returns a client. companyDB falls
+// back to the SQL database name. It does not contact the server.
+func NewServiceLayerClient(cfg config.SAPConfig, companyDB, password string) (*ServiceLayerClient, error) {
+	raw := strings.TrimSpace(cfg.ServiceLayerURL)
+	if raw == "" {
+		return nil, errors.New("sap.serviceLayerURL is required")
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("sap.serviceLayerURL %q must be an absolute http(s) URL", raw)
+	}
+	if strings.TrimSpace(cfg.User) == "" {
+		return nil, errors.New("sap.user is required")
+	}
+	if c := strings.TrimSpace(cfg.CompanyDB); c != "" {
+		companyDB = c
+	}
+	if strings.TrimSpace(companyDB) == "" {
+		return nil, errors.New("sap.companyDB (or db.database) is required")
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+
+	timeout := defaultServiceLayerTimeout
+	if cfg.TimeoutSeconds > 0 {
+		timeout = time.Duration(cfg.TimeoutSeconds) * time.Second
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.TrustServerCertificate {
+		// Service Layer ships with a self-signed certificate by default.
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	jar, _ := cookiejar.New(nil)
+
+	return &ServiceLayerClient{
+		baseURL:   u,
+		companyDB: strings.TrimSpace(companyDB),
+		user:      strings.TrimSpace(cfg.User),
+		pass:      password,
+		http:      &http.Client{Timeout: timeout, Transport: transport, Jar: jar},
+	}, nil
+}
+
+// Ping logs in to verify URL, company and credentials.
+func (c *ServiceLayerClient) Ping(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.login(ctx)
+}
+
+// post creates an entity and decodes the created entity.
+func (c *ServiceLayerClient) post(ctx context.Context, entity string, body, out any) error {
+	return c.call(ctx, http.MethodPost, entity, body, out)
+}
+
+// call runs one request within a session, logging in first when needed and
+// once more if Service Layer reports the session as expired.
+func (c *ServiceLayerClient) call(ctx context.Context, method, entity string, body, out any) error {
+	c.mu.Lock()
+	if !c.loggedIn {
+		if err := c.login(ctx); err != nil {
+			c.mu.Unlock()
+			return err
+		}
+	}
+	c.mu.Unlock()
+
+	err := c.do(ctx, method, entity, body, out)
+	var slErr *ServiceLayerError
+	if !errors.As(err, &slErr) || slErr.Status != http.StatusUnauthorized {
+		return err
+	}
+
+	c.mu.Lock()
+	err = c.login(ctx)
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, method, entity, body, out)
+}
+
+// login must be called with c.mu held.
+func (c *ServiceLayerClient) login(ctx context.Context) error {
+	c.loggedIn = false
+	body := map[string]string{
+		"CompanyDB": c.companyDB,
+		"UserName":  c.user,
+		"Password":  c.pass,
+	}
+	if err := c.do(ctx, http.MethodPost, "Login", body, nil); err != nil {
+		return fmt.Errorf("login: %w", err)
+	}
+	c.loggedIn = true
+	return nil
+}
+
+func (c *ServiceLayerClient) do(ctx context.Context, method, entity string, body, out any) error {
+	var rd io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		rd = bytes.NewReader(b)
+	}
+
+	u := *c.baseURL
+	u.Path += entity
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), rd)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("sap service layer: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxServiceLayerResponse))
+	if err != nil {
+		return fmt.Errorf("sap service layer: read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return parseServiceLayerError(resp.StatusCode, data)
+	}
+	if out == nil || len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("sap service layer: decode response: %w", err)
+	}
+	return nil
+}
+
+// parseServiceLayerError understands both error shapes Service Layer uses:
+// v1 {"error":{"code":-10,"message":{"lang":"en-us","value":"..."}}} and
+// v2 {"error":{"code":"-10","message":"..."}}, falling back to the raw body.
+func parseServiceLayerError(status int, data []byte) error {
+	var body struct {
+		Error struct {
+			Code    json.RawMessage `json:"code"`
+			Message json.RawMessage `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(data, &body) == nil && len(body.Error.Message) > 0 {
+		code := strings.Trim(string(body.Error.Code), `"`)
+		var msg string
+		if json.Unmarshal(body.Error.Message, &msg) != nil {
+			var v1 struct {
+				Value string `json:"value"`
+			}
+			_ = json.Unmarshal(body.Error.Message, &v1)
+			msg = v1.Value
+		}
+		if msg != "" {
+			return &ServiceLayerError{Status: status, Code: code, Message: msg}
+		}
+	}
+
+	msg := strings.TrimSpace(string(data))
+	if len(msg) > 500 {
+		msg = msg[:500]
+	}
+	if msg == "" {
+		msg = http.StatusText(status)
+	}
+	return &ServiceLayerError{Status: status, Message: msg}
+}
@@ -0,0 +1,296 @@
+package sap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"erp-connector/internal/config"
+	"erp-connector/internal/erp"
+)
+
+// serviceLayerStandIn is a minimal in-memory stand-in for the SAP Business One
+// Service Layer: Login issues a B1SESSION cookie, document POSTs require it
+// and return the created document with the next DocEntry/DocNum.
+type serviceLayerStandIn struct {
+	t *testing.T
+
+	mu       sync.Mutex
+	logins   int
+	sessions map[string]bool
+	posted   map[string][]map[string]any
+	nextDoc  int64
+	// failWith, when set, is returned as a Service Layer error for document POSTs.
+	failWith string
+}
+
+func (s *serviceLayerStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entity := strings.TrimPrefix(r.URL.Path, "/b1s/v1/")
+	var body map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "bad json", http.StatusBadRequest)
+		return
+	}
+
+	if entity == "Login" {
+		if body["CompanyDB"] != "SBODEMO" || body["UserName"] != "manager" || body["Password"] != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":{"code":-304,"message":{"lang":"en-us","value":"Fail to get DB Credentials"}}}`))
+			return
+		}
+		s.logins++
+		id := fmt.Sprintf("session-%d", s.logins)
+		s.sessions[id] = true
+		http.SetCookie(w, &http.Cookie{Name: "B1SESSION", Value: id, Path: "/b1s/v1"})
+		_, _ = w.Write([]byte(`{"SessionId":"` + id + `","SessionTimeout":30}`))
+		return
+	}
+
+	if c, err := r.Cookie("B1SESSION"); err != nil || !s.sessions[c.Value] {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":{"code":301,"message":{"lang":"en-us","value":"Invalid session."}}}`))
+		return
+	}
+	if s.failWith != "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":{"code":"-10","message":"` + s.failWith + `"}}`))
+		return
+	}
+
+	s.nextDoc++
+	s.posted[entity] = append(s.posted[entity], body)
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"DocEntry": 500 + s.nextDoc,
+		"DocNum":   1000 + s.nextDoc,
+		"CardCode": body["CardCode"],
+		"CardName": "Acme Ltd",
+		"Address":  "1 Main St",
+	})
+}
+
+// expireSessions simulates Service Layer dropping every session (timeout or
+// restart).
+func (s *serviceLayerStandIn) expireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions = map[string]bool{}
+}
+
+func newServiceLayerStandIn(t *testing.T) (*serviceLayerStandIn, *ServiceLayerClient) {
+	t.Helper()
+	s := &serviceLayerStandIn{t: t, sessions: map[string]bool{}, posted: map[string][]map[string]any{}}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+
+	c, err := NewServiceLayerClient(config.SAPConfig{
+		ServiceLayerURL: srv.URL + "/b1s/v1",
+		User:            "manager",
+	}, "SBODEMO", "secret")
+	if err != nil {
+		t.Fatalf("NewServiceLayerClient: %v", err)
+	}
+	return s, c
+}
+
+func testOrder(docType string) erp.OrderRequest {
+	return erp.OrderRequest{
+		DocumentType: docType,
+		UserExtID:    "C20000",
+		CreatedDate:  "2026-03-01",
+		DueDate:      "2026-03-05T10:00:00Z",
+		Comment:      " deliver to gate 2 ",
+		Discount:     5,
+		HistoryID:    "hist-42",
+		Details: []erp.OrderLineItem{
+			{SKU: "A-1", Quantity: 3, OriginalPrice: 10, Discount: 10},
+			{SKU: "B-2", Quantity: 1, OriginalPrice: 99.5},
+		},
+	}
+}
+
+// TestSubmitOrder_Order verifies login, the ORDR body and the returned numbers.
+func TestSubmitOrder_Order(t *testing.T) {
+	s, c := newServiceLayerStandIn(t)
+	res, err := SubmitOrder(context.Background(), c, OrderOptions{DefaultWarehouse: "01"}, testOrder("ORDER"))
+	if err != nil {
+		t.Fatalf("SubmitOrder: %v", err)
+	}
+	if res.OrderNumber != 1001 || res.DocumentNumber != "1001" || res.Entity != "Orders(501)" {
+		t.Errorf("result = %+v", res)
+	}
+	if res.Account.AccountKey != "C20000" || res.Account.FullName != "Acme Ltd" {
+		t.Errorf("account = %+v", res.Account)
+	}
+
+	docs := s.posted["Orders"]
+	if len(docs) != 1 {
+		t.Fatalf("posted = %v", s.posted)
+	}
+	doc := docs[0]
+	if doc["CardCode"] != "C20000" || doc["DocDate"] != "2026-03-01" || doc["DocDueDate"] != "2026-03-05" ||
+		doc["NumAtCard"] != "hist-42" || doc["Comments"] != "deliver to gate 2" || doc["DiscountPercent"] != 5.0 {
+		t.Errorf("header = %v", doc)
+	}
+	if _, ok := doc["DocObjectCode"]; ok {
+		t.Errorf("final document carries DocObjectCode: %v", doc)
+	}
+	lines, _ := doc["DocumentLines"].([]any)
+	if len(lines) != 2 {
+		t.Fatalf("lines = %v", doc["DocumentLines"])
+	}
+	first := lines[0].(map[string]any)
+	if first["ItemCode"] != "A-1" || first["Quantity"] != 3.0 || first["UnitPrice"] != 10.0 ||
+		first["DiscountPercent"] != 10.0 || first["WarehouseCode"] != "01" {
+		t.Errorf("line = %v", first)
+	}
+}
+
+// TestSubmitOrder_DocumentTypesAndDrafts verifies the entity per documentType
+// and that drafts go to Drafts with the document's object code.
+func TestSubmitOrder_DocumentTypesAndDrafts(t *testing.T) {
+	s, c := newServiceLayerStandIn(t)
+	for docType, want := range map[string]string{"ORDER": "oOrders", "QUOATE": "oQuotations", "RETURN": "oReturns"} {
+		if _, err := SubmitOrder(context.Background(), c, OrderOptions{}, testOrder(docType)); err != nil {
+			t.Fatalf("%s: %v", docType, err)
+		}
+		res, err := SubmitOrder(context.Background(), c, OrderOptions{Drafts: true}, testOrder(docType))
+		if err != nil {
+			t.Fatalf("%s draft: %v", docType, err)
+		}
+		if !strings.HasPrefix(res.Entity, "Drafts(") {
+			t.Errorf("%s draft entity = %q", docType, res.Entity)
+		}
+		drafts := s.posted["Drafts"]
+		if got := drafts[len(drafts)-1]["DocObjectCode"]; got != want {
+			t.Errorf("%s draft DocObjectCode = %v, want %s", docType, got, want)
+		}
+	}
+	if len(s.posted["Orders"]) != 1 || len(s.posted["Quotations"]) != 1 || len(s.posted["Returns"]) != 1 {
+		t.Errorf("posted = %v", s.posted)
+	}
+	if s.logins != 1 {
+		t.Errorf("logins = %d, want the session reused", s.logins)
+	}
+}
+
+// TestSubmitOrder_SessionExpired verifies one transparent re-login.
+func TestSubmitOrder_SessionExpired(t *testing.T) {
+	s, c := newServiceLayerStandIn(t)
+	if _, err := SubmitOrder(context.Background(), c, OrderOptions{}, testOrder("ORDER")); err != nil {
+		t.Fatal(err)
+	}
+	s.expireSessions()
+	if _, err := SubmitOrder(context.Background(), c, OrderOptions{}, testOrder("ORDER")); err != nil {
+		t.Fatalf("after expiry: %v", err)
+	}
+	if s.logins != 2 || len(s.posted["Orders"]) != 2 {
+		t.Errorf("logins = %d posted = %d", s.logins, len(s.posted["Orders"]))
+	}
+}
+
+// TestSubmitOrder_Errors verifies Service Layer errors in both shapes surface
+// as ServiceLayerError.
+func TestSubmitOrder_Errors(t *testing.T) {
+	s, c := newServiceLayerStandIn(t)
+	s.failWith = "Item A-1 is inactive"
+	_, err := SubmitOrder(context.Background(), c, OrderOptions{}, testOrder("ORDER"))
+	var slErr *ServiceLayerError
+	if !errors.As(err, &slErr) || slErr.Code != "-10" || slErr.Message != "Item A-1 is inactive" {
+		t.Errorf("err = %v", err)
+	}
+
+	bad, _ := NewServiceLayerClient(config.SAPConfig{ServiceLayerURL: c.baseURL.String(), User: "manager"}, "SBODEMO", "wrong")
+	err = bad.Ping(context.Background())
+	if !errors.As(err, &slErr) || slErr.Status != http.StatusUnauthorized || slErr.Message != "Fail to get DB Credentials" {
+		t.Errorf("login err = %v", err)
+	}
+
+	if _, err := SubmitOrder(context.Background(), c, OrderOptions{}, erp.OrderRequest{DocumentType: "INVOICE"}); err == nil {
+		t.Error("unsupported documentType accepted")
+	}
+}
+
+type recordingHook struct {
+	mu     sync.Mutex
+	result *erp.OrderResult
+}
+
+func (h *recordingHook) AfterOrder(_ context.Context, _ erp.OrderRequest, r *erp.OrderResult) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.result = r
+	return nil
+}
+
+// TestOrderQueue_RunsHooks verifies the async queue records SAP's number and
+// runs the post-order hooks with it.
+func TestOrderQueue_RunsHooks(t *testing.T) {
+	_, c := newServiceLayerStandIn(t)
+	hook := &recordingHook{}
+	q := NewOrderQueue(c, OrderOptions{}, noopLogger{}, hook)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q.Start(ctx)
+
+	id, err := q.Submit(testOrder("ORDER"))
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if r, _ := q.Status(id); r.Status == erp.JobStatusDone {
+			if r.OrderNumber != 1001 || r.DocumentNumber != "1001" {
+				t.Errorf("job = %+v", r)
+			}
+			hook.mu.Lock()
+			got := hook.result
+			hook.mu.Unlock()
+			if got == nil || got.OrderNumber != 1001 {
+				t.Errorf("hook result = %+v", got)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("job did not finish")
+}
+
+func TestNewServiceLayerClient_Validation(t *testing.T) {
+	cases := []struct {
+		cfg       config.SAPConfig
+		companyDB string
+	}{
+		{config.SAPConfig{User: "manager"}, "SBODEMO"},
+		{config.SAPConfig{ServiceLayerURL: "sap-host:50000", User: "manager"}, "SBODEMO"},
+		{config.SAPConfig{ServiceLayerURL: "https://sap-host:50000/b1s/v1"}, "SBODEMO"},
+		{config.SAPConfig{ServiceLayerURL: "https://sap-host:50000/b1s/v1", User: "manager"}, ""},
+	}
+	for i, tc := range cases {
+		if _, err := NewServiceLayerClient(tc.cfg, tc.companyDB, "x"); err == nil {
+			t.Errorf("case %d: expected error", i)
+		}
+	}
+	c, err := NewServiceLayerClient(config.SAPConfig{ServiceLayerURL: "https://sap-host:50000/b1s/v1", User: "manager", CompanyDB: "OTHER"}, "SBODEMO", "x")
+	if err != nil || c.companyDB != "OTHER" {
+		t.Errorf("companyDB override: %v %+v", err, c)
+	}
+}
+
+type noopLogger struct{}
+
+func (noopLogger) Info(string)         {}
+func (noopLogger) Error(string, error) {}
+func (noopLogger) Warn(string)         {}
+func (noopLogger) Success(string)      {}
+func (noopLogger) Close() error        { return nil }
@@ -6,13 +6,38 @@ type PriceStockRequest struct {
 	Warehouses []string
 	UserExtID  string
 	Date       string
+	// Quantities prices each SKU at the quantity being ordered so quantity
+	// breaks apply. SKUs without an entry are priced at 1.
+	Quantities map[string]float64
+	// DocumentType (ORDER, QUOATE, RETURN) prices as that document would be
+	// priced on import. Empty uses the ERP's default pricing document.
+	DocumentType string
+}
+
+// QuantityFor returns the requested quantity of sku, or 1.
+func QuantityFor(quantities map[string]float64, sku string) float64 {
+	if q := quantities[sku]; q > 0 {
+		return q
+	}
+	return 1
 }
 
 type PriceStockItem struct {
 	SKU              string
 	Prices           map[string]float64
 	StockByWarehouse map[string]float64
-	Details          map[string]any
+	// WarehouseStock breaks StockByWarehouse down further for ERPs that
+	// track committed and on-order quantities. Nil when not available.
+	WarehouseStock map[string]WarehouseStock
+	Details        map[string]any
+}
+
+// WarehouseStock is the stock position of an item in one warehouse.
+type WarehouseStock struct {
+	OnHand    float64
+	Committed float64
+	OnOrder   float64
+	Available float64 // OnHand - Committed + OnOrder
 }
 
 type PriceStockResult struct {